@@ -6,8 +6,8 @@ create table orders
     trade_id             varchar(32)    not null comment 'epusdt订单号',
     order_id             varchar(32)    not null comment '客户交易id',
     block_transaction_id varchar(128)   null comment '区块唯一编号',
-    actual_amount        decimal(19, 4) not null comment '订单实际需要支付的金额，保留4位小数',
-    amount               decimal(19, 4) not null comment '订单金额，保留4位小数',
+    actual_amount        decimal(19, 6) not null comment '订单实际需要支付的金额',
+    amount               decimal(19, 6) not null comment '订单金额',
     token                varchar(50)    not null comment '所属钱包地址',
     status               int default 1  not null comment '1：等待支付，2：支付成功，3：已过期',
     notify_url           varchar(128)   not null comment '异步回调地址',
@@ -55,6 +55,7 @@ create table ktv_authorizes
     customer_wallet     varchar(100)   null comment '客户钱包地址',
     merchant_wallet     varchar(100)   not null comment '商家收款钱包',
     chain               varchar(20)    default 'TRON' not null comment '链标识（TRON/BSC/ETH/POLYGON）',
+    token_symbol        varchar(20)    default 'USDT' null comment '代币符号',
     authorized_usdt     decimal(19, 6) not null comment '授权额度(USDT)',
     used_usdt           decimal(19, 6) default 0 not null comment '已使用额度(USDT)',
     remaining_usdt      decimal(19, 6) not null comment '剩余额度(USDT)',
@@ -91,7 +92,8 @@ create table ktv_deductions
     auth_no      varchar(50)    not null comment '授权编号',
     password     varchar(20)    not null comment '密码凭证',
     amount_usdt  decimal(19, 6) not null comment '扣款金额(USDT)',
-    amount_cny   decimal(19, 2) not null comment '扣款金额(CNY)',
+    amount_cny   decimal(19, 6) not null comment '扣款金额(CNY)',
+    fee_amount   decimal(19, 6) default 0 not null comment '平台手续费(USDT)',
     tx_hash      varchar(128)   null comment '扣款交易哈希',
     status       int            default 1 not null comment '1:处理中 2:成功 3:失败',
     fail_reason  varchar(255)   null comment '失败原因',
@@ -0,0 +1,35 @@
+-- 金额字段由浮点改为 decimal(19, 6)
+-- MODIFY 按 6 位小数四舍五入转换已有数据，已是 decimal 的列只调整精度
+-- 程序启动时会自动完成同样的转换，手工维护表结构时执行本脚本
+
+alter table orders
+    modify actual_amount decimal(19, 6) not null comment '订单实际需要支付的金额',
+    modify amount decimal(19, 6) not null comment '订单金额';
+
+alter table merchant_withdrawals
+    modify amount decimal(19, 6) null;
+
+alter table authorizations
+    modify authorized_usdt decimal(19, 6) null,
+    modify used_usdt decimal(19, 6) default 0 null,
+    modify remaining_usdt decimal(19, 6) null;
+
+alter table deductions
+    modify amount_usdt decimal(19, 6) null,
+    modify amount_cny decimal(19, 6) null;
+
+alter table subscriptions
+    modify amount_usdt decimal(19, 6) null;
+
+alter table ktv_authorizes
+    modify authorized_usdt decimal(19, 6) not null comment '授权额度(USDT)',
+    modify used_usdt decimal(19, 6) default 0 not null comment '已使用额度(USDT)',
+    modify remaining_usdt decimal(19, 6) not null comment '剩余额度(USDT)';
+
+alter table ktv_deductions
+    modify amount_usdt decimal(19, 6) not null comment '扣款金额(USDT)',
+    modify amount_cny decimal(19, 6) not null comment '扣款金额(CNY)';
+
+-- KTV扣款记录增加平台手续费
+alter table ktv_deductions
+    add fee_amount decimal(19, 6) default 0 not null comment '平台手续费(USDT)' after amount_cny;
@@ -6,6 +6,7 @@ import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/service"
 	"github.com/assimon/luuu/mq"
+	"github.com/assimon/luuu/mq/handle"
 	"github.com/assimon/luuu/task"
 	"github.com/assimon/luuu/telegram"
 	"github.com/assimon/luuu/util/chain"
@@ -27,6 +28,7 @@ func Start() {
 	// dao.RedisInit()
 	dao.Init()
 	// 队列启动
+	handle.SetSubscriptionCharger(service.ChargeSubscription)
 	mq.Start()
 	// telegram机器人启动
 	if config.TgBotToken != "" && config.TgManage != 0 {
@@ -34,6 +36,10 @@ func Start() {
 	}
 	// 初始化默认管理员
 	_ = service.EnsureDefaultAdmin()
+	// 账本上线前的商家余额记入期初
+	if err := service.InitMerchantLedger(); err != nil {
+		log.Sugar.Errorf("[ledger] 初始化商家期初余额失败, err=%v", err)
+	}
 	// 定时任务
 	go task.Start()
 	err := command.Execute()
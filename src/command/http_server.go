@@ -9,6 +9,7 @@ import (
 	"github.com/assimon/luuu/util/constant"
 	luluHttp "github.com/assimon/luuu/util/http"
 	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/render"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	"github.com/spf13/cobra"
@@ -47,6 +48,8 @@ func HttpServerStart() {
 	// e.HTTPErrorHandler = customHTTPErrorHandler
 	// 中间件注册
 	MiddlewareRegister(e)
+	// 页面模板渲染
+	e.Renderer = render.NewTemplateRenderer(config.AppDebug)
 	// 路由注册
 	route.RegisterRoute(e)
 	// 静态目录注册
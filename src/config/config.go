@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
@@ -159,6 +161,14 @@ func GetApiAuthToken() string {
 	return viper.GetString("api_auth_token")
 }
 
+// IsApiGlobalTokenEnabled 未携带商家ID的签名请求是否仍可用全局 api_auth_token 校验（默认允许，兼容旧接入）
+func IsApiGlobalTokenEnabled() bool {
+	if !viper.IsSet("api_global_token_enabled") {
+		return true
+	}
+	return viper.GetBool("api_global_token_enabled")
+}
+
 func GetUsdtRate() float64 {
 	forcedUsdtRate := viper.GetFloat64("forced_usdt_rate")
 	if forcedUsdtRate > 0 {
@@ -170,6 +180,84 @@ func GetUsdtRate() float64 {
 	return UsdtRate
 }
 
+const (
+	CurrencyCny = "CNY"
+	CurrencyUsd = "USD"
+	CurrencyEur = "EUR"
+	CurrencyHkd = "HKD"
+)
+
+// SupportedFiatCurrencies 下单支持的法币
+var SupportedFiatCurrencies = []string{CurrencyCny, CurrencyUsd, CurrencyEur, CurrencyHkd}
+
+var (
+	usdtRates     = map[string]float64{}
+	usdtRatesLock sync.RWMutex
+)
+
+// NormalizeFiatCurrency 规范化法币代码，未填写时按 CNY 处理
+func NormalizeFiatCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return CurrencyCny
+	}
+	return currency
+}
+
+// IsSupportedFiatCurrency 是否为支持的法币
+func IsSupportedFiatCurrency(currency string) bool {
+	currency = NormalizeFiatCurrency(currency)
+	for _, c := range SupportedFiatCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// SetUsdtRateByCurrency 更新某法币兑USDT汇率
+func SetUsdtRateByCurrency(currency string, rate float64) {
+	currency = NormalizeFiatCurrency(currency)
+	usdtRatesLock.Lock()
+	defer usdtRatesLock.Unlock()
+	usdtRates[currency] = rate
+	if currency == CurrencyCny {
+		UsdtRate = rate
+	}
+}
+
+// GetUsdtRateByCurrency 获取某法币兑USDT汇率，forced_usdt_rate_<币种> 优先，未获取到时返回0
+func GetUsdtRateByCurrency(currency string) float64 {
+	currency = NormalizeFiatCurrency(currency)
+	if currency == CurrencyCny {
+		return GetUsdtRate()
+	}
+	forcedUsdtRate := viper.GetFloat64("forced_usdt_rate_" + strings.ToLower(currency))
+	if forcedUsdtRate > 0 {
+		return forcedUsdtRate
+	}
+	usdtRatesLock.RLock()
+	rate := usdtRates[currency]
+	usdtRatesLock.RUnlock()
+	if rate <= 0 && currency == CurrencyUsd {
+		return 1
+	}
+	return rate
+}
+
+// GetUsdtRatePrecision 定时获取的汇率保留的小数位，usdt_rate_precision 未配置时 CNY 保留2位，其他法币保留4位
+func GetUsdtRatePrecision(currency string) int {
+	if raw := strings.TrimSpace(viper.GetString("usdt_rate_precision")); raw != "" {
+		if precision, err := strconv.Atoi(raw); err == nil && precision >= 0 {
+			return precision
+		}
+	}
+	if NormalizeFiatCurrency(currency) == CurrencyCny {
+		return 2
+	}
+	return 4
+}
+
 func GetOrderExpirationTime() int {
 	timer := viper.GetInt("order_expiration_time")
 	if timer <= 0 {
@@ -183,6 +271,173 @@ func GetOrderExpirationTimeDuration() time.Duration {
 	return time.Minute * time.Duration(timer)
 }
 
+// GetOrderAmountTolerance 订单金额容差(USDT)，实收与应付差额在此范围内视为足额支付
+func GetOrderAmountTolerance() float64 {
+	tolerance := viper.GetFloat64("order_amount_tolerance")
+	if tolerance < 0 {
+		return 0
+	}
+	return tolerance
+}
+
+// GetSubscriptionMaxRetries 订阅扣款失败默认最大重试次数
+func GetSubscriptionMaxRetries() int {
+	if !viper.IsSet("subscription_max_retries") {
+		return 3
+	}
+	retries := viper.GetInt("subscription_max_retries")
+	if retries < 0 {
+		return 0
+	}
+	return retries
+}
+
+// GetSubscriptionRetryInterval 订阅扣款失败默认重试间隔(分钟)
+func GetSubscriptionRetryInterval() int {
+	interval := viper.GetInt("subscription_retry_interval")
+	if interval <= 0 {
+		return 1440
+	}
+	return interval
+}
+
+// defaultCallbackRetrySchedule 默认回调重试退避时间表，间隔逐次翻倍
+var defaultCallbackRetrySchedule = []time.Duration{
+	time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute,
+	32 * time.Minute, time.Hour, 2 * time.Hour, 4 * time.Hour, 8 * time.Hour,
+}
+
+// GetCallbackRetrySchedule 回调/事件通知失败后的重试间隔，次数即最大重试次数
+// 兼容旧配置 order_notice_max_retry：设置时作为最大重试次数，时间表不足时按最后一个间隔补齐
+func GetCallbackRetrySchedule() []time.Duration {
+	schedule := parseCallbackRetrySchedule()
+	legacy := strings.TrimSpace(viper.GetString("order_notice_max_retry"))
+	if legacy == "" {
+		return schedule
+	}
+	maxRetry, err := strconv.Atoi(legacy)
+	if err != nil || maxRetry < 0 {
+		return schedule
+	}
+	if maxRetry <= len(schedule) {
+		return schedule[:maxRetry]
+	}
+	capped := make([]time.Duration, maxRetry)
+	copy(capped, schedule)
+	for i := len(schedule); i < maxRetry; i++ {
+		capped[i] = schedule[len(schedule)-1]
+	}
+	return capped
+}
+
+// parseCallbackRetrySchedule 解析 callback_retry_schedule，未配置或无效时使用默认时间表
+func parseCallbackRetrySchedule() []time.Duration {
+	raw := strings.TrimSpace(viper.GetString("callback_retry_schedule"))
+	if raw == "" {
+		return defaultCallbackRetrySchedule
+	}
+	var schedule []time.Duration
+	for _, item := range strings.Split(raw, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(item))
+		if err != nil || d <= 0 {
+			continue
+		}
+		schedule = append(schedule, d)
+	}
+	if len(schedule) == 0 {
+		return defaultCallbackRetrySchedule
+	}
+	return schedule
+}
+
+// GetCallbackRetryDelay 第 n 次重试(从0开始)前的等待时间
+func GetCallbackRetryDelay(n int) time.Duration {
+	schedule := GetCallbackRetrySchedule()
+	if len(schedule) == 0 {
+		return 0
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n >= len(schedule) {
+		n = len(schedule) - 1
+	}
+	return schedule[n]
+}
+
+const (
+	WalletAllocationAmount = "amount" // 固定钱包 + 金额递增区分订单
+	WalletAllocationHd     = "hd"     // 每笔订单从 HD 钱包派生独立收款地址
+)
+
+// GetWalletAllocationMode 钱包分配模式，默认 amount
+func GetWalletAllocationMode() string {
+	if strings.ToLower(strings.TrimSpace(viper.GetString("wallet_allocation_mode"))) == WalletAllocationHd {
+		return WalletAllocationHd
+	}
+	return WalletAllocationAmount
+}
+
+// GetHdEvmXpub EVM 系链账户级扩展公钥 m/44'/60'/0'
+func GetHdEvmXpub() string {
+	return strings.TrimSpace(viper.GetString("hd_evm_xpub"))
+}
+
+// GetHdTronXpub TRON 账户级扩展公钥 m/44'/195'/0'
+func GetHdTronXpub() string {
+	return strings.TrimSpace(viper.GetString("hd_tron_xpub"))
+}
+
+// GetCheckoutBrandName 收银台展示的品牌名称，默认使用 app_name
+func GetCheckoutBrandName() string {
+	name := strings.TrimSpace(viper.GetString("checkout_brand_name"))
+	if name == "" {
+		return GetAppName()
+	}
+	return name
+}
+
+// GetCheckoutLogoUrl 收银台 Logo 地址
+func GetCheckoutLogoUrl() string {
+	return strings.TrimSpace(viper.GetString("checkout_logo_url"))
+}
+
+// GetCheckoutThemeColor 收银台主题色
+func GetCheckoutThemeColor() string {
+	color := strings.TrimSpace(viper.GetString("checkout_theme_color"))
+	if color == "" {
+		return "#1677ff"
+	}
+	return color
+}
+
+// GetPaymentLinkMaxAmount 收款链接未设置上限时，付款人可填写的最大金额
+func GetPaymentLinkMaxAmount() float64 {
+	amount := viper.GetFloat64("payment_link_max_amount")
+	if amount <= 0 {
+		return 10000
+	}
+	return amount
+}
+
+// GetPaymentLinkRateLimit 每个 IP 每分钟通过收款链接下单的次数上限
+func GetPaymentLinkRateLimit() int {
+	limit := viper.GetInt("payment_link_rate_limit")
+	if limit <= 0 {
+		return 10
+	}
+	return limit
+}
+
+// GetCheckoutTemplatePath 收银台模板文件，可替换为自定义模板
+func GetCheckoutTemplatePath() string {
+	path := strings.TrimSpace(viper.GetString("checkout_template_path"))
+	if path == "" {
+		return "static/checkout/checkout_counter.html"
+	}
+	return path
+}
+
 // GetMerchantPrivateKey 获取商家私钥（用于授权扣款）
 func GetMerchantPrivateKey() string {
 	return viper.GetString("merchant_private_key")
@@ -317,6 +572,68 @@ func GetPolygonUsdtDecimals() int {
 	return 6
 }
 
+// GetChainConfirmations 链入账所需确认数，配置项 <链前缀>_confirmations，未配置时使用默认值
+func GetChainConfirmations(chainPrefix string, defaultValue int) int {
+	key := chainPrefix + "_confirmations"
+	if !viper.IsSet(key) {
+		return defaultValue
+	}
+	confirmations := viper.GetInt(key)
+	if confirmations < 0 {
+		return 0
+	}
+	return confirmations
+}
+
+// GetWalletStrategy 收款钱包选择策略，配置项 <链前缀>_wallet_strategy，未配置时使用 wallet_strategy
+func GetWalletStrategy(chainPrefix string) string {
+	if strategy := strings.TrimSpace(viper.GetString(chainPrefix + "_wallet_strategy")); strategy != "" {
+		return strings.ToLower(strategy)
+	}
+	return strings.ToLower(strings.TrimSpace(viper.GetString("wallet_strategy")))
+}
+
+// GetGasAlertThreshold 原生币余额告警阈值，配置项 <链前缀>_gas_alert_threshold，未配置时使用默认值，0 表示不告警
+func GetGasAlertThreshold(chainPrefix string, defaultValue float64) float64 {
+	key := chainPrefix + "_gas_alert_threshold"
+	if !viper.IsSet(key) {
+		return defaultValue
+	}
+	threshold := viper.GetFloat64(key)
+	if threshold < 0 {
+		return 0
+	}
+	return threshold
+}
+
+// GetEnabledTokenSymbols 启用的收款代币符号，默认仅 USDT
+func GetEnabledTokenSymbols() []string {
+	symbols := make([]string, 0)
+	seen := map[string]bool{}
+	for _, symbol := range splitAndTrim(viper.GetString("enabled_tokens")) {
+		symbol = strings.ToUpper(symbol)
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return []string{"USDT"}
+	}
+	return symbols
+}
+
+// GetTokenContract 读取代币合约配置 <链前缀>_<代币>_contract，例如 bsc_usdc_contract
+func GetTokenContract(chainPrefix, symbol string) string {
+	return strings.TrimSpace(viper.GetString(fmt.Sprintf("%s_%s_contract", chainPrefix, strings.ToLower(symbol))))
+}
+
+// GetTokenDecimals 读取代币精度配置 <链前缀>_<代币>_decimals，例如 bsc_usdc_decimals
+func GetTokenDecimals(chainPrefix, symbol string) int {
+	return viper.GetInt(fmt.Sprintf("%s_%s_decimals", chainPrefix, strings.ToLower(symbol)))
+}
+
 func splitAndTrim(raw string) []string {
 	if raw == "" {
 		return nil
@@ -416,4 +733,32 @@ func GetCompanyWallet() string {
 // GetCompanyPrivateKey 获取公司钱包私钥（用于提现自动转账）
 func GetCompanyPrivateKey() string {
 	return CompanyPrivateKey
-}
\ No newline at end of file
+}
+
+// IsSweepEnabled 是否开启收款钱包自动归集
+func IsSweepEnabled() bool {
+	return viper.GetBool("sweep_enabled")
+}
+
+// GetSweepColdAddress 归集目标冷钱包地址 <链前缀>_sweep_address，未配置时该链不归集
+func GetSweepColdAddress(chainPrefix string) string {
+	return strings.TrimSpace(viper.GetString(chainPrefix + "_sweep_address"))
+}
+
+// GetSweepThreshold 归集阈值 <链前缀>_sweep_threshold，收款钱包代币余额达到后归集，默认 100
+func GetSweepThreshold(chainPrefix string) float64 {
+	threshold := viper.GetFloat64(chainPrefix + "_sweep_threshold")
+	if threshold <= 0 {
+		return 100
+	}
+	return threshold
+}
+
+// GetSweepGasMin 归集前收款钱包需持有的原生币数量 <链前缀>_sweep_gas_min，未配置时返回 0 由链上估算
+func GetSweepGasMin(chainPrefix string) float64 {
+	gasMin := viper.GetFloat64(chainPrefix + "_sweep_gas_min")
+	if gasMin < 0 {
+		return 0
+	}
+	return gasMin
+}
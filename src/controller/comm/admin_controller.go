@@ -47,11 +47,15 @@ func (c *BaseCommController) AdminMe(ctx echo.Context) error {
 
 // AdminListUsers 列出管理员
 func (c *BaseCommController) AdminListUsers(ctx echo.Context) error {
-	users, err := data.ListAdminUsers()
+	q, filter, err := bindAdminList(ctx)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, users)
+	result, err := data.ListAdminUsers(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminCreateUser 创建管理员
@@ -143,47 +147,67 @@ func (c *BaseCommController) AdminUpdateUser(ctx echo.Context) error {
 
 // AdminListRoles 列出角色
 func (c *BaseCommController) AdminListRoles(ctx echo.Context) error {
-	roles, err := data.ListAdminRoles()
+	q, filter, err := bindAdminList(ctx)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, roles)
+	result, err := data.ListAdminRoles(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminListOrders 订单列表
 func (c *BaseCommController) AdminListOrders(ctx echo.Context) error {
-	orders, err := data.ListOrders(200)
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListOrders(filter, q)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, orders)
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminListAuthorizations 授权列表
 func (c *BaseCommController) AdminListAuthorizations(ctx echo.Context) error {
-	auths, err := data.ListAuthorizations(200)
+	q, filter, err := bindAdminList(ctx)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, auths)
+	result, err := data.ListAuthorizations(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminListDeductions 扣款列表
 func (c *BaseCommController) AdminListDeductions(ctx echo.Context) error {
-	deducts, err := data.ListDeductions(200)
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListDeductions(filter, q)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, deducts)
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminListCallbacks 回调日志
 func (c *BaseCommController) AdminListCallbacks(ctx echo.Context) error {
-	logs, err := data.ListCallbackLogs()
+	q, filter, err := bindAdminList(ctx)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, logs)
+	result, err := data.ListCallbackLogs(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // OrderDetailAPI 订单详情接口（返回JSON）
@@ -214,13 +238,17 @@ func (c *BaseCommController) AdminOrderDetailAPI(ctx echo.Context) error {
 
 // ==================== 商家管理 ====================
 
-// AdminListMerchants 列出所有商家
+// AdminListMerchants 商家列表
 func (c *BaseCommController) AdminListMerchants(ctx echo.Context) error {
-	merchants, err := data.ListMerchants(500)
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListMerchants(filter, q)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-	return c.SucJson(ctx, merchants)
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
 }
 
 // AdminBanMerchant 封禁/解封商家
@@ -259,24 +287,11 @@ func (c *BaseCommController) AdminBanMerchant(ctx echo.Context) error {
 
 // AdminListWithdrawals 提现列表
 func (c *BaseCommController) AdminListWithdrawals(ctx echo.Context) error {
-	type Request struct {
-		Page     int `query:"page"`
-		PageSize int `query:"page_size"`
-	}
-
-	req := new(Request)
-	if err := ctx.Bind(req); err != nil {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
 		return c.FailJson(ctx, err)
 	}
-
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 50
-	}
-
-	list, total, err := service.GetAllWithdrawals(req.Page, req.PageSize)
+	result, err := service.ListWithdrawals(filter, q)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
@@ -287,8 +302,8 @@ func (c *BaseCommController) AdminListWithdrawals(ctx echo.Context) error {
 		MerchantName string `json:"merchant_name"`
 	}
 
-	var items []WithdrawalItem
-	for _, w := range list {
+	items := make([]WithdrawalItem, 0, len(result.List))
+	for _, w := range result.List {
 		item := WithdrawalItem{MerchantWithdrawal: w}
 		merchant, err := data.GetMerchantByID(w.MerchantID)
 		if err == nil && merchant.ID > 0 {
@@ -297,12 +312,7 @@ func (c *BaseCommController) AdminListWithdrawals(ctx echo.Context) error {
 		items = append(items, item)
 	}
 
-	return c.SucJson(ctx, map[string]interface{}{
-		"list":      items,
-		"total":     total,
-		"page":      req.Page,
-		"page_size": req.PageSize,
-	})
+	return c.adminListJson(ctx, q, items, result.Total, result.NextCursor)
 }
 
 // AdminApproveWithdrawal 批准提现
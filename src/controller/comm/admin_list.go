@@ -0,0 +1,86 @@
+package comm
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/page"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// adminListPageSize 管理后台列表默认每页行数
+const adminListPageSize = 50
+
+// adminListRequest 管理后台列表通用参数
+type adminListRequest struct {
+	page.Query
+	Status     int    `query:"status"`
+	Chain      string `query:"chain"`
+	Wallet     string `query:"wallet"`
+	MerchantID uint64 `query:"merchant_id"`
+	MinAmount  string `query:"min_amount"`
+	MaxAmount  string `query:"max_amount"`
+	StartDate  string `query:"start_date"` // YYYY-MM-DD
+	EndDate    string `query:"end_date"`   // YYYY-MM-DD
+	Keyword    string `query:"keyword"`    // 单号、交易哈希等ID
+}
+
+// bindAdminList 解析管理后台列表的分页、排序和筛选参数
+func bindAdminList(ctx echo.Context) (*page.Query, *data.ListFilter, error) {
+	req := new(adminListRequest)
+	if err := ctx.Bind(req); err != nil {
+		return nil, nil, err
+	}
+	req.Normalize(adminListPageSize)
+	startTime, endTime, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	minAmount, err := parseAmountFilter(req.MinAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxAmount, err := parseAmountFilter(req.MaxAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := &data.ListFilter{
+		Status:     req.Status,
+		Chain:      chain.NormalizeChain(req.Chain),
+		Wallet:     strings.TrimSpace(req.Wallet),
+		MerchantID: req.MerchantID,
+		MinAmount:  minAmount,
+		MaxAmount:  maxAmount,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Keyword:    strings.TrimSpace(req.Keyword),
+	}
+	return &req.Query, filter, nil
+}
+
+// parseAmountFilter 解析金额筛选条件，未填写时为 0（不筛选）
+func parseAmountFilter(raw string) (decimal.Decimal, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return decimal.Zero, nil
+	}
+	amount, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, errors.New("金额格式错误")
+	}
+	return amount, nil
+}
+
+// adminListJson 输出管理后台列表，游标分页时 total 为 -1
+func (c *BaseCommController) adminListJson(ctx echo.Context, q *page.Query, list interface{}, total int64, nextCursor uint64) error {
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":        list,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"next_cursor": nextCursor,
+	})
+}
@@ -3,6 +3,7 @@ package comm
 import (
 	"github.com/assimon/luuu/model/service"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 )
 
 // ==================== 授权支付 API ====================
@@ -10,11 +11,12 @@ import (
 // CreateAuthorization 创建授权请求
 func (c *BaseCommController) CreateAuthorization(ctx echo.Context) error {
 	type Request struct {
-		AmountUsdt   float64 `json:"amount_usdt" validate:"required|gt:0"`
-		TableNo      string  `json:"table_no"`
-		CustomerName string  `json:"customer_name"`
-		Remark       string  `json:"remark"`
-		Chain        string  `json:"chain"`
+		AmountUsdt   decimal.Decimal `json:"amount_usdt" validate:"required|amountGt:0"`
+		TableNo      string          `json:"table_no"`
+		CustomerName string          `json:"customer_name"`
+		Remark       string          `json:"remark"`
+		Chain        string          `json:"chain"`
+		TokenSymbol  string          `json:"token_symbol"`
 	}
 
 	req := new(Request)
@@ -22,7 +24,7 @@ func (c *BaseCommController) CreateAuthorization(ctx echo.Context) error {
 		return c.FailJson(ctx, err)
 	}
 
-	resp, err := service.CreateAuthorization(req.AmountUsdt, req.TableNo, req.CustomerName, req.Remark, req.Chain)
+	resp, err := service.CreateAuthorization(req.AmountUsdt, req.TableNo, req.CustomerName, req.Remark, req.Chain, req.TokenSymbol)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
@@ -73,10 +75,10 @@ func (c *BaseCommController) ConfirmAuthorizationAuto(ctx echo.Context) error {
 // DeductFromAuthorization 从授权中扣款
 func (c *BaseCommController) DeductFromAuthorization(ctx echo.Context) error {
 	type Request struct {
-		Password    string  `json:"password" validate:"required"`
-		AmountCny   float64 `json:"amount_cny" validate:"required|gt:0"`
-		ProductInfo string  `json:"product_info"`
-		OperatorID  string  `json:"operator_id"`
+		Password    string          `json:"password" validate:"required"`
+		AmountCny   decimal.Decimal `json:"amount_cny" validate:"required|amountGt:0"`
+		ProductInfo string          `json:"product_info"`
+		OperatorID  string          `json:"operator_id"`
 	}
 
 	req := new(Request)
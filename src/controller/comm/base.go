@@ -1,9 +1,18 @@
 package comm
 
-import "github.com/assimon/luuu/controller"
+import (
+	"github.com/assimon/luuu/controller"
+	"github.com/labstack/echo/v4"
+)
 
 var Ctrl = &BaseCommController{}
 
 type BaseCommController struct {
 	controller.BaseController
 }
+
+// apiMerchantID 签名接口识别出的商家ID，使用全局密钥签名时为 0
+func apiMerchantID(ctx echo.Context) uint64 {
+	merchantID, _ := ctx.Get("merchant_id").(uint64)
+	return merchantID
+}
@@ -0,0 +1,153 @@
+package comm
+
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/labstack/echo/v4"
+)
+
+// redeliverFailedRequest 批量重发死信回调
+type redeliverFailedRequest struct {
+	StartTime int64 `json:"start_time" validate:"required"`
+	EndTime   int64 `json:"end_time" validate:"required"`
+}
+
+// AdminRedeliverCallback 管理员手动重发回调
+func (c *BaseCommController) AdminRedeliverCallback(ctx echo.Context) error {
+	type Request struct {
+		Id uint64 `json:"id" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.RedeliverCallback(0, req.Id); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "已加入重发队列")
+}
+
+// AdminRedeliverFailedCallbacks 管理员批量重发时间范围内的死信回调
+func (c *BaseCommController) AdminRedeliverFailedCallbacks(ctx echo.Context) error {
+	req := new(redeliverFailedRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	count, err := service.RedeliverFailedCallbacks(0, req.StartTime, req.EndTime)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{"count": count})
+}
+
+// MerchantGetCallbacks 商家回调日志
+func (c *BaseCommController) MerchantGetCallbacks(ctx echo.Context) error {
+	type Request struct {
+		Page         int    `query:"page"`
+		PageSize     int    `query:"page_size"`
+		CallbackType string `query:"callback_type"`
+		DeadLetter   int    `query:"dead_letter"` // 1:仅看死信
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	list, total, err := service.GetMerchantCallbackLogs(merchantID, req.Page, req.PageSize, req.CallbackType, req.DeadLetter)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// MerchantRedeliverCallback 商家手动重发回调
+func (c *BaseCommController) MerchantRedeliverCallback(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var logID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &logID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的回调ID"))
+	}
+	if err := service.RedeliverCallback(merchantID, logID); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "已加入重发队列")
+}
+
+// MerchantRedeliverFailedCallbacks 商家批量重发时间范围内的死信回调
+func (c *BaseCommController) MerchantRedeliverFailedCallbacks(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(redeliverFailedRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	count, err := service.RedeliverFailedCallbacks(merchantID, req.StartTime, req.EndTime)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{"count": count})
+}
+
+// MerchantRedeliverWebhook 商家手动重发事件投递
+func (c *BaseCommController) MerchantRedeliverWebhook(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var deliveryID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &deliveryID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的投递记录ID"))
+	}
+	if err := service.RedeliverWebhookDelivery(merchantID, deliveryID); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "已加入重发队列")
+}
+
+// MerchantGetCallbackAck 获取回调确认规则
+func (c *BaseCommController) MerchantGetCallbackAck(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	resp, err := service.GetMerchantCallbackAck(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// MerchantUpdateCallbackAck 设置回调确认规则
+func (c *BaseCommController) MerchantUpdateCallbackAck(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(request.CallbackAckRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateMerchantCallbackAck(merchantID, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetMerchantCallbackAck(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
@@ -0,0 +1,96 @@
+package comm
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/export"
+	"github.com/assimon/luuu/util/log"
+	"github.com/labstack/echo/v4"
+)
+
+// exportRequest 导出参数，与对应列表接口的筛选参数一致
+type exportRequest struct {
+	Format       string `query:"format"` // csv/xlsx
+	MerchantID   uint64 `query:"merchant_id"`
+	Status       int    `query:"status"`
+	Chain        string `query:"chain"`
+	OrderId      string `query:"order_id"`
+	TxHash       string `query:"tx_hash"`
+	CallbackType string `query:"callback_type"`
+	DeadLetter   int    `query:"dead_letter"`
+	StartDate    string `query:"start_date"` // YYYY-MM-DD
+	EndDate      string `query:"end_date"`   // YYYY-MM-DD
+}
+
+// AdminExport 管理员导出，可按 merchant_id 限定商家
+func (c *BaseCommController) AdminExport(ctx echo.Context) error {
+	req := new(exportRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	return c.streamExport(ctx, operator, req.MerchantID, req)
+}
+
+// MerchantExport 商家导出本商家数据
+func (c *BaseCommController) MerchantExport(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(exportRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("merchant_%d", merchantID)
+	return c.streamExport(ctx, operator, merchantID, req)
+}
+
+// streamExport 边查询边写出文件，完成后记录审计日志
+func (c *BaseCommController) streamExport(ctx echo.Context, operator string, merchantID uint64, req *exportRequest) error {
+	params := &service.ExportParams{
+		Type:         ctx.Param("type"),
+		MerchantID:   merchantID,
+		Status:       req.Status,
+		Chain:        chain.NormalizeChain(req.Chain),
+		OrderId:      req.OrderId,
+		TxHash:       req.TxHash,
+		CallbackType: req.CallbackType,
+		DeadLetter:   req.DeadLetter,
+	}
+	if err := service.CheckExportType(params.Type); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	var err error
+	if params.StartTime, params.EndTime, err = parseDateRange(req.StartDate, req.EndDate); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Format == "" {
+		req.Format = export.FormatCsv
+	}
+	if req.Format != export.FormatCsv && req.Format != export.FormatXlsx {
+		return c.FailJson(ctx, fmt.Errorf("不支持的导出格式"))
+	}
+
+	resp := ctx.Response()
+	filename := fmt.Sprintf("%s_%s.%s", params.Type, time.Now().Format("20060102150405"), req.Format)
+	resp.Header().Set(echo.HeaderContentType, export.ContentType(req.Format))
+	resp.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	resp.WriteHeader(http.StatusOK)
+
+	// 响应头已发出，之后的错误只能中断下载并记录
+	rows := 0
+	w, err := export.NewWriter(req.Format, resp)
+	if err == nil {
+		rows, err = service.Export(w, params)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Sugar.Errorf("[export] %s %s failed: %v", operator, params.Type, err)
+	}
+	service.RecordDataExport(operator, ctx.RealIP(), ctx.Request().UserAgent(), params, req.Format, rows, err)
+	return nil
+}
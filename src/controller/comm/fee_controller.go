@@ -0,0 +1,93 @@
+package comm
+
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/log"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminListFeeSchedules 手续费方案列表，可按商家筛选，merchant_id=0 为全局方案
+func (c *BaseCommController) AdminListFeeSchedules(ctx echo.Context) error {
+	var merchantID *uint64
+	if v := ctx.QueryParam("merchant_id"); v != "" {
+		var id uint64
+		if _, err := fmt.Sscanf(v, "%d", &id); err != nil {
+			return c.FailJson(ctx, fmt.Errorf("无效的商家ID"))
+		}
+		merchantID = &id
+	}
+	list, err := service.ListFeeSchedules(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, list)
+}
+
+// AdminSaveFeeSchedule 新增或更新手续费方案，同一商家同一业务只有一个方案
+func (c *BaseCommController) AdminSaveFeeSchedule(ctx echo.Context) error {
+	req := new(request.FeeScheduleRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	schedule, err := service.SaveFeeSchedule(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	log.AuditLog(log.EventFeeScheduleChange, operator, ctx.RealIP(),
+		fmt.Sprintf("merchant_id=%d biz_type=%s percent=%s fixed=%s status=%d",
+			schedule.MerchantID, schedule.BizType, schedule.Percent, schedule.FixedAmount, schedule.Status))
+	return c.SucJson(ctx, schedule)
+}
+
+// AdminDeleteFeeSchedule 删除手续费方案
+func (c *BaseCommController) AdminDeleteFeeSchedule(ctx echo.Context) error {
+	var id uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &id); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的方案ID"))
+	}
+	if err := service.DeleteFeeSchedule(id); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	log.AuditLog(log.EventFeeScheduleChange, operator, ctx.RealIP(), fmt.Sprintf("delete id=%d", id))
+	return c.SucJson(ctx, "手续费方案已删除")
+}
+
+// MerchantGetFeeSchedules 商家当前生效的手续费方案
+func (c *BaseCommController) MerchantGetFeeSchedules(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	list, err := service.GetMerchantFeeSchedules(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, list)
+}
+
+// MerchantGetFeeStatement 商家对账单，按业务汇总总额、手续费与净额
+func (c *BaseCommController) MerchantGetFeeStatement(ctx echo.Context) error {
+	type Request struct {
+		StartDate string `query:"start_date"`
+		EndDate   string `query:"end_date"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	startTime, endTime, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	list, err := service.GetMerchantFeeStatement(merchantID, startTime, endTime)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, list)
+}
@@ -0,0 +1,60 @@
+package comm
+
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/service"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminListIncomingTransfers 管理员查看入账转账，默认只看未匹配的，match_status=0 查看全部
+func (c *BaseCommController) AdminListIncomingTransfers(ctx echo.Context) error {
+	type Request struct {
+		MatchStatus *int `query:"match_status"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if filter.Status == 0 {
+		filter.Status = mdb.IncomingTransferUnmatched
+		if req.MatchStatus != nil {
+			filter.Status = *req.MatchStatus
+		}
+	}
+	result, err := service.ListIncomingTransfers(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// AdminAttachIncomingTransfer 管理员将未匹配的入账转账关联到订单
+func (c *BaseCommController) AdminAttachIncomingTransfer(ctx echo.Context) error {
+	type Request struct {
+		Id      uint64 `json:"id" validate:"required"`
+		TradeId string `json:"trade_id" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	order, err := service.AttachIncomingTransfer(req.Id, req.TradeId, operator)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"trade_id":        order.TradeId,
+		"status":          order.Status,
+		"received_amount": order.ReceivedAmount,
+	})
+}
@@ -0,0 +1,105 @@
+package comm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/log"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// MerchantGetLedgerEntries 商家账单，记录余额的每一笔变动
+func (c *BaseCommController) MerchantGetLedgerEntries(ctx echo.Context) error {
+	type Request struct {
+		Page      int    `query:"page"`
+		PageSize  int    `query:"page_size"`
+		Account   string `query:"account"`  // available/pending/refunding
+		BizType   string `query:"biz_type"` // 业务类型
+		StartDate string `query:"start_date"`
+		EndDate   string `query:"end_date"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	switch req.Account {
+	case "", mdb.LedgerMerchantAvailable, mdb.LedgerMerchantPending, mdb.LedgerMerchantRefunding:
+	default:
+		return c.FailJson(ctx, errors.New("account 只能为 available、pending 或 refunding"))
+	}
+	startTime, endTime, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	filter := &data.LedgerEntryFilter{
+		Account:   req.Account,
+		BizType:   req.BizType,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	list, total, err := service.GetMerchantLedgerEntries(merchantID, req.Page, req.PageSize, filter)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// AdminListLedgerEntries 账本分录列表
+func (c *BaseCommController) AdminListLedgerEntries(ctx echo.Context) error {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := service.ListLedgerEntries(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// AdminAdjustMerchantBalance 人工调整商家余额
+func (c *BaseCommController) AdminAdjustMerchantBalance(ctx echo.Context) error {
+	type Request struct {
+		MerchantID uint64          `json:"merchant_id" validate:"required|gt:0"`
+		Amount     decimal.Decimal `json:"amount" validate:"required"` // 正数增加，负数减少
+		Memo       string          `json:"memo" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	journal, err := service.AdjustMerchantBalance(req.MerchantID, req.Amount, req.Memo, operator)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	log.AuditLog(log.EventBalanceAdjust, operator, ctx.RealIP(),
+		fmt.Sprintf("merchant_id=%d amount=%v journal_no=%s memo=%s", req.MerchantID, req.Amount, journal.JournalNo, req.Memo))
+	balance, err := data.GetMerchantBalance(req.MerchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"journal_no": journal.JournalNo,
+		"balance":    balance,
+	})
+}
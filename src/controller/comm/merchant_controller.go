@@ -8,6 +8,7 @@ import (
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/model/service"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 )
 
 // ==================== 商家认证 ====================
@@ -86,16 +87,32 @@ func (c *BaseCommController) MerchantProfile(ctx echo.Context) error {
 	}
 
 	return c.SucJson(ctx, map[string]interface{}{
-		"id":            merchant.ID,
-		"username":      merchant.Username,
-		"email":         merchant.Email,
-		"merchant_name": merchant.MerchantName,
-		"wallet_token":  merchant.WalletToken,
-		"status":        merchant.Status,
-		"balance":       merchant.Balance,
-		"usdt_rate":     merchant.UsdtRate,
-		"api_token":     merchant.ApiToken,
-		"last_login_at": merchant.LastLoginAt,
+		"id":              merchant.ID,
+		"username":        merchant.Username,
+		"email":           merchant.Email,
+		"merchant_name":   merchant.MerchantName,
+		"wallet_token":    merchant.WalletToken,
+		"status":          merchant.Status,
+		"balance":         merchant.Balance,
+		"usdt_rate":       merchant.UsdtRate,
+		"api_token":       merchant.ApiToken,
+		"last_login_at":   merchant.LastLoginAt,
+		"wallet_strategy": merchant.WalletStrategy,
+	})
+}
+
+// MerchantRollApiToken 重新生成API密钥
+func (c *BaseCommController) MerchantRollApiToken(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+
+	apiToken, err := service.RollMerchantApiToken(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+
+	return c.SucJson(ctx, map[string]interface{}{
+		"pid":       merchantID,
+		"api_token": apiToken,
 	})
 }
 
@@ -104,10 +121,11 @@ func (c *BaseCommController) MerchantProfile(ctx echo.Context) error {
 // MerchantGenerateQRCode 生成授权二维码
 func (c *BaseCommController) MerchantGenerateQRCode(ctx echo.Context) error {
 	type Request struct {
-		AmountUsdt     float64 `json:"amount_usdt" validate:"required|gt:0"`
-		TableNo        string  `json:"table_no"`
-		CustomerName   string  `json:"customer_name"`
-		ExpireMinutes  int     `json:"expire_minutes"` // 授权有效期（分钟）
+		AmountUsdt     decimal.Decimal `json:"amount_usdt" validate:"required|amountGt:0"`
+		TableNo        string          `json:"table_no"`
+		CustomerName   string          `json:"customer_name"`
+		ExpireMinutes  int             `json:"expire_minutes"` // 授权有效期（分钟）
+		TokenSymbol    string          `json:"token_symbol"`   // 代币符号，默认 USDT
 	}
 
 	merchantID := ctx.Get("merchant_id").(uint64)
@@ -126,7 +144,7 @@ func (c *BaseCommController) MerchantGenerateQRCode(ctx echo.Context) error {
 		req.ExpireMinutes = 1440
 	}
 
-	auth, err := service.GenerateMerchantQRCode(merchantID, req.AmountUsdt, req.TableNo, req.CustomerName, req.ExpireMinutes)
+	auth, err := service.GenerateMerchantQRCode(merchantID, req.AmountUsdt, req.TableNo, req.CustomerName, req.ExpireMinutes, req.TokenSymbol)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
@@ -261,9 +279,9 @@ func (c *BaseCommController) MerchantGetDeductions(ctx echo.Context) error {
 // MerchantDeduct 发起扣款
 func (c *BaseCommController) MerchantDeduct(ctx echo.Context) error {
 	type Request struct {
-		Password    string  `json:"password" validate:"required"`
-		AmountCny   float64 `json:"amount_cny" validate:"required|gt:0"`
-		ProductInfo string  `json:"product_info"`
+		Password    string          `json:"password" validate:"required"`
+		AmountCny   decimal.Decimal `json:"amount_cny" validate:"required|amountGt:0"`
+		ProductInfo string          `json:"product_info"`
 	}
 
 	merchantID := ctx.Get("merchant_id").(uint64)
@@ -450,18 +468,24 @@ func (c *BaseCommController) MerchantGetBalance(ctx echo.Context) error {
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
+	pending, err := data.GetMerchantPendingBalance(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
 
 	return c.SucJson(ctx, map[string]interface{}{
-		"balance": balance,
+		"balance":         balance,
+		"pending_balance": pending,
 	})
 }
 
 // MerchantCreateWithdrawal 商家申请提现
 func (c *BaseCommController) MerchantCreateWithdrawal(ctx echo.Context) error {
 	type Request struct {
-		Amount   float64 `json:"amount"`
-		ToWallet string  `json:"to_wallet"`
-		Chain    string  `json:"chain"`
+		Amount      decimal.Decimal `json:"amount"`
+		ToWallet    string          `json:"to_wallet"`
+		Chain       string          `json:"chain"`
+		TokenSymbol string          `json:"token_symbol"`
 	}
 
 	merchantID := ctx.Get("merchant_id").(uint64)
@@ -471,14 +495,14 @@ func (c *BaseCommController) MerchantCreateWithdrawal(ctx echo.Context) error {
 		return c.FailJson(ctx, err)
 	}
 
-	if req.Amount <= 0 {
+	if !req.Amount.IsPositive() {
 		return c.FailJson(ctx, fmt.Errorf("提现金额必须大于0"))
 	}
 	if req.ToWallet == "" {
 		return c.FailJson(ctx, fmt.Errorf("提现钱包地址不能为空"))
 	}
 
-	withdrawal, err := service.CreateMerchantWithdrawal(merchantID, req.Amount, req.ToWallet, req.Chain)
+	withdrawal, err := service.CreateMerchantWithdrawal(merchantID, req.Amount, req.ToWallet, req.Chain, req.TokenSymbol)
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
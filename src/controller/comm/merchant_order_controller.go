@@ -0,0 +1,90 @@
+package comm
+
+import (
+	"errors"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/page"
+	"github.com/labstack/echo/v4"
+)
+
+// parseDateRange 解析 YYYY-MM-DD 日期范围，结束日期包含当天
+func parseDateRange(startDate, endDate string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if startDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startDate, time.Local); err != nil {
+			return start, end, errors.New("开始日期格式错误")
+		}
+	}
+	if endDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", endDate, time.Local); err != nil {
+			return start, end, errors.New("结束日期格式错误")
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// MerchantGetOrders 商家订单列表
+func (c *BaseCommController) MerchantGetOrders(ctx echo.Context) error {
+	type Request struct {
+		Page      int    `query:"page"`
+		PageSize  int    `query:"page_size"`
+		Status    int    `query:"status"`
+		Chain     string `query:"chain"`
+		OrderId   string `query:"order_id"` // 商户订单号或平台交易号
+		TxHash    string `query:"tx_hash"`
+		StartDate string `query:"start_date"` // YYYY-MM-DD
+		EndDate   string `query:"end_date"`   // YYYY-MM-DD
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > page.MaxPageSize {
+		req.PageSize = page.MaxPageSize
+	}
+	startTime, endTime, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	filter := &data.OrderFilter{
+		Status:    req.Status,
+		Chain:     chain.NormalizeChain(req.Chain),
+		OrderId:   req.OrderId,
+		TxHash:    req.TxHash,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	list, total, err := service.GetMerchantOrders(merchantID, req.Page, req.PageSize, filter)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// MerchantGetOrderDetail 商家订单详情
+func (c *BaseCommController) MerchantGetOrderDetail(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	detail, err := service.GetMerchantOrderDetail(merchantID, ctx.Param("trade_id"))
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, detail)
+}
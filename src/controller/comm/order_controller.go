@@ -16,6 +16,7 @@ func (c *BaseCommController) CreateTransaction(ctx echo.Context) (err error) {
 	if err = c.ValidateStruct(ctx, req); err != nil {
 		return c.FailJson(ctx, err)
 	}
+	req.MerchantID = apiMerchantID(ctx)
 	resp, err := service.CreateTransaction(req)
 	if err != nil {
 		return c.FailJson(ctx, err)
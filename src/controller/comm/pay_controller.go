@@ -1,11 +1,73 @@
 package comm
 
 import (
+	"net/http"
+	"net/url"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/response"
 	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
 	"github.com/labstack/echo/v4"
 )
 
+// checkoutCounterView 收银台页面数据
+type checkoutCounterView struct {
+	BrandName  string
+	LogoUrl    string
+	ThemeColor string
+	QrCodeUrl  string
+	Error      string
+	Order      *response.CheckoutCounterResponse
+}
+
+func (v *checkoutCounterView) setBranding(branding *response.CheckoutBranding) {
+	v.BrandName = branding.BrandName
+	v.LogoUrl = branding.LogoUrl
+	v.ThemeColor = branding.ThemeColor
+}
+
+// CheckoutCounter 收银台页面
+func (c *BaseCommController) CheckoutCounter(ctx echo.Context) error {
+	view := &checkoutCounterView{}
+	order, err := service.GetCheckoutCounterByTradeId(ctx.Param("trade_id"))
+	if err != nil {
+		view.setBranding(service.GetCheckoutBranding(0))
+		view.Error = err.Error()
+		return ctx.Render(http.StatusOK, config.GetCheckoutTemplatePath(), view)
+	}
+	view.setBranding(service.GetCheckoutBranding(order.MerchantID))
+	view.Order = order
+	if order.Token != "" {
+		view.QrCodeUrl = "/qrcode?size=256&content=" + url.QueryEscape(order.Token)
+	}
+	return ctx.Render(http.StatusOK, config.GetCheckoutTemplatePath(), view)
+}
+
+// SelectChain 付款人为不指定链的订单选择支付网络
+func (c *BaseCommController) SelectChain(ctx echo.Context) error {
+	type Request struct {
+		Chain string `json:"chain" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	tradeId := ctx.Param("trade_id")
+	if err := service.SelectOrderChain(tradeId, req.Chain); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetCheckoutCounterByTradeId(tradeId)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
 // CheckStatus 支付状态检测
 func (c *BaseCommController) CheckStatus(ctx echo.Context) (err error) {
 	tradeId := ctx.Param("trade_id")
@@ -19,3 +81,33 @@ func (c *BaseCommController) CheckStatus(ctx echo.Context) (err error) {
 	}
 	return c.SucJson(ctx, resp)
 }
+
+// MerchantGetCheckoutBranding 获取收银台品牌设置
+func (c *BaseCommController) MerchantGetCheckoutBranding(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	resp, err := service.GetMerchantCheckoutBranding(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// MerchantUpdateCheckoutBranding 设置收银台品牌
+func (c *BaseCommController) MerchantUpdateCheckoutBranding(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(request.CheckoutBrandingRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateMerchantCheckoutBranding(merchantID, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetMerchantCheckoutBranding(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
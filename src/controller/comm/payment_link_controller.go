@@ -0,0 +1,155 @@
+package comm
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/model/service"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// paymentLinkTemplatePath 收款链接金额填写页模板
+const paymentLinkTemplatePath = "static/checkout/payment_link.html"
+
+// paymentLinkView 收款链接页面数据
+type paymentLinkView struct {
+	BrandName  string
+	LogoUrl    string
+	ThemeColor string
+	Error      string
+	Link       *mdb.PaymentLink
+	MinAmount  string // 付款人可填写的金额范围
+	MaxAmount  string
+}
+
+func (v *paymentLinkView) setBranding(branding *response.CheckoutBranding) {
+	v.BrandName = branding.BrandName
+	v.LogoUrl = branding.LogoUrl
+	v.ThemeColor = branding.ThemeColor
+}
+
+// renderPaymentLink 渲染收款链接页，链接不可用时只展示错误
+func renderPaymentLink(ctx echo.Context, slug, errMsg string) error {
+	view := &paymentLinkView{Error: errMsg}
+	link, err := service.GetAvailablePaymentLink(slug)
+	if err != nil {
+		view.setBranding(service.GetCheckoutBranding(0))
+		view.Error = err.Error()
+		return ctx.Render(http.StatusOK, paymentLinkTemplatePath, view)
+	}
+	view.setBranding(service.GetCheckoutBranding(link.MerchantID))
+	view.Link = link
+	min, max := service.PaymentLinkAmountRange(link)
+	view.MinAmount = min.StringFixed(2)
+	view.MaxAmount = max.StringFixed(2)
+	return ctx.Render(http.StatusOK, paymentLinkTemplatePath, view)
+}
+
+// PaymentLink 打开收款链接：只展示链接信息与金额填写页，不生成订单
+func (c *BaseCommController) PaymentLink(ctx echo.Context) error {
+	return renderPaymentLink(ctx, ctx.Param("slug"), "")
+}
+
+// PaymentLinkOrder 收款链接页提交：生成订单并跳转收银台
+func (c *BaseCommController) PaymentLinkOrder(ctx echo.Context) error {
+	slug := ctx.Param("slug")
+	amount, _ := decimal.NewFromString(ctx.FormValue("amount"))
+	resp, err := service.CreateOrderFromPaymentLink(slug, amount)
+	if err != nil {
+		return renderPaymentLink(ctx, slug, err.Error())
+	}
+	return ctx.Redirect(http.StatusSeeOther, "/pay/checkout-counter/"+resp.TradeId)
+}
+
+// MerchantGetPaymentLinks 商家收款链接列表
+func (c *BaseCommController) MerchantGetPaymentLinks(ctx echo.Context) error {
+	type Request struct {
+		Page     int `query:"page"`
+		PageSize int `query:"page_size"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	list, total, err := service.GetMerchantPaymentLinks(merchantID, req.Page, req.PageSize)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// MerchantCreatePaymentLink 商家创建收款链接
+func (c *BaseCommController) MerchantCreatePaymentLink(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(request.PaymentLinkRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	link, err := service.CreatePaymentLink(merchantID, req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, paymentLinkResponse(link))
+}
+
+// MerchantUpdatePaymentLink 商家更新收款链接
+func (c *BaseCommController) MerchantUpdatePaymentLink(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var linkID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &linkID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的链接ID"))
+	}
+	req := new(request.PaymentLinkRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	link, err := service.UpdatePaymentLink(merchantID, linkID, req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, paymentLinkResponse(link))
+}
+
+// MerchantDeletePaymentLink 商家删除收款链接
+func (c *BaseCommController) MerchantDeletePaymentLink(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var linkID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &linkID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的链接ID"))
+	}
+	if err := service.DeleteMerchantPaymentLink(merchantID, linkID); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "收款链接已删除")
+}
+
+// paymentLinkResponse 收款链接附带完整访问地址
+func paymentLinkResponse(link *mdb.PaymentLink) map[string]interface{} {
+	return map[string]interface{}{
+		"link": link,
+		"url":  fmt.Sprintf("%s/pay/link/%s", config.GetAppUri(), link.Slug),
+	}
+}
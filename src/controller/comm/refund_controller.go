@@ -0,0 +1,120 @@
+package comm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/assimon/luuu/model/service"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// RefundCreateRequest 发起退款请求
+type RefundCreateRequest struct {
+	TradeId string          `json:"trade_id" validate:"required"`
+	Amount  decimal.Decimal `json:"amount"` // 退款金额(USDT)，不传或为0时全额退款
+	Reason  string          `json:"reason"`
+}
+
+// AdminCreateRefund 管理员发起退款
+func (c *BaseCommController) AdminCreateRefund(ctx echo.Context) error {
+	req := new(RefundCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Amount.IsNegative() {
+		return c.FailJson(ctx, errors.New("退款金额不能为负数"))
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	refund, err := service.CreateOrderRefund(req.TradeId, req.Amount, req.Reason, operator, 0)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, refund)
+}
+
+// AdminRetryRefund 管理员重试失败的退款
+func (c *BaseCommController) AdminRetryRefund(ctx echo.Context) error {
+	type Request struct {
+		RefundNo string `json:"refund_no" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.RetryOrderRefund(req.RefundNo); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "退款已重新提交")
+}
+
+// AdminListRefunds 退款列表
+func (c *BaseCommController) AdminListRefunds(ctx echo.Context) error {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := service.ListRefunds(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// MerchantCreateRefund 商家发起退款
+func (c *BaseCommController) MerchantCreateRefund(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+
+	req := new(RefundCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Amount.IsNegative() {
+		return c.FailJson(ctx, errors.New("退款金额不能为负数"))
+	}
+	operator := fmt.Sprintf("merchant_%d", merchantID)
+	refund, err := service.CreateOrderRefund(req.TradeId, req.Amount, req.Reason, operator, merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, refund)
+}
+
+// MerchantGetRefunds 商家退款记录
+func (c *BaseCommController) MerchantGetRefunds(ctx echo.Context) error {
+	type Request struct {
+		Page     int `query:"page"`
+		PageSize int `query:"page_size"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	list, total, err := service.GetMerchantRefunds(merchantID, req.Page, req.PageSize)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
@@ -0,0 +1,107 @@
+package comm
+
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/log"
+	"github.com/labstack/echo/v4"
+)
+
+// MerchantGetSubscriptions 商家订阅列表
+func (c *BaseCommController) MerchantGetSubscriptions(ctx echo.Context) error {
+	type Request struct {
+		Page     int `query:"page"`
+		PageSize int `query:"page_size"`
+		Status   int `query:"status"` // 0:全部 1:正常 2:逾期 3:停止扣款 4:已取消
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	list, total, err := service.GetMerchantSubscriptions(merchantID, req.Page, req.PageSize, req.Status)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// MerchantCreateSubscription 商家基于授权创建订阅
+func (c *BaseCommController) MerchantCreateSubscription(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(request.SubscriptionRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	sub, err := service.CreateSubscription(merchantID, req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, sub)
+}
+
+// MerchantGetSubscriptionDetail 商家订阅详情
+func (c *BaseCommController) MerchantGetSubscriptionDetail(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var subID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &subID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的订阅ID"))
+	}
+	sub, err := service.GetMerchantSubscription(merchantID, subID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, sub)
+}
+
+// AdminResetSubscriptionBilling 重置卡在扣款中的订阅
+func (c *BaseCommController) AdminResetSubscriptionBilling(ctx echo.Context) error {
+	type Request struct {
+		SubscriptionNo string `json:"subscription_no" validate:"required"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	sub, err := service.ResetSubscriptionBilling(req.SubscriptionNo)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	log.AuditLog(log.EventSubscriptionReset, operator, ctx.RealIP(),
+		fmt.Sprintf("subscription_no=%s last_deduct_no=%s", sub.SubscriptionNo, sub.LastDeductNo))
+	return c.SucJson(ctx, sub)
+}
+
+// MerchantCancelSubscription 商家取消订阅
+func (c *BaseCommController) MerchantCancelSubscription(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var subID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &subID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的订阅ID"))
+	}
+	sub, err := service.CancelMerchantSubscription(merchantID, subID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, sub)
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/service"
 	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/log"
 	"github.com/labstack/echo/v4"
 )
 
@@ -36,7 +38,7 @@ func (c *BaseCommController) AddWalletAddress(ctx echo.Context) error {
 		return c.FailJson(ctx, err)
 	}
 
-	wallet, err := data.AddWalletAddress(req.Token, normalizedChain)
+	wallet, err := data.AddWalletAddress(req.Token, normalizedChain, apiMerchantID(ctx))
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
@@ -50,15 +52,159 @@ func (c *BaseCommController) AddWalletAddress(ctx echo.Context) error {
 	})
 }
 
-// WalletList 获取所有钱包地址
+// WalletList 获取钱包地址，商家签名时只返回该商家的钱包
 func (c *BaseCommController) WalletList(ctx echo.Context) error {
-	wallets, err := data.GetAllWalletAddress()
+	var wallets []mdb.WalletAddress
+	var err error
+	if merchantID := apiMerchantID(ctx); merchantID > 0 {
+		wallets, err = data.GetWalletsByMerchantID(merchantID)
+	} else {
+		wallets, err = data.GetAllWalletAddress()
+	}
 	if err != nil {
 		return c.FailJson(ctx, err)
 	}
 	return c.SucJson(ctx, wallets)
 }
 
+// AdminListWallets 管理后台钱包地址列表
+func (c *BaseCommController) AdminListWallets(ctx echo.Context) error {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListWalletAddress(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// AdminListWalletSweeps 管理后台收款钱包归集记录
+func (c *BaseCommController) AdminListWalletSweeps(ctx echo.Context) error {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListWalletSweeps(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// AdminListWalletBalances 管理后台钱包余额列表
+func (c *BaseCommController) AdminListWalletBalances(ctx echo.Context) error {
+	q, filter, err := bindAdminList(ctx)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	result, err := data.ListWalletBalances(filter, q)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.adminListJson(ctx, q, result.List, result.Total, result.NextCursor)
+}
+
+// MerchantGetWalletBalances 商家收款钱包余额
+func (c *BaseCommController) MerchantGetWalletBalances(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	balances, err := data.GetWalletBalancesByMerchantID(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if balances == nil {
+		balances = []mdb.WalletBalance{}
+	}
+	return c.SucJson(ctx, balances)
+}
+
+// AdminUpdateWalletWeight 设置钱包权重（weighted 策略使用）
+func (c *BaseCommController) AdminUpdateWalletWeight(ctx echo.Context) error {
+	type Request struct {
+		ID     uint64 `json:"id" validate:"required|gt:0"`
+		Weight int    `json:"weight" validate:"required|min:1|max:1000"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateWalletWeight(req.ID, 0, req.Weight); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	log.AuditLog(log.EventWalletConfig, operator, ctx.RealIP(), fmt.Sprintf("wallet_id=%d weight=%d", req.ID, req.Weight))
+	return c.SucJson(ctx, "权重已更新")
+}
+
+// AdminUpdateMerchantWalletStrategy 设置商家的钱包选择策略，strategy 为空时跟随链配置
+func (c *BaseCommController) AdminUpdateMerchantWalletStrategy(ctx echo.Context) error {
+	type Request struct {
+		ID       uint64 `json:"id" validate:"required|gt:0"`
+		Strategy string `json:"strategy"`
+	}
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateMerchantWalletStrategy(req.ID, req.Strategy); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	operator := fmt.Sprintf("admin_%v", ctx.Get("admin_user_id"))
+	log.AuditLog(log.EventWalletConfig, operator, ctx.RealIP(), fmt.Sprintf("merchant_id=%d wallet_strategy=%s", req.ID, req.Strategy))
+	return c.SucJson(ctx, "钱包选择策略已更新")
+}
+
+// MerchantUpdateWalletWeight 商家设置自有钱包的权重
+func (c *BaseCommController) MerchantUpdateWalletWeight(ctx echo.Context) error {
+	type Request struct {
+		ID     uint64 `json:"id" validate:"required|gt:0"`
+		Weight int    `json:"weight" validate:"required|min:1|max:1000"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateWalletWeight(req.ID, merchantID, req.Weight); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "权重已更新")
+}
+
+// MerchantUpdateWalletStrategy 商家设置自己的钱包选择策略，strategy 为空时跟随链配置
+func (c *BaseCommController) MerchantUpdateWalletStrategy(ctx echo.Context) error {
+	type Request struct {
+		Strategy string `json:"strategy"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := service.UpdateMerchantWalletStrategy(merchantID, req.Strategy); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "钱包选择策略已更新")
+}
+
+// WalletStrategyList 可选的钱包选择策略
+func (c *BaseCommController) WalletStrategyList(ctx echo.Context) error {
+	return c.SucJson(ctx, map[string]interface{}{
+		"default":    service.DefaultWalletStrategy,
+		"strategies": service.GetWalletStrategyNames(),
+	})
+}
+
 // UpdateWalletStatus 启用/禁用钱包地址
 func (c *BaseCommController) UpdateWalletStatus(ctx echo.Context) error {
 	type Request struct {
@@ -77,7 +223,13 @@ func (c *BaseCommController) UpdateWalletStatus(ctx echo.Context) error {
 		return c.FailJson(ctx, errors.New("状态无效"))
 	}
 
-	if err := data.ChangeWalletAddressStatus(req.ID, req.Status); err != nil {
+	var err error
+	if merchantID := apiMerchantID(ctx); merchantID > 0 {
+		err = data.UpdateMerchantWalletStatus(merchantID, req.ID, int64(req.Status))
+	} else {
+		err = data.ChangeWalletAddressStatus(req.ID, req.Status)
+	}
+	if err != nil {
 		return c.FailJson(ctx, err)
 	}
 	return c.SucJson(ctx, "ok")
@@ -97,7 +249,13 @@ func (c *BaseCommController) DeleteWallet(ctx echo.Context) error {
 		return c.FailJson(ctx, err)
 	}
 
-	if err := data.DeleteWalletAddressById(req.ID); err != nil {
+	var err error
+	if merchantID := apiMerchantID(ctx); merchantID > 0 {
+		err = data.DeleteMerchantWallet(merchantID, req.ID)
+	} else {
+		err = data.DeleteWalletAddressById(req.ID)
+	}
+	if err != nil {
 		return c.FailJson(ctx, err)
 	}
 	return c.SucJson(ctx, "ok")
@@ -0,0 +1,120 @@
+package comm
+
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/labstack/echo/v4"
+)
+
+// MerchantGetWebhookEndpoints 商家事件接收地址列表
+func (c *BaseCommController) MerchantGetWebhookEndpoints(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	list, err := service.GetMerchantWebhookEndpoints(merchantID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":        list,
+		"event_types": mdb.WebhookEventTypes,
+	})
+}
+
+// MerchantCreateWebhookEndpoint 商家注册事件接收地址
+func (c *BaseCommController) MerchantCreateWebhookEndpoint(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(request.WebhookEndpointRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	endpoint, err := service.CreateWebhookEndpoint(merchantID, req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, endpoint)
+}
+
+// MerchantUpdateWebhookEndpoint 商家更新事件接收地址
+func (c *BaseCommController) MerchantUpdateWebhookEndpoint(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var endpointID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &endpointID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的接收地址ID"))
+	}
+	req := new(request.WebhookEndpointRequest)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err := c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	endpoint, err := service.UpdateWebhookEndpoint(merchantID, endpointID, req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, endpoint)
+}
+
+// MerchantRollWebhookSecret 商家重新生成签名密钥
+func (c *BaseCommController) MerchantRollWebhookSecret(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var endpointID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &endpointID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的接收地址ID"))
+	}
+	endpoint, err := service.RollWebhookEndpointSecret(merchantID, endpointID)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, endpoint)
+}
+
+// MerchantDeleteWebhookEndpoint 商家删除事件接收地址
+func (c *BaseCommController) MerchantDeleteWebhookEndpoint(ctx echo.Context) error {
+	merchantID := ctx.Get("merchant_id").(uint64)
+	var endpointID uint64
+	if _, err := fmt.Sscanf(ctx.Param("id"), "%d", &endpointID); err != nil {
+		return c.FailJson(ctx, fmt.Errorf("无效的接收地址ID"))
+	}
+	if err := service.DeleteWebhookEndpoint(merchantID, endpointID); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, "接收地址已删除")
+}
+
+// MerchantGetWebhookDeliveries 商家事件投递记录
+func (c *BaseCommController) MerchantGetWebhookDeliveries(ctx echo.Context) error {
+	type Request struct {
+		Page       int    `query:"page"`
+		PageSize   int    `query:"page_size"`
+		EndpointID uint64 `query:"endpoint_id"`
+		EventType  string `query:"event_type"`
+		EventId    string `query:"event_id"`
+	}
+	merchantID := ctx.Get("merchant_id").(uint64)
+	req := new(Request)
+	if err := ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	list, total, err := service.GetMerchantWebhookDeliveries(merchantID, req.Page, req.PageSize, req.EndpointID, req.EventType, req.EventId)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
@@ -8,11 +8,13 @@ import (
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/util/constant"
 	"github.com/assimon/luuu/util/json"
 	"github.com/assimon/luuu/util/sign"
 	"github.com/labstack/echo/v4"
 	"io/ioutil"
+	"strconv"
 )
 
 // 重放防护: nonce 在 Redis 中缓存的过期时间
@@ -35,6 +37,25 @@ func CheckApiSign() echo.MiddlewareFunc {
 				return constant.SignatureErr
 			}
 
+			// ========== 识别商家: pid/merchant_id 存在时使用商家密钥 ==========
+			merchantID, err := parseApiMerchantID(m)
+			if err != nil {
+				return echo.NewHTTPError(400, "pid 参数格式错误")
+			}
+			signKey := config.GetApiAuthToken()
+			if merchantID > 0 {
+				merchant, err := data.GetMerchantByID(merchantID)
+				if err != nil || merchant.ID <= 0 || merchant.ApiToken == "" {
+					return constant.SignatureErr
+				}
+				if merchant.Status != 1 {
+					return echo.NewHTTPError(403, "商家已被禁用")
+				}
+				signKey = merchant.ApiToken
+			} else if !config.IsApiGlobalTokenEnabled() {
+				return echo.NewHTTPError(400, "缺少 pid 参数")
+			}
+
 			// ========== 重放防护: 验证时间戳 ==========
 			timestampVal, ok := m["timestamp"]
 			if !ok {
@@ -69,7 +90,7 @@ func CheckApiSign() echo.MiddlewareFunc {
 			}
 
 			// 检查 nonce 是否已使用（Redis SETNX 原子操作）
-			nonceKey := fmt.Sprintf("api_nonce:%s", nonce)
+			nonceKey := fmt.Sprintf("api_nonce:%d:%s", merchantID, nonce)
 			redisCtx := context.Background()
 			set, err := dao.Rdb.SetNX(redisCtx, nonceKey, 1, nonceExpiration).Result()
 			if err != nil {
@@ -88,10 +109,10 @@ func CheckApiSign() echo.MiddlewareFunc {
 
 			if signVersion == "v2" {
 				// 使用 HMAC-SHA256（推荐）
-				checkSignature, err = sign.GetHMAC(m, config.GetApiAuthToken())
+				checkSignature, err = sign.GetHMAC(m, signKey)
 			} else {
 				// 兼容旧版 MD5（默认，6个月后移除）
-				checkSignature, err = sign.Get(m, config.GetApiAuthToken())
+				checkSignature, err = sign.Get(m, signKey)
 			}
 
 			if err != nil {
@@ -100,8 +121,35 @@ func CheckApiSign() echo.MiddlewareFunc {
 			if checkSignature != signature {
 				return constant.SignatureErr
 			}
+			if merchantID > 0 {
+				ctx.Set("merchant_id", merchantID)
+			}
 			ctx.Request().Body = ioutil.NopCloser(bytes.NewBuffer(params))
 			return next(ctx)
 		}
 	}
 }
+
+// parseApiMerchantID 读取请求中的商家ID，pid 与 merchant_id 等价，均未传时返回 0
+func parseApiMerchantID(m map[string]interface{}) (uint64, error) {
+	val, ok := m["pid"]
+	if !ok {
+		val, ok = m["merchant_id"]
+	}
+	if !ok || val == nil {
+		return 0, nil
+	}
+	switch v := val.(type) {
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return 0, fmt.Errorf("invalid merchant id")
+		}
+		return uint64(v), nil
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.ParseUint(v, 10, 64)
+	}
+	return 0, fmt.Errorf("invalid merchant id")
+}
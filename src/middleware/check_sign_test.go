@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/sign"
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupSignTest 内存数据库与 redis，建启用(1)与禁用(2)两个商家，结束后还原
+func setupSignTest(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&mdb.Merchant{}))
+	require.NoError(t, db.Create(&mdb.Merchant{BaseModel: mdb.BaseModel{ID: 1}, Username: "m1", ApiToken: "merchant1_token", Status: 1}).Error)
+	require.NoError(t, db.Create(&mdb.Merchant{BaseModel: mdb.BaseModel{ID: 2}, Username: "m2", ApiToken: "merchant2_token", Status: 2}).Error)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	originalDB, originalRdb := dao.Mdb, dao.Rdb
+	originalToken, originalGlobal := viper.Get("api_auth_token"), viper.Get("api_global_token_enabled")
+	dao.Mdb, dao.Rdb = db, rdb
+	viper.Set("api_auth_token", "global_token")
+	t.Cleanup(func() {
+		dao.Mdb, dao.Rdb = originalDB, originalRdb
+		viper.Set("api_auth_token", originalToken)
+		viper.Set("api_global_token_enabled", originalGlobal)
+		_ = rdb.Close()
+		_ = sqlDB.Close()
+	})
+}
+
+// TestCheckApiSign 测试按 pid 选择商家密钥、禁用商家及全局密钥开关
+func TestCheckApiSign(t *testing.T) {
+	testCases := []struct {
+		name           string
+		params         map[string]interface{}
+		signKey        string
+		globalDisabled bool
+		wantCode       int    // 0 表示通过
+		wantMerchant   uint64 // 通过时上下文中的商家ID
+	}{
+		{"未传 pid 使用全局密钥", map[string]interface{}{}, "global_token", false, 0, 0},
+		{"数字 pid 使用商家密钥", map[string]interface{}{"pid": 1}, "merchant1_token", false, 0, 1},
+		{"字符串 pid 使用商家密钥", map[string]interface{}{"pid": "1"}, "merchant1_token", false, 0, 1},
+		{"merchant_id 与 pid 等价", map[string]interface{}{"merchant_id": 1}, "merchant1_token", false, 0, 1},
+		{"商家请求用全局密钥签名", map[string]interface{}{"pid": 1}, "global_token", false, 401, 0},
+		{"用其它商家的密钥签名", map[string]interface{}{"pid": 1}, "merchant2_token", false, 401, 0},
+		{"商家不存在", map[string]interface{}{"pid": 9}, "global_token", false, 401, 0},
+		{"商家已禁用", map[string]interface{}{"pid": 2}, "merchant2_token", false, 403, 0},
+		{"关闭全局密钥后缺少 pid", map[string]interface{}{}, "global_token", true, 400, 0},
+		{"关闭全局密钥后商家请求正常", map[string]interface{}{"pid": "1"}, "merchant1_token", true, 0, 1},
+		{"pid 为小数", map[string]interface{}{"pid": 1.5}, "merchant1_token", false, 400, 0},
+		{"pid 非数字", map[string]interface{}{"pid": "abc"}, "merchant1_token", false, 400, 0},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupSignTest(t)
+			viper.Set("api_global_token_enabled", !tc.globalDisabled)
+
+			params := map[string]interface{}{
+				"order_id":     "O1",
+				"amount":       10,
+				"timestamp":    time.Now().Unix(),
+				"nonce":        "nonce-" + strconv.Itoa(i),
+				"sign_version": "v2",
+			}
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			body := signedBody(t, params, tc.signKey)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/order/create-transaction", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			called := false
+			err := CheckApiSign()(func(c echo.Context) error {
+				called = true
+				return nil
+			})(c)
+
+			if tc.wantCode == 0 {
+				require.NoError(t, err)
+				assert.True(t, called)
+				merchantID, _ := c.Get("merchant_id").(uint64)
+				assert.Equal(t, tc.wantMerchant, merchantID)
+				return
+			}
+			assert.False(t, called)
+			if tc.wantCode == 401 {
+				assert.ErrorIs(t, err, constant.SignatureErr)
+				return
+			}
+			var httpErr *echo.HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tc.wantCode, httpErr.Code)
+		})
+	}
+}
+
+// TestCheckApiSignReplay 测试同一 nonce 不能重复使用
+func TestCheckApiSignReplay(t *testing.T) {
+	setupSignTest(t)
+	body := signedBody(t, map[string]interface{}{"pid": 1, "timestamp": time.Now().Unix(), "nonce": "n1"}, "merchant1_token")
+	e := echo.New()
+	handler := CheckApiSign()(func(c echo.Context) error { return nil })
+
+	first := e.NewContext(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), httptest.NewRecorder())
+	require.NoError(t, handler(first))
+	second := e.NewContext(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), httptest.NewRecorder())
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, handler(second), &httpErr)
+	assert.Equal(t, 400, httpErr.Code)
+}
+
+// TestParseApiMerchantID 测试 pid 的数字与字符串格式解析
+func TestParseApiMerchantID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   map[string]interface{}
+		expected uint64
+		wantErr  bool
+	}{
+		{"未传", map[string]interface{}{}, 0, false},
+		{"null", map[string]interface{}{"pid": nil}, 0, false},
+		{"数字", map[string]interface{}{"pid": float64(12)}, 12, false},
+		{"字符串", map[string]interface{}{"pid": "12"}, 12, false},
+		{"空字符串", map[string]interface{}{"pid": ""}, 0, false},
+		{"merchant_id", map[string]interface{}{"merchant_id": float64(7)}, 7, false},
+		{"pid 优先于 merchant_id", map[string]interface{}{"pid": "3", "merchant_id": float64(7)}, 3, false},
+		{"小数", map[string]interface{}{"pid": 1.5}, 0, true},
+		{"负数", map[string]interface{}{"pid": float64(-1)}, 0, true},
+		{"非数字字符串", map[string]interface{}{"pid": "abc"}, 0, true},
+		{"布尔值", map[string]interface{}{"pid": true}, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseApiMerchantID(tc.params)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+// signedBody 按 JSON 解码后的参数计算签名，返回请求体
+func signedBody(t *testing.T, params map[string]interface{}, signKey string) string {
+	raw, err := json.Cjson.Marshal(params)
+	require.NoError(t, err)
+	decoded := make(map[string]interface{})
+	require.NoError(t, json.Cjson.Unmarshal(raw, &decoded))
+	if decoded["sign_version"] == "v2" {
+		decoded["signature"], err = sign.GetHMAC(decoded, signKey)
+	} else {
+		decoded["signature"], err = sign.Get(decoded, signKey)
+	}
+	require.NoError(t, err)
+	raw, err = json.Cjson.Marshal(decoded)
+	require.NoError(t, err)
+	return string(raw)
+}
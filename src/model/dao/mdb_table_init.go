@@ -1,10 +1,14 @@
 package dao
 
 import (
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/gookit/color"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var once sync.Once
@@ -12,6 +16,11 @@ var once sync.Once
 // 自动建表
 func MdbTableInit() {
 	once.Do(func() {
+		// 金额字段由浮点改为 decimal，先显式转换已有数据，AutoMigrate 不再改动这些列
+		if err := migrateDecimalColumns(); err != nil {
+			color.Red.Printf("[store_db] Migrate decimal columns,err=%s\n", err)
+			return
+		}
 		if err := Mdb.AutoMigrate(&mdb.Orders{}); err != nil {
 			color.Red.Printf("[store_db] AutoMigrate DB(Orders),err=%s\n", err)
 			return
@@ -29,6 +38,15 @@ func MdbTableInit() {
 			color.Red.Printf("[store_db] AutoMigrate DB(Deduction),err=%s\n", err)
 			return
 		}
+		// KTV授权支付表
+		if err := Mdb.AutoMigrate(&mdb.KtvAuthorize{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(KtvAuthorize),err=%s\n", err)
+			return
+		}
+		if err := Mdb.AutoMigrate(&mdb.KtvDeduction{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(KtvDeduction),err=%s\n", err)
+			return
+		}
 		// 管理系统表
 		if err := Mdb.AutoMigrate(&mdb.AdminRole{}); err != nil {
 			color.Red.Printf("[store_db] AutoMigrate DB(AdminRole),err=%s\n", err)
@@ -57,5 +75,151 @@ func MdbTableInit() {
 			color.Red.Printf("[store_db] AutoMigrate DB(MerchantWithdrawal),err=%s\n", err)
 			return
 		}
+		// 订单退款表
+		if err := Mdb.AutoMigrate(&mdb.OrderRefund{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(OrderRefund),err=%s\n", err)
+			return
+		}
+		// 订单入账记录表
+		if err := Mdb.AutoMigrate(&mdb.OrderTransfer{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(OrderTransfer),err=%s\n", err)
+			return
+		}
+		if err := dropLegacyIndex(&mdb.OrderTransfer{}, "idx_order_transfer_tx"); err != nil {
+			color.Red.Printf("[store_db] DropIndex DB(OrderTransfer),err=%s\n", err)
+			return
+		}
+		// 入账转账对账表
+		if err := Mdb.AutoMigrate(&mdb.IncomingTransfer{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(IncomingTransfer),err=%s\n", err)
+			return
+		}
+		if err := dropLegacyIndex(&mdb.IncomingTransfer{}, "idx_incoming_transfer_tx"); err != nil {
+			color.Red.Printf("[store_db] DropIndex DB(IncomingTransfer),err=%s\n", err)
+			return
+		}
+		// 收款链接表
+		if err := Mdb.AutoMigrate(&mdb.PaymentLink{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(PaymentLink),err=%s\n", err)
+			return
+		}
+		// 订阅表
+		if err := Mdb.AutoMigrate(&mdb.Subscription{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(Subscription),err=%s\n", err)
+			return
+		}
+		// 商户事件通知表
+		if err := Mdb.AutoMigrate(&mdb.WebhookEndpoint{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(WebhookEndpoint),err=%s\n", err)
+			return
+		}
+		if err := Mdb.AutoMigrate(&mdb.WebhookDelivery{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(WebhookDelivery),err=%s\n", err)
+			return
+		}
+		// HD派生收款地址表
+		if err := Mdb.AutoMigrate(&mdb.HdDepositAddress{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(HdDepositAddress),err=%s\n", err)
+			return
+		}
+		// 商家资金账本
+		if err := Mdb.AutoMigrate(&mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(Ledger),err=%s\n", err)
+			return
+		}
+		// 手续费方案表
+		if err := Mdb.AutoMigrate(&mdb.FeeSchedule{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(FeeSchedule),err=%s\n", err)
+			return
+		}
+		// 收款钱包归集记录表
+		if err := Mdb.AutoMigrate(&mdb.WalletSweep{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(WalletSweep),err=%s\n", err)
+			return
+		}
+		// 钱包余额快照表
+		if err := Mdb.AutoMigrate(&mdb.WalletBalance{}); err != nil {
+			color.Red.Printf("[store_db] AutoMigrate DB(WalletBalance),err=%s\n", err)
+			return
+		}
 	})
 }
+
+// dropLegacyIndex 删除已被替换的旧索引，如 (tx_hash, to_address) 唯一索引会挡住同一交易内的批量转账
+func dropLegacyIndex(model interface{}, name string) error {
+	if !Mdb.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	return Mdb.Migrator().DropIndex(model, name)
+}
+
+// decimalColumns 曾以浮点类型建表的金额字段
+var decimalColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&mdb.Orders{}, []string{"amount", "actual_amount"}},
+	{&mdb.MerchantWithdrawal{}, []string{"amount"}},
+	{&mdb.Authorization{}, []string{"authorized_usdt", "used_usdt", "remaining_usdt"}},
+	{&mdb.Deduction{}, []string{"amount_usdt", "amount_cny"}},
+	{&mdb.KtvAuthorize{}, []string{"authorized_usdt", "used_usdt", "remaining_usdt"}},
+	{&mdb.KtvDeduction{}, []string{"amount_usdt", "amount_cny"}},
+	{&mdb.Subscription{}, []string{"amount_usdt"}},
+}
+
+// migrateDecimalColumns 将仍为浮点类型的金额列转为 decimal(19,6)，按 6 位小数四舍五入保留原有数据
+// sqlite 由 AutoMigrate 重建表并复制数据，无需处理
+func migrateDecimalColumns() error {
+	dialect := Mdb.Dialector.Name()
+	if dialect != "mysql" && dialect != "postgres" {
+		return nil
+	}
+	migrator := Mdb.Migrator()
+	for _, item := range decimalColumns {
+		if !migrator.HasTable(item.model) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: Mdb}
+		if err := stmt.Parse(item.model); err != nil {
+			return err
+		}
+		columnTypes, err := migrator.ColumnTypes(item.model)
+		if err != nil {
+			return err
+		}
+		for _, columnType := range columnTypes {
+			if !slices.Contains(item.columns, columnType.Name()) {
+				continue
+			}
+			typeName := strings.ToLower(columnType.DatabaseTypeName())
+			if typeName == "decimal" || typeName == "numeric" {
+				continue
+			}
+			table, column := clause.Table{Name: stmt.Schema.Table}, clause.Column{Name: columnType.Name()}
+			if dialect == "postgres" {
+				err = Mdb.Exec("ALTER TABLE ? ALTER COLUMN ? TYPE DECIMAL(19,6) USING ROUND(?::numeric, 6)", table, column, column).Error
+			} else {
+				// MODIFY 需完整定义列，保留原有的非空、默认值与注释
+				definition := "DECIMAL(19,6)"
+				args := []interface{}{table, column}
+				if nullable, ok := columnType.Nullable(); ok && !nullable {
+					definition += " NOT NULL"
+				}
+				if value, ok := columnType.DefaultValue(); ok {
+					definition += " DEFAULT ?"
+					args = append(args, value)
+				}
+				if comment, ok := columnType.Comment(); ok && comment != "" {
+					definition += " COMMENT ?"
+					args = append(args, comment)
+				}
+				err = Mdb.Exec("ALTER TABLE ? MODIFY COLUMN ? "+definition, args...).Error
+			}
+			if err != nil {
+				return err
+			}
+			color.Green.Printf("[store_db] %s.%s 已转为 decimal(19,6)\n", stmt.Schema.Table, columnType.Name())
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
 )
 
 func GetAdminRoleByName(name string) (*mdb.AdminRole, error) {
@@ -15,8 +16,12 @@ func CreateAdminRole(role *mdb.AdminRole) error {
 	return dao.Mdb.Create(role).Error
 }
 
-func ListAdminRoles() ([]mdb.AdminRole, error) {
-	var roles []mdb.AdminRole
-	err := dao.Mdb.Model(&mdb.AdminRole{}).Order("id asc").Find(&roles).Error
-	return roles, err
+var adminRoleListSpec = &listSpec{
+	keyword: anyColumn("name"),
+	sorts:   []string{"created_at"},
+}
+
+// ListAdminRoles 角色列表
+func ListAdminRoles(filter *ListFilter, q *page.Query) (*ListResult[mdb.AdminRole], error) {
+	return listPage[mdb.AdminRole](adminRoleListSpec, filter, q)
 }
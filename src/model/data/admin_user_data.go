@@ -3,6 +3,7 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
 )
 
 func GetAdminUserByUsername(username string) (*mdb.AdminUser, error) {
@@ -30,8 +31,13 @@ func UpdateAdminUser(user *mdb.AdminUser) error {
 	}).Error
 }
 
-func ListAdminUsers() ([]mdb.AdminUser, error) {
-	var users []mdb.AdminUser
-	err := dao.Mdb.Model(&mdb.AdminUser{}).Order("id desc").Find(&users).Error
-	return users, err
+var adminUserListSpec = &listSpec{
+	status:  "status",
+	keyword: anyColumn("username"),
+	sorts:   []string{"created_at"},
+}
+
+// ListAdminUsers 管理员列表
+func ListAdminUsers(filter *ListFilter, q *page.Query) (*ListResult[mdb.AdminUser], error) {
+	return listPage[mdb.AdminUser](adminUserListSpec, filter, q)
 }
@@ -3,6 +3,7 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -86,7 +87,7 @@ func ActivateAuthorization(authNo, customerWallet, txHash string, authorizeTime
 }
 
 // UpdateAuthorizationUsed 更新已使用额度
-func UpdateAuthorizationUsed(tx *gorm.DB, authID uint64, usedAmount float64) error {
+func UpdateAuthorizationUsed(tx *gorm.DB, authID uint64, usedAmount decimal.Decimal) error {
 	return tx.Model(&mdb.Authorization{}).Where("id = ?", authID).
 		Updates(map[string]interface{}{
 			"used_usdt":      gorm.Expr("used_usdt + ?", usedAmount),
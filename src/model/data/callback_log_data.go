@@ -1,16 +1,65 @@
 package data
 
 import (
+	"time"
+
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
 )
 
 func CreateCallbackLog(log *mdb.CallbackLog) error {
 	return dao.Mdb.Create(log).Error
 }
 
-func ListCallbackLogs() ([]mdb.CallbackLog, error) {
+var callbackLogListSpec = &listSpec{
+	status:   "status_code",
+	merchant: anyColumn("merchant_id"),
+	keyword:  anyColumn("trade_id", "order_id", "biz_no"),
+	sorts:    []string{"created_at", "status_code", "attempt"},
+}
+
+// ListCallbackLogs 管理后台回调日志列表，status 为回调响应的 HTTP 状态码
+func ListCallbackLogs(filter *ListFilter, q *page.Query) (*ListResult[mdb.CallbackLog], error) {
+	return listPage[mdb.CallbackLog](callbackLogListSpec, filter, q)
+}
+
+// GetCallbackLogById 通过ID查询回调日志
+func GetCallbackLogById(id uint64) (*mdb.CallbackLog, error) {
+	callbackLog := new(mdb.CallbackLog)
+	err := dao.Mdb.Model(callbackLog).Limit(1).Find(callbackLog, id).Error
+	return callbackLog, err
+}
+
+// GetMerchantCallbackLogs 分页查询商家的回调日志
+func GetMerchantCallbackLogs(merchantID uint64, page, pageSize int, callbackType string, deadLetter int) ([]mdb.CallbackLog, int64, error) {
+	var logs []mdb.CallbackLog
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.CallbackLog{}).Where("merchant_id = ?", merchantID)
+	if callbackType != "" {
+		query = query.Where("callback_type = ?", callbackType)
+	}
+	if deadLetter > 0 {
+		query = query.Where("dead_letter = ?", 1)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&logs).Error
+	return logs, total, err
+}
+
+// GetUnresolvedDeadLetterCallbackLogs 查询时间范围内进入死信且之后未成功送达（订阅通知需为同一事件）的回调日志，merchantID 为 0 时不限商家
+func GetUnresolvedDeadLetterCallbackLogs(merchantID uint64, startTime, endTime time.Time, limit int) ([]mdb.CallbackLog, error) {
 	var logs []mdb.CallbackLog
-	err := dao.Mdb.Model(&mdb.CallbackLog{}).Order("id desc").Limit(200).Find(&logs).Error
+	query := dao.Mdb.Model(&mdb.CallbackLog{}).
+		Where("dead_letter = ? AND created_at BETWEEN ? AND ?", 1, startTime, endTime).
+		Where("NOT EXISTS (SELECT 1 FROM callback_logs s WHERE s.callback_type = callback_logs.callback_type" +
+			" AND s.biz_no = callback_logs.biz_no AND COALESCE(s.event, '') = COALESCE(callback_logs.event, '') AND s.success = 1 AND s.id > callback_logs.id AND s.deleted_at IS NULL)")
+	if merchantID > 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&logs).Error
 	return logs, err
 }
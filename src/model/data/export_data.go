@@ -0,0 +1,112 @@
+package data
+
+import (
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize 导出时每批读取的行数
+const exportBatchSize = 500
+
+// DeductionFilter 扣款导出筛选条件，时间为扣款时间
+type DeductionFilter struct {
+	Status    int
+	StartTime int64
+	EndTime   int64
+}
+
+// WithdrawalFilter 提现导出筛选条件
+type WithdrawalFilter struct {
+	Status    int
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// CallbackLogFilter 回调日志导出筛选条件
+type CallbackLogFilter struct {
+	CallbackType string
+	DeadLetter   int
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// createdAtRange 按创建时间筛选
+func createdAtRange(query *gorm.DB, start, end time.Time) *gorm.DB {
+	if !start.IsZero() {
+		query = query.Where("created_at >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("created_at < ?", end)
+	}
+	return query
+}
+
+// EachOrders 分批遍历订单，merchantID 为 0 时不限商家
+func EachOrders(merchantID uint64, filter *OrderFilter, fn func([]mdb.Orders) error) error {
+	query := dao.Mdb.Model(&mdb.Orders{})
+	if merchantID > 0 {
+		query = query.Scopes(merchantOrderScope(merchantID))
+	}
+	var batch []mdb.Orders
+	return filter.apply(query).FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// EachDeductions 分批遍历扣款记录，merchantWallet 为空时不限商家
+func EachDeductions(merchantWallet string, filter *DeductionFilter, fn func([]mdb.KtvDeduction) error) error {
+	query := dao.Mdb.Model(&mdb.KtvDeduction{})
+	if merchantWallet != "" {
+		query = query.Where("auth_id IN (?)",
+			dao.Mdb.Model(&mdb.KtvAuthorize{}).Select("id").Where("merchant_wallet = ?", merchantWallet))
+	}
+	if filter.Status > 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StartTime > 0 {
+		query = query.Where("deduct_time >= ?", filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query = query.Where("deduct_time < ?", filter.EndTime)
+	}
+	var batch []mdb.KtvDeduction
+	return query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// EachWithdrawals 分批遍历提现记录，merchantID 为 0 时不限商家
+func EachWithdrawals(merchantID uint64, filter *WithdrawalFilter, fn func([]mdb.MerchantWithdrawal) error) error {
+	query := dao.Mdb.Model(&mdb.MerchantWithdrawal{})
+	if merchantID > 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if filter.Status > 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var batch []mdb.MerchantWithdrawal
+	return createdAtRange(query, filter.StartTime, filter.EndTime).FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// EachCallbackLogs 分批遍历回调日志，merchantID 为 0 时不限商家
+func EachCallbackLogs(merchantID uint64, filter *CallbackLogFilter, fn func([]mdb.CallbackLog) error) error {
+	query := dao.Mdb.Model(&mdb.CallbackLog{})
+	if merchantID > 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if filter.CallbackType != "" {
+		query = query.Where("callback_type = ?", filter.CallbackType)
+	}
+	if filter.DeadLetter > 0 {
+		query = query.Where("dead_letter = 1")
+	}
+	var batch []mdb.CallbackLog
+	return createdAtRange(query, filter.StartTime, filter.EndTime).FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
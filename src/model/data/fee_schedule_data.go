@@ -0,0 +1,97 @@
+package data
+
+import (
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// FeeStatementLine 商家对账单某业务的汇总
+// 订单与扣款：净额 = 总额 - 手续费；提现：总额为从余额扣除的金额，净额为到账金额
+type FeeStatementLine struct {
+	BizType string          `json:"biz_type"`
+	Count   int64           `json:"count"`
+	Gross   decimal.Decimal `json:"gross"`
+	Fee     decimal.Decimal `json:"fee"`
+	Net     decimal.Decimal `json:"net"`
+}
+
+// GetFeeSchedules 查询手续费方案，merchantID 为 nil 时查询全部
+func GetFeeSchedules(merchantID *uint64) ([]mdb.FeeSchedule, error) {
+	var list []mdb.FeeSchedule
+	query := dao.Mdb.Model(&mdb.FeeSchedule{})
+	if merchantID != nil {
+		query = query.Where("merchant_id = ?", *merchantID)
+	}
+	err := query.Order("merchant_id ASC, biz_type ASC").Find(&list).Error
+	return list, err
+}
+
+// GetFeeSchedule 查询商家或全局的某业务手续费方案
+func GetFeeSchedule(merchantID uint64, bizType string) (*mdb.FeeSchedule, error) {
+	schedule := new(mdb.FeeSchedule)
+	err := dao.Mdb.Model(schedule).Limit(1).
+		Find(schedule, "merchant_id = ? AND biz_type = ?", merchantID, bizType).Error
+	return schedule, err
+}
+
+// GetFeeScheduleById 通过ID查询手续费方案
+func GetFeeScheduleById(id uint64) (*mdb.FeeSchedule, error) {
+	schedule := new(mdb.FeeSchedule)
+	err := dao.Mdb.Model(schedule).Limit(1).Find(schedule, id).Error
+	return schedule, err
+}
+
+// SaveFeeSchedule 新增或更新手续费方案
+func SaveFeeSchedule(schedule *mdb.FeeSchedule) error {
+	return dao.Mdb.Save(schedule).Error
+}
+
+// DeleteFeeSchedule 删除手续费方案，物理删除以便同一商家业务重新配置
+func DeleteFeeSchedule(id uint64) error {
+	return dao.Mdb.Unscoped().Delete(&mdb.FeeSchedule{}, id).Error
+}
+
+// GetMerchantFeeStatement 汇总商家时间段内已完成的订单、扣款与提现的总额、手续费与净额
+func GetMerchantFeeStatement(merchantID uint64, start, end time.Time) ([]FeeStatementLine, error) {
+	type sums struct {
+		Count int64
+		Gross decimal.Decimal
+		Fee   decimal.Decimal
+	}
+	sumOf := func(query *gorm.DB, grossExpr string) (sums, error) {
+		var r sums
+		err := createdAtRange(query, start, end).
+			Select("COUNT(*) AS count, COALESCE(SUM(" + grossExpr + "), 0) AS gross, COALESCE(SUM(fee_amount), 0) AS fee").
+			Scan(&r).Error
+		return r, err
+	}
+
+	orders, err := sumOf(dao.Mdb.Model(&mdb.Orders{}).Scopes(merchantOrderScope(merchantID)).
+		Where("status IN ?", []int{mdb.StatusPaySuccess, mdb.StatusOverpaid, mdb.StatusRefunded, mdb.StatusPartialRefunded}),
+		"actual_amount")
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := sumOf(dao.Mdb.Model(&mdb.KtvDeduction{}).
+		Where("status = ? AND auth_id IN (?)", mdb.DeductionStatusSuccess,
+			dao.Mdb.Model(&mdb.KtvAuthorize{}).Select("id").Where("merchant_wallet IN (?)", merchantWalletTokens(merchantID))),
+		"amount_usdt")
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := sumOf(dao.Mdb.Model(&mdb.MerchantWithdrawal{}).
+		Where("merchant_id = ? AND status = ?", merchantID, mdb.WithdrawalStatusCompleted),
+		"amount + fee_amount")
+	if err != nil {
+		return nil, err
+	}
+	return []FeeStatementLine{
+		{BizType: mdb.FeeBizOrder, Count: orders.Count, Gross: orders.Gross, Fee: orders.Fee, Net: orders.Gross.Sub(orders.Fee)},
+		{BizType: mdb.FeeBizDeduction, Count: deductions.Count, Gross: deductions.Gross, Fee: deductions.Fee, Net: deductions.Gross.Sub(deductions.Fee)},
+		{BizType: mdb.FeeBizWithdrawal, Count: withdrawals.Count, Gross: withdrawals.Gross, Fee: withdrawals.Fee, Net: withdrawals.Gross.Sub(withdrawals.Fee)},
+	}, nil
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"gorm.io/gorm"
+)
+
+// GetNextHdDerivationIndexWithTransaction 事务内获取派生网络的下一个可用索引
+func GetNextHdDerivationIndexWithTransaction(tx *gorm.DB, network string) (uint32, error) {
+	var next uint32
+	err := tx.Model(&mdb.HdDepositAddress{}).Unscoped().
+		Where("network = ?", network).
+		Select("COALESCE(MAX(derivation_index) + 1, 0)").
+		Scan(&next).Error
+	return next, err
+}
+
+// CacheHdDerivationIndexKey 派生网络已分配的最大索引
+var CacheHdDerivationIndexKey = "hd:derivation_index:%s"
+
+// AllocateHdDerivationIndex 通过 Redis INCR 原子分配派生索引，多实例并发时不会重复
+// 缓存不存在时以数据库中的下一个索引为起点初始化
+func AllocateHdDerivationIndex(tx *gorm.DB, network string) (uint32, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheHdDerivationIndexKey, network)
+	exists, err := dao.Rdb.Exists(ctx, cacheKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		next, err := GetNextHdDerivationIndexWithTransaction(tx, network)
+		if err != nil {
+			return 0, err
+		}
+		if err = dao.Rdb.SetNX(ctx, cacheKey, int64(next)-1, 0).Err(); err != nil {
+			return 0, err
+		}
+	}
+	index, err := dao.Rdb.Incr(ctx, cacheKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(index), nil
+}
+
+// CreateHdDepositAddressWithTransaction 事务记录派生地址
+func CreateHdDepositAddressWithTransaction(tx *gorm.DB, address *mdb.HdDepositAddress) error {
+	return tx.Create(address).Error
+}
+
+// GetHdDepositAddressByTradeId 通过订单号获取派生地址
+func GetHdDepositAddressByTradeId(tradeId string) (*mdb.HdDepositAddress, error) {
+	address := new(mdb.HdDepositAddress)
+	err := dao.Mdb.Model(address).Limit(1).Find(address, "trade_id = ?", tradeId).Error
+	return address, err
+}
+
+// HdLateTransferWatchWindow 订单过期后继续监听派生地址的时长，用于登记迟到的转账
+const HdLateTransferWatchWindow = 24 * time.Hour
+
+// GetPendingHdDepositWallets 获取某条链上仍在收款期内的派生地址，供监听任务使用
+// 过期订单的地址在 window 内继续监听，迟到的转账会登记为未匹配入账
+func GetPendingHdDepositWallets(chain string, window time.Duration) ([]mdb.WalletAddress, error) {
+	var tokens []string
+	err := dao.Mdb.Model(&mdb.Orders{}).
+		Where("chain = ? AND derivation_path <> ''", chain).
+		Where("status IN ?", []int{mdb.StatusWaitPay, mdb.StatusPartiallyPaid, mdb.StatusConfirming, mdb.StatusExpired}).
+		Where("created_at >= ?", time.Now().Add(-window)).
+		Distinct().
+		Pluck("token", &tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]mdb.WalletAddress, 0, len(tokens))
+	for _, token := range tokens {
+		wallets = append(wallets, mdb.WalletAddress{
+			Token:  token,
+			Chain:  chain,
+			Status: mdb.TokenStatusEnable,
+		})
+	}
+	return wallets, nil
+}
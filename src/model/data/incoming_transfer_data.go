@@ -0,0 +1,64 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"gorm.io/gorm"
+)
+
+// GetIncomingTransferByTxHash 通过交易哈希、收款地址和交易内序号查询入账转账
+func GetIncomingTransferByTxHash(tx *gorm.DB, txHash, toAddress string, logIndex uint) (*mdb.IncomingTransfer, error) {
+	transfer := new(mdb.IncomingTransfer)
+	err := tx.Model(transfer).Limit(1).Find(transfer, "tx_hash = ? AND to_address = ? AND log_index = ?", txHash, toAddress, logIndex).Error
+	return transfer, err
+}
+
+// GetIncomingTransferByIdWithTransaction 事务内通过id查询入账转账
+func GetIncomingTransferByIdWithTransaction(tx *gorm.DB, id uint64) (*mdb.IncomingTransfer, error) {
+	transfer := new(mdb.IncomingTransfer)
+	err := tx.Model(transfer).Limit(1).Find(transfer, "id = ?", id).Error
+	return transfer, err
+}
+
+// CreateIncomingTransfer 创建入账转账记录
+func CreateIncomingTransfer(transfer *mdb.IncomingTransfer) error {
+	return dao.Mdb.Create(transfer).Error
+}
+
+// UpdateIncomingTransferMatch 更新入账转账的匹配状态
+func UpdateIncomingTransferMatch(tx *gorm.DB, txHash, toAddress string, logIndex uint, matchStatus int, tradeId string) error {
+	return tx.Model(&mdb.IncomingTransfer{}).
+		Where("tx_hash = ? AND to_address = ? AND log_index = ?", txHash, toAddress, logIndex).
+		Updates(map[string]interface{}{
+			"match_status": matchStatus,
+			"trade_id":     tradeId,
+		}).Error
+}
+
+// AttachIncomingTransferWithTransaction 手动关联入账转账到订单，仅未匹配的记录可关联
+func AttachIncomingTransferWithTransaction(tx *gorm.DB, id uint64, tradeId, operator string) (int64, error) {
+	result := tx.Model(&mdb.IncomingTransfer{}).
+		Where("id = ? AND match_status = ?", id, mdb.IncomingTransferUnmatched).
+		Updates(map[string]interface{}{
+			"match_status": mdb.IncomingTransferAttached,
+			"trade_id":     tradeId,
+			"operator":     operator,
+		})
+	return result.RowsAffected, result.Error
+}
+
+var incomingTransferListSpec = &listSpec{
+	status:   "match_status",
+	chain:    "chain",
+	amount:   "amount",
+	wallet:   anyColumn("to_address", "from_address"),
+	merchant: inSubQuery("to_address", merchantReceiveWallets),
+	keyword:  anyColumn("tx_hash", "trade_id"),
+	sorts:    []string{"created_at", "amount", "block_timestamp", "block_number"},
+}
+
+// ListIncomingTransfers 分页查询入账转账，status 为匹配状态
+func ListIncomingTransfers(filter *ListFilter, q *page.Query) (*ListResult[mdb.IncomingTransfer], error) {
+	return listPage[mdb.IncomingTransfer](incomingTransferListSpec, filter, q)
+}
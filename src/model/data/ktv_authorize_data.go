@@ -3,6 +3,7 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -45,7 +46,7 @@ func UpdateAuthorizeActive(authNo, txHash string, authorizeTime int64) error {
 }
 
 // UpdateAuthorizeUsed 更新已使用额度
-func UpdateAuthorizeUsed(tx *gorm.DB, authID uint64, usedAmount float64) error {
+func UpdateAuthorizeUsed(tx *gorm.DB, authID uint64, usedAmount decimal.Decimal) error {
 	return tx.Model(&mdb.KtvAuthorize{}).Where("id = ?", authID).
 		Updates(map[string]interface{}{
 			"used_usdt":      gorm.Expr("used_usdt + ?", usedAmount),
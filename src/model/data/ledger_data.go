@@ -0,0 +1,163 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LedgerEntryFilter 商家账单筛选条件
+type LedgerEntryFilter struct {
+	Account   string // 商家科目 available/pending，为空时查询全部科目
+	BizType   string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// GetOrCreateLedgerAccount 按编码查询科目，不存在时创建
+// 并发创建同一科目时唯一索引冲突，在保存点内插入，冲突后回滚到保存点重新查询
+func GetOrCreateLedgerAccount(tx *gorm.DB, account *mdb.LedgerAccount) error {
+	if err := tx.Where("code = ?", account.Code).Limit(1).Find(account).Error; err != nil {
+		return err
+	}
+	if account.ID > 0 {
+		return nil
+	}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(account).Error
+	})
+	if err == nil || !isDuplicateKeyErr(err) {
+		return err
+	}
+	account.ID = 0
+	return tx.Where("code = ?", account.Code).First(account).Error
+}
+
+// isDuplicateKeyErr 是否唯一索引冲突（MySQL / PostgreSQL / SQLite）
+func isDuplicateKeyErr(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Duplicate entry") ||
+		strings.Contains(msg, "duplicate key value") ||
+		strings.Contains(msg, "UNIQUE constraint failed")
+}
+
+// LockLedgerAccounts 锁定科目，同一科目的记账串行执行
+func LockLedgerAccounts(tx *gorm.DB, ids []uint64) error {
+	var accounts []mdb.LedgerAccount
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).Order("id").Find(&accounts).Error
+}
+
+// GetLedgerAccountBalance 科目余额，取该科目最近一条分录的记账后余额
+func GetLedgerAccountBalance(tx *gorm.DB, account *mdb.LedgerAccount) (decimal.Decimal, error) {
+	entry := new(mdb.LedgerEntry)
+	err := tx.Model(entry).Select("balance_after").
+		Where("account_id = ?", account.ID).
+		Order("id DESC").Limit(1).Find(entry).Error
+	return entry.BalanceAfter, err
+}
+
+// GetLedgerBalanceByCode 查询科目余额，科目不存在时为 0
+func GetLedgerBalanceByCode(code string) (decimal.Decimal, error) {
+	account := new(mdb.LedgerAccount)
+	if err := dao.Mdb.Model(account).Limit(1).Find(account, "code = ?", code).Error; err != nil {
+		return decimal.Zero, err
+	}
+	if account.ID == 0 {
+		return decimal.Zero, nil
+	}
+	return GetLedgerAccountBalance(dao.Mdb, account)
+}
+
+// GetMerchantBalance 获取商家可用余额，由账本汇总得出
+func GetMerchantBalance(merchantID uint64) (decimal.Decimal, error) {
+	return GetLedgerBalanceByCode(mdb.MerchantLedgerCode(merchantID, mdb.LedgerMerchantAvailable))
+}
+
+// GetMerchantPendingBalance 获取商家提现在途金额
+func GetMerchantPendingBalance(merchantID uint64) (decimal.Decimal, error) {
+	return GetLedgerBalanceByCode(mdb.MerchantLedgerCode(merchantID, mdb.LedgerMerchantPending))
+}
+
+// SyncMerchantBalance 将账本余额同步到商家表，供列表展示与排序
+func SyncMerchantBalance(tx *gorm.DB, merchantID uint64, balance decimal.Decimal) error {
+	return tx.Model(&mdb.Merchant{}).Where("id = ?", merchantID).
+		Update("balance", balance).Error
+}
+
+// ExistsLedgerJournal 业务单据是否已记账
+func ExistsLedgerJournal(tx *gorm.DB, bizType, bizNo string) (bool, error) {
+	var count int64
+	err := tx.Model(&mdb.LedgerJournal{}).Where("biz_type = ? AND biz_no = ?", bizType, bizNo).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateLedgerJournal 写入凭证及其分录
+func CreateLedgerJournal(tx *gorm.DB, journal *mdb.LedgerJournal, entries []mdb.LedgerEntry) error {
+	if err := tx.Create(journal).Error; err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].JournalID = journal.ID
+	}
+	return tx.Create(&entries).Error
+}
+
+// GetMerchantsWithoutLedger 余额不为 0 且尚未建立账本科目的商家（账本上线前的历史余额）
+func GetMerchantsWithoutLedger() ([]mdb.Merchant, error) {
+	var merchants []mdb.Merchant
+	err := dao.Mdb.Model(&mdb.Merchant{}).
+		Where("balance <> 0 AND id NOT IN (?)",
+			dao.Mdb.Model(&mdb.LedgerAccount{}).Select("merchant_id").Where("merchant_id > 0")).
+		Find(&merchants).Error
+	return merchants, err
+}
+
+// GetMerchantLedgerEntries 分页查询商家账单
+func GetMerchantLedgerEntries(merchantID uint64, page, pageSize int, filter *LedgerEntryFilter) ([]mdb.LedgerEntry, int64, error) {
+	var list []mdb.LedgerEntry
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.LedgerEntry{}).Where("merchant_id = ?", merchantID)
+	if filter.Account != "" {
+		query = query.Where("account_code = ?", mdb.MerchantLedgerCode(merchantID, filter.Account))
+	}
+	if filter.BizType != "" {
+		query = query.Where("biz_type = ?", filter.BizType)
+	}
+	query = createdAtRange(query, filter.StartTime, filter.EndTime)
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+var ledgerEntryListSpec = &listSpec{
+	amount:   "amount",
+	merchant: anyColumn("merchant_id"),
+	keyword:  anyColumn("biz_no", "account_code", "biz_type"),
+	sorts:    []string{"created_at", "amount"},
+}
+
+// ListLedgerEntries 管理后台账本分录列表
+func ListLedgerEntries(filter *ListFilter, q *page.Query) (*ListResult[mdb.LedgerEntry], error) {
+	return listPage[mdb.LedgerEntry](ledgerEntryListSpec, filter, q)
+}
+
+// GetLedgerJournalByBiz 按业务单据查询凭证
+func GetLedgerJournalByBiz(bizType, bizNo string) (*mdb.LedgerJournal, error) {
+	journal := new(mdb.LedgerJournal)
+	err := dao.Mdb.Model(journal).Limit(1).Find(journal, "biz_type = ? AND biz_no = ?", bizType, bizNo).Error
+	return journal, err
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrCursorSort 游标分页只能按 id 排序
+var ErrCursorSort = errors.New("游标分页仅支持按 id 排序")
+
+// ListFilter 管理后台列表通用筛选条件，零值表示不筛选，列表不支持的条件会被忽略
+type ListFilter struct {
+	Status     int
+	Chain      string
+	Wallet     string // 钱包地址，匹配收款、付款等地址字段
+	MerchantID uint64
+	MinAmount  decimal.Decimal
+	MaxAmount  decimal.Decimal
+	StartTime  time.Time
+	EndTime    time.Time
+	Keyword    string // 单号、交易哈希等ID，精确匹配
+}
+
+// ListResult 列表查询结果，游标分页时不统计总数，Total 为 -1
+type ListResult[T any] struct {
+	List       []T
+	Total      int64
+	NextCursor uint64 // 下一页游标，按 id 排序且本页已满时返回
+}
+
+// listScope 按筛选值限定查询范围
+type listScope func(db *gorm.DB, value interface{}) *gorm.DB
+
+// listSpec 列表可筛选、可排序的字段，为空表示不支持该条件
+type listSpec struct {
+	status   string
+	chain    string
+	amount   string
+	wallet   listScope
+	merchant listScope
+	keyword  listScope
+	sorts    []string // 除 id 外可排序的字段
+}
+
+// anyColumn 任一字段等于筛选值
+func anyColumn(columns ...string) listScope {
+	conds := make([]string, len(columns))
+	for i, column := range columns {
+		conds[i] = column + " = ?"
+	}
+	cond := strings.Join(conds, " OR ")
+	return func(db *gorm.DB, value interface{}) *gorm.DB {
+		args := make([]interface{}, len(columns))
+		for i := range args {
+			args[i] = value
+		}
+		return db.Where(cond, args...)
+	}
+}
+
+// inSubQuery 字段在子查询结果中
+func inSubQuery(column string, sub func(value interface{}) *gorm.DB) listScope {
+	return func(db *gorm.DB, value interface{}) *gorm.DB {
+		return db.Where(column+" IN (?)", sub(value))
+	}
+}
+
+// merchantWalletTokens 商家的结算钱包
+func merchantWalletTokens(merchantID interface{}) *gorm.DB {
+	return dao.Mdb.Model(&mdb.Merchant{}).Unscoped().Select("wallet_token").Where("id = ?", merchantID)
+}
+
+// merchantReceiveWallets 商家的收款钱包
+func merchantReceiveWallets(merchantID interface{}) *gorm.DB {
+	return dao.Mdb.Model(&mdb.WalletAddress{}).Unscoped().Select("token").Where("merchant_id = ?", merchantID)
+}
+
+// apply 将筛选条件应用到查询
+func (s *listSpec) apply(query *gorm.DB, f *ListFilter) *gorm.DB {
+	if f == nil {
+		return query
+	}
+	if s.status != "" && f.Status > 0 {
+		query = query.Where(s.status+" = ?", f.Status)
+	}
+	if s.chain != "" && f.Chain != "" {
+		query = query.Where(s.chain+" = ?", f.Chain)
+	}
+	if s.wallet != nil && f.Wallet != "" {
+		query = s.wallet(query, f.Wallet)
+	}
+	if s.merchant != nil && f.MerchantID > 0 {
+		query = s.merchant(query, f.MerchantID)
+	}
+	if s.amount != "" && f.MinAmount.IsPositive() {
+		query = query.Where(s.amount+" >= ?", f.MinAmount)
+	}
+	if s.amount != "" && f.MaxAmount.IsPositive() {
+		query = query.Where(s.amount+" <= ?", f.MaxAmount)
+	}
+	if s.keyword != nil && f.Keyword != "" {
+		query = s.keyword(query, f.Keyword)
+	}
+	return createdAtRange(query, f.StartTime, f.EndTime)
+}
+
+// listPage 按筛选条件分页查询，传入游标时按 id 翻页且不统计总数
+func listPage[T interface{ GetID() uint64 }](spec *listSpec, filter *ListFilter, q *page.Query) (*ListResult[T], error) {
+	field, desc := q.SortField()
+	if field != "id" && !slices.Contains(spec.sorts, field) {
+		return nil, fmt.Errorf("不支持的排序字段: %s", field)
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	result := &ListResult[T]{Total: -1}
+	query := spec.apply(dao.Mdb.Model(new(T)), filter)
+	if q.Cursor > 0 {
+		if field != "id" {
+			return nil, ErrCursorSort
+		}
+		if desc {
+			query = query.Where("id < ?", q.Cursor)
+		} else {
+			query = query.Where("id > ?", q.Cursor)
+		}
+	} else {
+		if err := query.Count(&result.Total).Error; err != nil {
+			return nil, err
+		}
+		query = query.Offset(q.Offset())
+	}
+
+	order := field + " " + direction
+	if field != "id" {
+		// 相同排序值按 id 保持稳定顺序
+		order += ", id " + direction
+	}
+	if err := query.Order(order).Limit(q.PageSize).Find(&result.List).Error; err != nil {
+		return nil, err
+	}
+	if n := len(result.List); field == "id" && n > 0 && n == q.PageSize {
+		result.NextCursor = result.List[n-1].GetID()
+	}
+	return result, nil
+}
@@ -3,6 +3,8 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
 )
 
 // CreateMerchant 创建商家
@@ -41,11 +43,33 @@ func UpdateMerchant(merchant *mdb.Merchant) error {
 	return dao.Mdb.Save(merchant).Error
 }
 
-// ListMerchants 列出所有商家
-func ListMerchants(limit int) ([]mdb.Merchant, error) {
-	var merchants []mdb.Merchant
-	err := dao.Mdb.Model(&mdb.Merchant{}).Order("id DESC").Limit(limit).Find(&merchants).Error
-	return merchants, err
+// UpdateMerchantApiToken 更新商家API密钥
+func UpdateMerchantApiToken(id uint64, apiToken string) error {
+	return dao.Mdb.Model(&mdb.Merchant{}).Where("id = ?", id).Update("api_token", apiToken).Error
+}
+
+// UpdateMerchantCallbackAck 更新商家回调确认规则
+func UpdateMerchantCallbackAck(id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.Merchant{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// UpdateMerchantCheckoutBranding 更新商家收银台品牌
+func UpdateMerchantCheckoutBranding(id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.Merchant{}).Where("id = ?", id).Updates(updates).Error
+}
+
+var merchantListSpec = &listSpec{
+	status:   "status",
+	amount:   "balance",
+	wallet:   anyColumn("wallet_token"),
+	merchant: anyColumn("id"),
+	keyword:  anyColumn("username", "email", "merchant_name"),
+	sorts:    []string{"created_at", "balance", "last_login_at"},
+}
+
+// ListMerchants 管理后台商家列表
+func ListMerchants(filter *ListFilter, q *page.Query) (*ListResult[mdb.Merchant], error) {
+	return listPage[mdb.Merchant](merchantListSpec, filter, q)
 }
 
 // UpdateMerchantStatus 更新商家状态（封禁/解封）
@@ -120,8 +144,11 @@ func GetMerchantStats(merchantWallet string, startTime, endTime int64) (map[stri
 	stats := make(map[string]interface{})
 
 	if len(authIDs) == 0 {
-		stats["total_amount_usdt"] = 0.0
-		stats["total_amount_cny"] = 0.0
+		stats["total_amount_usdt"] = decimal.Zero
+		stats["total_amount_cny"] = decimal.Zero
+		stats["gross_amount_usdt"] = decimal.Zero
+		stats["fee_amount_usdt"] = decimal.Zero
+		stats["net_amount_usdt"] = decimal.Zero
 		stats["total_count"] = 0
 		stats["success_count"] = 0
 		stats["failed_count"] = 0
@@ -130,8 +157,8 @@ func GetMerchantStats(merchantWallet string, startTime, endTime int64) (map[stri
 
 	// 统计扣款数据
 	type Result struct {
-		TotalAmountUsdt float64
-		TotalAmountCny  float64
+		TotalAmountUsdt decimal.Decimal
+		TotalAmountCny  decimal.Decimal
 		TotalCount      int64
 		SuccessCount    int64
 		FailedCount     int64
@@ -148,7 +175,7 @@ func GetMerchantStats(merchantWallet string, startTime, endTime int64) (map[stri
 		query = query.Where("deduct_time <= ?", endTime)
 	}
 
-	query.Select("SUM(amount_usdt) as total_amount_usdt, SUM(amount_cny) as total_amount_cny, COUNT(*) as total_count").Scan(&result)
+	query.Select("COALESCE(SUM(amount_usdt), 0) as total_amount_usdt, COALESCE(SUM(amount_cny), 0) as total_amount_cny, COUNT(*) as total_count").Scan(&result)
 
 	// 成功数量
 	dao.Mdb.Model(&mdb.KtvDeduction{}).Where("auth_id IN ? AND status = 2", authIDs).
@@ -160,8 +187,21 @@ func GetMerchantStats(merchantWallet string, startTime, endTime int64) (map[stri
 		Where("deduct_time >= ? AND deduct_time <= ?", startTime, endTime).
 		Count(&result.FailedCount)
 
+	// 成功扣款的总额与平台手续费
+	var settled struct {
+		GrossAmountUsdt decimal.Decimal
+		FeeAmountUsdt   decimal.Decimal
+	}
+	dao.Mdb.Model(&mdb.KtvDeduction{}).Where("auth_id IN ? AND status = 2", authIDs).
+		Where("deduct_time >= ? AND deduct_time <= ?", startTime, endTime).
+		Select("COALESCE(SUM(amount_usdt), 0) as gross_amount_usdt, COALESCE(SUM(fee_amount), 0) as fee_amount_usdt").
+		Scan(&settled)
+
 	stats["total_amount_usdt"] = result.TotalAmountUsdt
 	stats["total_amount_cny"] = result.TotalAmountCny
+	stats["gross_amount_usdt"] = settled.GrossAmountUsdt
+	stats["fee_amount_usdt"] = settled.FeeAmountUsdt
+	stats["net_amount_usdt"] = settled.GrossAmountUsdt.Sub(settled.FeeAmountUsdt)
 	stats["total_count"] = result.TotalCount
 	stats["success_count"] = result.SuccessCount
 	stats["failed_count"] = result.FailedCount
@@ -184,14 +224,15 @@ func GetMerchantChartData(merchantWallet string, startTime, endTime int64) ([]ma
 	// 按日期分组统计
 	type DailyStats struct {
 		Date        string  `json:"date"`
-		AmountUsdt  float64 `json:"amount_usdt"`
-		AmountCny   float64 `json:"amount_cny"`
+		AmountUsdt  decimal.Decimal `json:"amount_usdt"`
+		AmountCny   decimal.Decimal `json:"amount_cny"`
+		FeeUsdt     decimal.Decimal `json:"fee_usdt"`
 		Count       int64   `json:"count"`
 	}
 
 	var dailyStats []DailyStats
 	err := dao.Mdb.Model(&mdb.KtvDeduction{}).
-		Select("DATE(FROM_UNIXTIME(deduct_time)) as date, SUM(amount_usdt) as amount_usdt, SUM(amount_cny) as amount_cny, COUNT(*) as count").
+		Select("DATE(FROM_UNIXTIME(deduct_time)) as date, SUM(amount_usdt) as amount_usdt, SUM(amount_cny) as amount_cny, SUM(fee_amount) as fee_usdt, COUNT(*) as count").
 		Where("auth_id IN ? AND status = 2", authIDs).
 		Where("deduct_time >= ? AND deduct_time <= ?", startTime, endTime).
 		Group("date").
@@ -207,6 +248,8 @@ func GetMerchantChartData(merchantWallet string, startTime, endTime int64) ([]ma
 			"date":        stat.Date,
 			"amount_usdt": stat.AmountUsdt,
 			"amount_cny":  stat.AmountCny,
+			"fee_usdt":    stat.FeeUsdt,
+			"net_usdt":    stat.AmountUsdt.Sub(stat.FeeUsdt),
 			"count":       stat.Count,
 		})
 	}
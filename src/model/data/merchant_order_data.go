@@ -0,0 +1,73 @@
+package data
+
+import (
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"gorm.io/gorm"
+)
+
+// OrderFilter 订单列表筛选条件，零值表示不筛选
+type OrderFilter struct {
+	Status    int
+	Chain     string
+	OrderId   string // 商户订单号或平台交易号
+	TxHash    string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// apply 将筛选条件应用到订单查询
+func (f *OrderFilter) apply(query *gorm.DB) *gorm.DB {
+	if f == nil {
+		return query
+	}
+	if f.Status > 0 {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Chain != "" {
+		query = query.Where("chain = ?", f.Chain)
+	}
+	if f.OrderId != "" {
+		query = query.Where("order_id = ? OR trade_id = ?", f.OrderId, f.OrderId)
+	}
+	if f.TxHash != "" {
+		// 多笔转账累计的订单，任一笔转账的哈希均可查到
+		query = query.Where("block_transaction_id = ? OR trade_id IN (?)", f.TxHash,
+			dao.Mdb.Model(&mdb.OrderTransfer{}).Select("trade_id").Where("tx_hash = ?", f.TxHash))
+	}
+	return createdAtRange(query, f.StartTime, f.EndTime)
+}
+
+// merchantOrderScope 商家订单范围：记录了商家ID的订单，以及早期未记录商家ID、通过收款链接或商家钱包收款的订单
+func merchantOrderScope(merchantID uint64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("merchant_id = ? OR (merchant_id = 0 AND (payment_link_id IN (?) OR token IN (?)))",
+			merchantID,
+			dao.Mdb.Model(&mdb.PaymentLink{}).Unscoped().Select("id").Where("merchant_id = ?", merchantID),
+			dao.Mdb.Model(&mdb.WalletAddress{}).Unscoped().Select("token").Where("merchant_id = ?", merchantID),
+		)
+	}
+}
+
+// GetMerchantOrders 分页查询商家订单
+func GetMerchantOrders(merchantID uint64, page, pageSize int, filter *OrderFilter) ([]mdb.Orders, int64, error) {
+	var list []mdb.Orders
+	var total int64
+
+	query := filter.apply(dao.Mdb.Model(&mdb.Orders{}).Scopes(merchantOrderScope(merchantID)))
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetMerchantOrderByTradeId 查询商家的订单
+func GetMerchantOrderByTradeId(merchantID uint64, tradeId string) (*mdb.Orders, error) {
+	order := new(mdb.Orders)
+	err := dao.Mdb.Model(order).Scopes(merchantOrderScope(merchantID)).
+		Where("trade_id = ?", tradeId).Limit(1).Find(order).Error
+	return order, err
+}
@@ -3,31 +3,58 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"gorm.io/gorm"
 )
 
-func ListOrders(limit int) ([]mdb.Orders, error) {
-	var orders []mdb.Orders
-	if limit <= 0 {
-		limit = 200
-	}
-	err := dao.Mdb.Model(&mdb.Orders{}).Order("id desc").Limit(limit).Find(&orders).Error
-	return orders, err
+var orderListSpec = &listSpec{
+	status: "status",
+	chain:  "chain",
+	amount: "actual_amount",
+	wallet: anyColumn("token", "from_address"),
+	merchant: func(db *gorm.DB, value interface{}) *gorm.DB {
+		return db.Scopes(merchantOrderScope(value.(uint64)))
+	},
+	keyword: anyColumn("trade_id", "order_id", "block_transaction_id"),
+	sorts:   []string{"created_at", "updated_at", "amount", "actual_amount"},
 }
 
-func ListAuthorizations(limit int) ([]mdb.KtvAuthorize, error) {
-	var auths []mdb.KtvAuthorize
-	if limit <= 0 {
-		limit = 200
-	}
-	err := dao.Mdb.Model(&mdb.KtvAuthorize{}).Order("id desc").Limit(limit).Find(&auths).Error
-	return auths, err
+var authorizationListSpec = &listSpec{
+	status:   "status",
+	chain:    "chain",
+	amount:   "authorized_usdt",
+	wallet:   anyColumn("merchant_wallet", "customer_wallet"),
+	merchant: inSubQuery("merchant_wallet", merchantWalletTokens),
+	keyword:  anyColumn("auth_no", "tx_hash"),
+	sorts:    []string{"created_at", "authorized_usdt", "remaining_usdt", "expire_time"},
 }
 
-func ListDeductions(limit int) ([]mdb.KtvDeduction, error) {
-	var deducts []mdb.KtvDeduction
-	if limit <= 0 {
-		limit = 200
-	}
-	err := dao.Mdb.Model(&mdb.KtvDeduction{}).Order("id desc").Limit(limit).Find(&deducts).Error
-	return deducts, err
+var deductionListSpec = &listSpec{
+	status: "status",
+	amount: "amount_usdt",
+	wallet: inSubQuery("auth_id", func(value interface{}) *gorm.DB {
+		return dao.Mdb.Model(&mdb.KtvAuthorize{}).Unscoped().Select("id").
+			Where("merchant_wallet = ? OR customer_wallet = ?", value, value)
+	}),
+	merchant: inSubQuery("auth_id", func(value interface{}) *gorm.DB {
+		return dao.Mdb.Model(&mdb.KtvAuthorize{}).Unscoped().Select("id").
+			Where("merchant_wallet IN (?)", merchantWalletTokens(value))
+	}),
+	keyword: anyColumn("deduct_no", "auth_no", "tx_hash"),
+	sorts:   []string{"created_at", "amount_usdt", "deduct_time"},
+}
+
+// ListOrders 管理后台订单列表
+func ListOrders(filter *ListFilter, q *page.Query) (*ListResult[mdb.Orders], error) {
+	return listPage[mdb.Orders](orderListSpec, filter, q)
+}
+
+// ListAuthorizations 管理后台授权列表
+func ListAuthorizations(filter *ListFilter, q *page.Query) (*ListResult[mdb.KtvAuthorize], error) {
+	return listPage[mdb.KtvAuthorize](authorizationListSpec, filter, q)
+}
+
+// ListDeductions 管理后台扣款列表
+func ListDeductions(filter *ListFilter, q *page.Query) (*ListResult[mdb.KtvDeduction], error) {
+	return listPage[mdb.KtvDeduction](deductionListSpec, filter, q)
 }
@@ -5,16 +5,30 @@ import (
 	"fmt"
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
-	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/chain"
 	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
 var (
-	CacheWalletAddressWithAmountToTradeIdKey = "wallet:%s_%v" // 钱包_待支付金额 : 交易号
+	CacheWalletAddressWithAmountToTradeIdKey      = "wallet:%s_%v"      // 钱包_待支付金额 : 交易号
+	CacheWalletAddressTokenWithAmountToTradeIdKey = "wallet:%s_%s_%v"   // 钱包_代币_待支付金额 : 交易号（非USDT代币）
+	CacheOrderIdCreatingKey                       = "order:creating:%s" // 商户订单号创建锁
 )
 
+// walletLockKey 钱包金额锁的缓存键，USDT 沿用原有格式
+// 金额按去掉末尾 0 的十进制输出，与数据库读出的精度无关，也与原 float64 键一致
+func walletLockKey(token, tokenSymbol string, amount decimal.Decimal) string {
+	tokenSymbol = chain.NormalizeTokenSymbol(tokenSymbol)
+	if tokenSymbol == chain.TokenUsdt {
+		return fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, token, amount.String())
+	}
+	return fmt.Sprintf(CacheWalletAddressTokenWithAmountToTradeIdKey, token, tokenSymbol, amount.String())
+}
+
 // GetOrderInfoByOrderId 通过客户订单号查询订单
 func GetOrderInfoByOrderId(orderId string) (*mdb.Orders, error) {
 	order := new(mdb.Orders)
@@ -42,14 +56,95 @@ func GetOrderByBlockIdWithTransaction(tx *gorm.DB, blockId string) (*mdb.Orders,
 	return order, err
 }
 
-// OrderSuccessWithTransaction 事务支付成功
-func OrderSuccessWithTransaction(tx *gorm.DB, req *request.OrderProcessingRequest) error {
-	err := tx.Model(&mdb.Orders{}).Where("trade_id = ?", req.TradeId).Updates(map[string]interface{}{
-		"block_transaction_id": req.BlockTransactionId,
-		"status":               mdb.StatusPaySuccess,
-		"callback_confirm":     mdb.CallBackConfirmNo,
+// GetOrderInfoByTradeIdWithTransaction 事务内通过交易号查询订单并锁定该行，
+// 实收金额等读改写更新在事务提交前串行执行
+func GetOrderInfoByTradeIdWithTransaction(tx *gorm.DB, tradeId string) (*mdb.Orders, error) {
+	order := new(mdb.Orders)
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(order).Limit(1).Find(order, "trade_id = ?", tradeId).Error
+	return order, err
+}
+
+// UpdateOrderPaymentWithTransaction 事务更新订单入账信息
+func UpdateOrderPaymentWithTransaction(tx *gorm.DB, tradeId string, updates map[string]interface{}) error {
+	return tx.Model(&mdb.Orders{}).Where("trade_id = ?", tradeId).Updates(updates).Error
+}
+
+// GetPendingOrdersByToken 查询钱包下某代币仍在等待入账的订单
+func GetPendingOrdersByToken(token, chainName, tokenSymbol string) ([]mdb.Orders, error) {
+	var orders []mdb.Orders
+	err := dao.Mdb.Model(&mdb.Orders{}).
+		Where("token = ? AND chain = ? AND token_symbol = ?", token, chainName, chain.NormalizeTokenSymbol(tokenSymbol)).
+		Where("status IN ?", []int{mdb.StatusWaitPay, mdb.StatusPartiallyPaid, mdb.StatusConfirming}).
+		Order("id asc").
+		Find(&orders).Error
+	return orders, err
+}
+
+// GetOrderTransferByTxHash 通过交易哈希、收款地址和交易内序号查询入账记录
+func GetOrderTransferByTxHash(tx *gorm.DB, txHash, toAddress string, logIndex uint) (*mdb.OrderTransfer, error) {
+	transfer := new(mdb.OrderTransfer)
+	err := tx.Model(transfer).Limit(1).Find(transfer, "tx_hash = ? AND to_address = ? AND log_index = ?", txHash, toAddress, logIndex).Error
+	return transfer, err
+}
+
+// CreateOrderTransferWithTransaction 事务创建入账记录
+func CreateOrderTransferWithTransaction(tx *gorm.DB, transfer *mdb.OrderTransfer) error {
+	return tx.Create(transfer).Error
+}
+
+// UpdateOrderTransferWithTransaction 事务更新入账记录
+func UpdateOrderTransferWithTransaction(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&mdb.OrderTransfer{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetOrderTransferByIdWithTransaction 事务内通过id查询入账记录
+func GetOrderTransferByIdWithTransaction(tx *gorm.DB, id uint64) (*mdb.OrderTransfer, error) {
+	transfer := new(mdb.OrderTransfer)
+	err := tx.Model(transfer).Limit(1).Find(transfer, "id = ?", id).Error
+	return transfer, err
+}
+
+// MarkOrderTransferMissing 记录一次查不到交易，首次记录当时的最新区块高度
+func MarkOrderTransferMissing(transfer *mdb.OrderTransfer, latestBlock uint64) error {
+	transfer.MissingCount++
+	if transfer.MissingSince == 0 {
+		transfer.MissingSince = latestBlock
+	}
+	return dao.Mdb.Model(&mdb.OrderTransfer{}).Where("id = ?", transfer.ID).Updates(map[string]interface{}{
+		"missing_count": transfer.MissingCount,
+		"missing_since": transfer.MissingSince,
+	}).Error
+}
+
+// ResetOrderTransferMissing 交易重新查到后清除缺失记录
+func ResetOrderTransferMissing(id uint64) error {
+	return dao.Mdb.Model(&mdb.OrderTransfer{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"missing_count": 0,
+		"missing_since": 0,
 	}).Error
-	return err
+}
+
+// GetConfirmingOrderTransfers 查询等待区块确认的入账记录
+func GetConfirmingOrderTransfers() ([]mdb.OrderTransfer, error) {
+	var list []mdb.OrderTransfer
+	err := dao.Mdb.Model(&mdb.OrderTransfer{}).Where("status = ?", mdb.TransferStatusConfirming).Order("id asc").Find(&list).Error
+	return list, err
+}
+
+// CountConfirmingTransfersByTradeId 统计订单下其它仍在确认中的入账记录
+func CountConfirmingTransfersByTradeId(tx *gorm.DB, tradeId string, excludeId uint64) (int64, error) {
+	var count int64
+	err := tx.Model(&mdb.OrderTransfer{}).
+		Where("trade_id = ? AND status = ? AND id <> ?", tradeId, mdb.TransferStatusConfirming, excludeId).
+		Count(&count).Error
+	return count, err
+}
+
+// GetOrderTransfersByTradeId 获取订单的入账记录
+func GetOrderTransfersByTradeId(tradeId string) ([]mdb.OrderTransfer, error) {
+	var list []mdb.OrderTransfer
+	err := dao.Mdb.Model(&mdb.OrderTransfer{}).Where("trade_id = ?", tradeId).Order("id asc").Find(&list).Error
+	return list, err
 }
 
 // GetPendingCallbackOrders 查询出等待回调的订单
@@ -58,7 +153,7 @@ func GetPendingCallbackOrders() ([]mdb.Orders, error) {
 	err := dao.Mdb.Model(orders).
 		Where("callback_num < ?", 5).
 		Where("callback_confirm = ?", mdb.CallBackConfirmNo).
-		Where("status = ?", mdb.StatusPaySuccess).
+		Where("status IN ?", []int{mdb.StatusPaySuccess, mdb.StatusOverpaid, mdb.StatusPartiallyPaid}).
 		Find(&orders).Error
 	return orders, err
 }
@@ -78,10 +173,10 @@ func UpdateOrderIsExpirationById(id uint64) error {
 	return err
 }
 
-// GetTradeIdByWalletAddressAndAmount 通过钱包地址，支付金额获取交易号
-func GetTradeIdByWalletAddressAndAmount(token string, amount float64) (string, error) {
+// GetTradeIdByWalletAddressAndAmount 通过钱包地址，代币，支付金额获取交易号
+func GetTradeIdByWalletAddressAndAmount(token, tokenSymbol string, amount decimal.Decimal) (string, error) {
 	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, token, amount)
+	cacheKey := walletLockKey(token, tokenSymbol, amount)
 	result, err := dao.Rdb.Get(ctx, cacheKey).Result()
 	if err == redis.Nil {
 		return "", nil
@@ -92,18 +187,36 @@ func GetTradeIdByWalletAddressAndAmount(token string, amount float64) (string, e
 	return result, nil
 }
 
-// LockTransaction 锁定交易
-func LockTransaction(token, tradeId string, amount float64, expirationTime time.Duration) error {
+// unlockTransactionScript 仅当锁仍属于该交易号时删除，避免误删其它订单的锁
+var unlockTransactionScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// TryLockTransaction 原子锁定钱包金额（SETNX），已被占用时返回 false
+func TryLockTransaction(token, tokenSymbol, tradeId string, amount decimal.Decimal, expirationTime time.Duration) (bool, error) {
 	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, token, amount)
-	err := dao.Rdb.Set(ctx, cacheKey, tradeId, expirationTime).Err()
-	return err
+	cacheKey := walletLockKey(token, tokenSymbol, amount)
+	return dao.Rdb.SetNX(ctx, cacheKey, tradeId, expirationTime).Result()
 }
 
-// UnLockTransaction 解锁交易
-func UnLockTransaction(token string, amount float64) error {
+// UnLockTransaction 解锁交易，锁已过期或被其它订单占用时不处理
+func UnLockTransaction(token, tokenSymbol, tradeId string, amount decimal.Decimal) error {
 	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, token, amount)
-	err := dao.Rdb.Del(ctx, cacheKey).Err()
-	return err
+	cacheKey := walletLockKey(token, tokenSymbol, amount)
+	return unlockTransactionScript.Run(ctx, dao.Rdb, []string{cacheKey}, tradeId).Err()
+}
+
+// TryLockOrderId 防止多实例并发创建同一商户订单号，已被占用时返回 false
+func TryLockOrderId(orderId string, expirationTime time.Duration) (bool, error) {
+	ctx := context.Background()
+	return dao.Rdb.SetNX(ctx, fmt.Sprintf(CacheOrderIdCreatingKey, orderId), 1, expirationTime).Result()
+}
+
+// UnLockOrderId 释放订单号创建锁
+func UnLockOrderId(orderId string) error {
+	ctx := context.Background()
+	return dao.Rdb.Del(ctx, fmt.Sprintf(CacheOrderIdCreatingKey, orderId)).Err()
 }
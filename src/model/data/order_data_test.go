@@ -0,0 +1,104 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/assimon/luuu/model/dao"
+	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRedis 内存 redis，并替换 dao.Rdb，测试结束后还原
+func setupTestRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	original := dao.Rdb
+	dao.Rdb = client
+	t.Cleanup(func() {
+		dao.Rdb = original
+		_ = client.Close()
+	})
+	return mr
+}
+
+// TestWalletLockKey 测试 USDT 沿用旧键格式，其它代币的键带代币符号，互不冲突
+func TestWalletLockKey(t *testing.T) {
+	amount := decimal.RequireFromString("10.01")
+	testCases := []struct {
+		name        string
+		tokenSymbol string
+		expected    string
+	}{
+		{"USDT", "USDT", "wallet:0xwallet_10.01"},
+		{"USDT 小写", "usdt", "wallet:0xwallet_10.01"},
+		{"USDC", "USDC", "wallet:0xwallet_USDC_10.01"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, walletLockKey("0xwallet", tc.tokenSymbol, amount))
+		})
+	}
+}
+
+// TestTryLockTransaction 测试钱包金额预占与释放
+func TestTryLockTransaction(t *testing.T) {
+	amount := decimal.RequireFromString("10.01")
+	testCases := []struct {
+		name   string
+		setup  func(t *testing.T) // 预占前的准备
+		symbol string
+		want   bool
+	}{
+		{"未占用可预占", func(t *testing.T) {}, "USDT", true},
+		{"已被其它订单占用", func(t *testing.T) {
+			lockForTest(t, "USDT", "T0")
+		}, "USDT", false},
+		{"不同代币相同金额互不影响", func(t *testing.T) {
+			lockForTest(t, "USDC", "T0")
+		}, "USDT", true},
+		{"用其它交易号解锁不生效", func(t *testing.T) {
+			lockForTest(t, "USDT", "T0")
+			require.NoError(t, UnLockTransaction("0xwallet", "USDT", "T-other", amount))
+		}, "USDT", false},
+		{"本订单解锁后可再次预占", func(t *testing.T) {
+			lockForTest(t, "USDT", "T0")
+			require.NoError(t, UnLockTransaction("0xwallet", "USDT", "T0", amount))
+		}, "USDT", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTestRedis(t)
+			tc.setup(t)
+			locked, err := TryLockTransaction("0xwallet", tc.symbol, "T1", amount, time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, locked)
+		})
+	}
+}
+
+// TestLockTransactionExpire 测试预占到期后自动释放
+func TestLockTransactionExpire(t *testing.T) {
+	mr := setupTestRedis(t)
+	amount := decimal.RequireFromString("10.01")
+	lockForTest(t, "USDT", "T0")
+
+	tradeId, err := GetTradeIdByWalletAddressAndAmount("0xwallet", "USDT", amount)
+	require.NoError(t, err)
+	assert.Equal(t, "T0", tradeId)
+
+	mr.FastForward(2 * time.Minute)
+	tradeId, err = GetTradeIdByWalletAddressAndAmount("0xwallet", "USDT", amount)
+	require.NoError(t, err)
+	assert.Empty(t, tradeId)
+}
+
+// lockForTest 以交易号预占 0xwallet 上 10.01 的金额，一分钟后过期
+func lockForTest(t *testing.T, tokenSymbol, tradeId string) {
+	locked, err := TryLockTransaction("0xwallet", tokenSymbol, tradeId, decimal.RequireFromString("10.01"), time.Minute)
+	require.NoError(t, err)
+	require.True(t, locked)
+}
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"gorm.io/gorm"
+)
+
+// CreatePaymentLink 创建收款链接
+func CreatePaymentLink(link *mdb.PaymentLink) error {
+	return dao.Mdb.Create(link).Error
+}
+
+// GetPaymentLinkBySlug 通过标识查询收款链接
+func GetPaymentLinkBySlug(slug string) (*mdb.PaymentLink, error) {
+	link := new(mdb.PaymentLink)
+	err := dao.Mdb.Model(link).Limit(1).Find(link, "slug = ?", slug).Error
+	return link, err
+}
+
+// GetMerchantPaymentLink 查询商家的收款链接
+func GetMerchantPaymentLink(merchantID, id uint64) (*mdb.PaymentLink, error) {
+	link := new(mdb.PaymentLink)
+	err := dao.Mdb.Model(link).Limit(1).Find(link, "id = ? AND merchant_id = ?", id, merchantID).Error
+	return link, err
+}
+
+// GetMerchantPaymentLinks 分页查询商家的收款链接
+func GetMerchantPaymentLinks(merchantID uint64, page, pageSize int) ([]mdb.PaymentLink, int64, error) {
+	var list []mdb.PaymentLink
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.PaymentLink{}).Where("merchant_id = ?", merchantID)
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// UpdateMerchantPaymentLink 更新商家的收款链接
+func UpdateMerchantPaymentLink(merchantID, id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.PaymentLink{}).Where("id = ? AND merchant_id = ?", id, merchantID).Updates(updates).Error
+}
+
+// DeleteMerchantPaymentLink 删除商家的收款链接
+func DeleteMerchantPaymentLink(merchantID, id uint64) error {
+	return dao.Mdb.Where("id = ? AND merchant_id = ?", id, merchantID).Delete(&mdb.PaymentLink{}).Error
+}
+
+// IncrPaymentLinkUsage 占用一次使用次数，已达上限时返回 false
+func IncrPaymentLinkUsage(id uint64) (bool, error) {
+	result := dao.Mdb.Model(&mdb.PaymentLink{}).
+		Where("id = ? AND (max_uses = 0 OR used_count < max_uses)", id).
+		Update("used_count", gorm.Expr("used_count + ?", 1))
+	return result.RowsAffected > 0, result.Error
+}
+
+// DecrPaymentLinkUsage 下单失败时归还使用次数
+func DecrPaymentLinkUsage(id uint64) error {
+	return dao.Mdb.Model(&mdb.PaymentLink{}).
+		Where("id = ? AND used_count > 0", id).
+		Update("used_count", gorm.Expr("used_count - ?", 1)).Error
+}
+
+// GetOrderMerchantID 查询订单所属商家，早期收款链接订单未记录商家ID时通过收款链接查询
+func GetOrderMerchantID(order *mdb.Orders) (uint64, error) {
+	if order.MerchantID > 0 {
+		return order.MerchantID, nil
+	}
+	if order.PaymentLinkId <= 0 {
+		return 0, nil
+	}
+	var merchantID uint64
+	err := dao.Mdb.Model(&mdb.PaymentLink{}).Unscoped().Where("id = ?", order.PaymentLinkId).
+		Pluck("merchant_id", &merchantID).Error
+	return merchantID, err
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CreateRefundWithTransaction 事务创建退款记录
+func CreateRefundWithTransaction(tx *gorm.DB, refund *mdb.OrderRefund) error {
+	return tx.Create(refund).Error
+}
+
+// GetRefundByNo 通过退款单号获取
+func GetRefundByNo(refundNo string) (*mdb.OrderRefund, error) {
+	refund := new(mdb.OrderRefund)
+	err := dao.Mdb.Model(refund).Limit(1).Find(refund, "refund_no = ?", refundNo).Error
+	return refund, err
+}
+
+// GetRefundsByTradeId 获取订单的退款记录
+func GetRefundsByTradeId(tradeId string) ([]mdb.OrderRefund, error) {
+	var list []mdb.OrderRefund
+	err := dao.Mdb.Model(&mdb.OrderRefund{}).Where("trade_id = ?", tradeId).Order("id desc").Find(&list).Error
+	return list, err
+}
+
+// SumRefundAmountByStatus 统计订单指定状态的退款金额
+func SumRefundAmountByStatus(tx *gorm.DB, tradeId string, status ...int) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := tx.Model(&mdb.OrderRefund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("trade_id = ? AND status IN ?", tradeId, status).
+		Scan(&total).Error
+	return total, err
+}
+
+// TransitRefundStatus 按状态机流转退款状态，仅当当前状态为 from 时更新
+func TransitRefundStatus(tx *gorm.DB, refundNo string, from int, updates map[string]interface{}) (bool, error) {
+	result := tx.Model(&mdb.OrderRefund{}).
+		Where("refund_no = ? AND status = ?", refundNo, from).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateRefundLedgerPending 标记或清除退款的待补记状态
+func UpdateRefundLedgerPending(id uint64, pending bool) error {
+	value := 0
+	if pending {
+		value = 1
+	}
+	return dao.Mdb.Model(&mdb.OrderRefund{}).Where("id = ?", id).Update("ledger_pending", value).Error
+}
+
+// GetLedgerPendingRefunds 记账失败等待补记的退款
+func GetLedgerPendingRefunds() ([]mdb.OrderRefund, error) {
+	var list []mdb.OrderRefund
+	err := dao.Mdb.Model(&mdb.OrderRefund{}).Where("ledger_pending = ?", 1).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// SaveRefundCallbackResp 保存退款回调结果
+func SaveRefundCallbackResp(refund *mdb.OrderRefund) error {
+	return dao.Mdb.Model(refund).Where("id = ?", refund.ID).Updates(map[string]interface{}{
+		"callback_num":     gorm.Expr("callback_num + ?", 1),
+		"callback_confirm": refund.CallBackConfirm,
+	}).Error
+}
+
+// UpdateOrderRefundedWithTransaction 更新订单退款金额与状态
+func UpdateOrderRefundedWithTransaction(tx *gorm.DB, tradeId string, refundedAmount decimal.Decimal, status int) error {
+	return tx.Model(&mdb.Orders{}).Where("trade_id = ?", tradeId).Updates(map[string]interface{}{
+		"refunded_amount": refundedAmount,
+		"status":          status,
+	}).Error
+}
+
+// GetRefundsByMerchantID 获取商家的退款记录
+func GetRefundsByMerchantID(merchantID uint64, page, pageSize int) ([]mdb.OrderRefund, int64, error) {
+	var list []mdb.OrderRefund
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.OrderRefund{}).Where("merchant_id = ?", merchantID)
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+var refundListSpec = &listSpec{
+	status:   "status",
+	chain:    "chain",
+	amount:   "amount",
+	wallet:   anyColumn("to_wallet"),
+	merchant: anyColumn("merchant_id"),
+	keyword:  anyColumn("refund_no", "trade_id", "order_id", "tx_hash"),
+	sorts:    []string{"created_at", "amount", "refund_time"},
+}
+
+// ListRefunds 获取退款记录（管理员）
+func ListRefunds(filter *ListFilter, q *page.Query) (*ListResult[mdb.OrderRefund], error) {
+	return listPage[mdb.OrderRefund](refundListSpec, filter, q)
+}
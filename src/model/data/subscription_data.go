@@ -0,0 +1,84 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// CreateSubscription 创建订阅
+func CreateSubscription(sub *mdb.Subscription) error {
+	return dao.Mdb.Create(sub).Error
+}
+
+// GetSubscriptionById 通过ID查询订阅
+func GetSubscriptionById(id uint64) (*mdb.Subscription, error) {
+	sub := new(mdb.Subscription)
+	err := dao.Mdb.Model(sub).Limit(1).Find(sub, id).Error
+	return sub, err
+}
+
+// GetMerchantSubscription 查询商家的订阅
+func GetMerchantSubscription(merchantID, id uint64) (*mdb.Subscription, error) {
+	sub := new(mdb.Subscription)
+	err := dao.Mdb.Model(sub).Limit(1).Find(sub, "id = ? AND merchant_id = ?", id, merchantID).Error
+	return sub, err
+}
+
+// GetMerchantSubscriptions 分页查询商家的订阅
+func GetMerchantSubscriptions(merchantID uint64, page, pageSize int, status int) ([]mdb.Subscription, int64, error) {
+	var list []mdb.Subscription
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.Subscription{}).Where("merchant_id = ?", merchantID)
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetDueSubscriptions 获取已到扣款时间且未在扣款中的订阅
+func GetDueSubscriptions(now int64, limit int) ([]mdb.Subscription, error) {
+	var list []mdb.Subscription
+	err := dao.Mdb.Model(&mdb.Subscription{}).
+		Where("status IN ? AND billing = 0 AND next_billing_time <= ?",
+			[]int{mdb.SubscriptionStatusActive, mdb.SubscriptionStatusPastDue}, now).
+		Order("next_billing_time ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// MarkSubscriptionBilling 标记订阅扣款中并记录开始时间，已被标记时返回 false
+func MarkSubscriptionBilling(id uint64, startedAt int64) (bool, error) {
+	result := dao.Mdb.Model(&mdb.Subscription{}).
+		Where("id = ? AND billing = 0", id).
+		Updates(map[string]interface{}{"billing": 1, "billing_started_at": startedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetStaleBillingSubscriptions 扣款中状态开始于 before 之前仍未结束的订阅
+func GetStaleBillingSubscriptions(before int64, limit int) ([]mdb.Subscription, error) {
+	var list []mdb.Subscription
+	err := dao.Mdb.Model(&mdb.Subscription{}).
+		Where("billing = 1 AND billing_started_at < ?", before).
+		Order("billing_started_at ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// GetSubscriptionByNo 通过订阅编号查询订阅
+func GetSubscriptionByNo(subscriptionNo string) (*mdb.Subscription, error) {
+	sub := new(mdb.Subscription)
+	err := dao.Mdb.Model(sub).Limit(1).Find(sub, "subscription_no = ?", subscriptionNo).Error
+	return sub, err
+}
+
+// UpdateSubscriptionById 更新订阅
+func UpdateSubscriptionById(id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.Subscription{}).Where("id = ?", id).Updates(updates).Error
+}
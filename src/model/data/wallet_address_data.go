@@ -4,10 +4,11 @@ import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
 )
 
-// AddWalletAddress 创建钱包
-func AddWalletAddress(token, chain string) (*mdb.WalletAddress, error) {
+// AddWalletAddress 创建钱包，merchantID 为 0 表示平台钱包
+func AddWalletAddress(token, chain string, merchantID uint64) (*mdb.WalletAddress, error) {
 	exist, err := GetWalletAddressByTokenAndChain(token, chain)
 	if err != nil {
 		return nil, err
@@ -16,9 +17,10 @@ func AddWalletAddress(token, chain string) (*mdb.WalletAddress, error) {
 		return nil, constant.WalletAddressAlreadyExists
 	}
 	walletAddress := &mdb.WalletAddress{
-		Token:  token,
-		Chain:  chain,
-		Status: mdb.TokenStatusEnable,
+		Token:      token,
+		Chain:      chain,
+		MerchantID: merchantID,
+		Status:     mdb.TokenStatusEnable,
 	}
 	err = dao.Mdb.Create(walletAddress).Error
 	return walletAddress, err
@@ -67,6 +69,23 @@ func GetAvailableWalletAddressByChain(chain string) ([]mdb.WalletAddress, error)
 	return WalletAddressList, err
 }
 
+// GetOrderWalletAddressByChain 获得订单可用的收款钱包：商家在该链有启用的钱包时只用商家钱包，否则使用平台钱包
+func GetOrderWalletAddressByChain(merchantID uint64, chain string) ([]mdb.WalletAddress, error) {
+	var WalletAddressList []mdb.WalletAddress
+	if merchantID > 0 {
+		err := dao.Mdb.Model(WalletAddressList).
+			Where("status = ? AND chain = ? AND merchant_id = ?", mdb.TokenStatusEnable, chain, merchantID).
+			Find(&WalletAddressList).Error
+		if err != nil || len(WalletAddressList) > 0 {
+			return WalletAddressList, err
+		}
+	}
+	err := dao.Mdb.Model(WalletAddressList).
+		Where("status = ? AND chain = ? AND merchant_id = 0", mdb.TokenStatusEnable, chain).
+		Find(&WalletAddressList).Error
+	return WalletAddressList, err
+}
+
 // GetAllWalletAddress 获得所有钱包地址
 func GetAllWalletAddress() ([]mdb.WalletAddress, error) {
 	var WalletAddressList []mdb.WalletAddress
@@ -74,6 +93,19 @@ func GetAllWalletAddress() ([]mdb.WalletAddress, error) {
 	return WalletAddressList, err
 }
 
+var walletAddressListSpec = &listSpec{
+	status:   "status",
+	chain:    "chain",
+	wallet:   anyColumn("token"),
+	merchant: anyColumn("merchant_id"),
+	sorts:    []string{"created_at"},
+}
+
+// ListWalletAddress 管理后台钱包地址列表
+func ListWalletAddress(filter *ListFilter, q *page.Query) (*ListResult[mdb.WalletAddress], error) {
+	return listPage[mdb.WalletAddress](walletAddressListSpec, filter, q)
+}
+
 // ChangeWalletAddressStatus 启用禁用钱包
 func ChangeWalletAddressStatus(id uint64, status int) error {
 	err := dao.Mdb.Model(&mdb.WalletAddress{}).Where("id = ?", id).Update("status", status).Error
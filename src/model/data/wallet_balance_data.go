@@ -0,0 +1,52 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+)
+
+// GetWalletBalance 查询钱包余额快照
+func GetWalletBalance(chain, address string) (*mdb.WalletBalance, error) {
+	balance := new(mdb.WalletBalance)
+	err := dao.Mdb.Model(balance).Limit(1).Find(balance, "chain = ? AND address = ?", chain, address).Error
+	return balance, err
+}
+
+// SaveWalletBalance 新增或更新钱包余额快照
+func SaveWalletBalance(balance *mdb.WalletBalance) error {
+	return dao.Mdb.Save(balance).Error
+}
+
+// DeleteWalletBalancesExcept 删除不再监控的钱包快照
+func DeleteWalletBalancesExcept(ids []uint64) error {
+	query := dao.Mdb.Unscoped()
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	} else {
+		query = query.Where("1 = 1")
+	}
+	return query.Delete(&mdb.WalletBalance{}).Error
+}
+
+// GetWalletBalancesByMerchantID 商家收款钱包的余额快照
+func GetWalletBalancesByMerchantID(merchantID uint64) ([]mdb.WalletBalance, error) {
+	var list []mdb.WalletBalance
+	err := dao.Mdb.Where("merchant_id = ? AND kind = ?", merchantID, mdb.WalletKindReceive).
+		Order("chain ASC, id ASC").Find(&list).Error
+	return list, err
+}
+
+var walletBalanceListSpec = &listSpec{
+	status:   "low_gas", // status=1 仅返回原生币不足的钱包
+	chain:    "chain",
+	amount:   "usdt_balance",
+	wallet:   anyColumn("address"),
+	merchant: anyColumn("merchant_id"),
+	sorts:    []string{"usdt_balance", "native_balance", "updated_at"},
+}
+
+// ListWalletBalances 管理后台钱包余额列表
+func ListWalletBalances(filter *ListFilter, q *page.Query) (*ListResult[mdb.WalletBalance], error) {
+	return listPage[mdb.WalletBalance](walletBalanceListSpec, filter, q)
+}
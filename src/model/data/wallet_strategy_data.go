@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	CacheWalletRoundRobinKey = "wallet_select:rr:%s"        // 轮询计数，按链+商家
+	CacheWalletLastUsedKey   = "wallet_select:last_used:%s" // 钱包最近被选中的时间，按链
+)
+
+// NextWalletRoundRobin 递增并返回轮询计数，多实例共享
+func NextWalletRoundRobin(scope string) (int64, error) {
+	return dao.Rdb.Incr(context.Background(), fmt.Sprintf(CacheWalletRoundRobinKey, scope)).Result()
+}
+
+// MarkWalletUsed 记录钱包被选中的时间
+func MarkWalletUsed(chain, token string) error {
+	return dao.Rdb.HSet(context.Background(), fmt.Sprintf(CacheWalletLastUsedKey, chain), token, time.Now().UnixNano()).Err()
+}
+
+// GetWalletLastUsed 钱包最近被选中的时间(纳秒)，从未被选中的不在结果中
+func GetWalletLastUsed(chain string, tokens []string) (map[string]int64, error) {
+	result := make(map[string]int64, len(tokens))
+	if len(tokens) == 0 {
+		return result, nil
+	}
+	values, err := dao.Rdb.HMGet(context.Background(), fmt.Sprintf(CacheWalletLastUsedKey, chain), tokens...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+			result[tokens[i]] = ts
+		}
+	}
+	return result, nil
+}
+
+// GetWalletLockedAmounts 钱包当前占用的金额：待入账订单的应付金额 + 生效中授权的剩余额度
+func GetWalletLockedAmounts(chain string, tokens []string) (map[string]decimal.Decimal, error) {
+	result := make(map[string]decimal.Decimal, len(tokens))
+	if len(tokens) == 0 {
+		return result, nil
+	}
+	type row struct {
+		Token  string
+		Amount decimal.Decimal
+	}
+	var orderRows []row
+	err := dao.Mdb.Model(&mdb.Orders{}).
+		Select("token, COALESCE(SUM(actual_amount), 0) AS amount").
+		Where("chain = ? AND token IN ? AND status IN ?", chain, tokens,
+			[]int{mdb.StatusWaitPay, mdb.StatusPartiallyPaid, mdb.StatusConfirming}).
+		Group("token").Scan(&orderRows).Error
+	if err != nil {
+		return nil, err
+	}
+	var authRows []row
+	err = dao.Mdb.Model(&mdb.KtvAuthorize{}).
+		Select("merchant_wallet AS token, COALESCE(SUM(remaining_usdt), 0) AS amount").
+		Where("chain = ? AND merchant_wallet IN ? AND status IN ?", chain, tokens,
+			[]int{mdb.AuthorizeStatusPending, mdb.AuthorizeStatusActive}).
+		Group("merchant_wallet").Scan(&authRows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range append(orderRows, authRows...) {
+		result[r.Token] = result[r.Token].Add(r.Amount)
+	}
+	return result, nil
+}
+
+// UpdateWalletWeight 更新钱包权重，merchantID 大于 0 时仅能修改该商家的钱包
+func UpdateWalletWeight(id uint64, merchantID uint64, weight int) error {
+	query := dao.Mdb.Model(&mdb.WalletAddress{}).Where("id = ?", id)
+	if merchantID > 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	return query.Update("weight", weight).Error
+}
+
+// UpdateMerchantWalletStrategy 更新商家的钱包选择策略，空字符串表示跟随链配置
+func UpdateMerchantWalletStrategy(id uint64, strategy string) error {
+	return dao.Mdb.Model(&mdb.Merchant{}).Where("id = ?", id).Update("wallet_strategy", strategy).Error
+}
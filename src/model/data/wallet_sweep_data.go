@@ -0,0 +1,40 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
+)
+
+// CreateWalletSweep 创建归集记录
+func CreateWalletSweep(sweep *mdb.WalletSweep) error {
+	return dao.Mdb.Create(sweep).Error
+}
+
+// UpdateWalletSweep 更新归集记录
+func UpdateWalletSweep(id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.WalletSweep{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetOpenWalletSweeps 查询进行中的归集记录
+func GetOpenWalletSweeps() ([]mdb.WalletSweep, error) {
+	var list []mdb.WalletSweep
+	err := dao.Mdb.Model(&mdb.WalletSweep{}).
+		Where("status IN ?", []int{mdb.SweepStatusGasPending, mdb.SweepStatusSubmitted}).
+		Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+var walletSweepListSpec = &listSpec{
+	status:  "status",
+	chain:   "chain",
+	amount:  "amount",
+	wallet:  anyColumn("from_address", "to_address"),
+	keyword: anyColumn("sweep_no", "tx_hash", "gas_tx_hash"),
+	sorts:   []string{"created_at", "amount"},
+}
+
+// ListWalletSweeps 管理后台归集记录列表
+func ListWalletSweeps(filter *ListFilter, q *page.Query) (*ListResult[mdb.WalletSweep], error) {
+	return listPage[mdb.WalletSweep](walletSweepListSpec, filter, q)
+}
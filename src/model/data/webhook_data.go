@@ -0,0 +1,85 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// CreateWebhookEndpoint 创建事件接收地址
+func CreateWebhookEndpoint(endpoint *mdb.WebhookEndpoint) error {
+	return dao.Mdb.Create(endpoint).Error
+}
+
+// GetWebhookEndpointById 通过ID查询事件接收地址
+func GetWebhookEndpointById(id uint64) (*mdb.WebhookEndpoint, error) {
+	endpoint := new(mdb.WebhookEndpoint)
+	err := dao.Mdb.Model(endpoint).Limit(1).Find(endpoint, id).Error
+	return endpoint, err
+}
+
+// GetMerchantWebhookEndpoint 查询商家的事件接收地址
+func GetMerchantWebhookEndpoint(merchantID, id uint64) (*mdb.WebhookEndpoint, error) {
+	endpoint := new(mdb.WebhookEndpoint)
+	err := dao.Mdb.Model(endpoint).Limit(1).Find(endpoint, "id = ? AND merchant_id = ?", id, merchantID).Error
+	return endpoint, err
+}
+
+// GetMerchantWebhookEndpoints 查询商家的全部事件接收地址
+func GetMerchantWebhookEndpoints(merchantID uint64) ([]mdb.WebhookEndpoint, error) {
+	var list []mdb.WebhookEndpoint
+	err := dao.Mdb.Model(&mdb.WebhookEndpoint{}).Where("merchant_id = ?", merchantID).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// GetEnabledWebhookEndpoints 查询商家启用中的事件接收地址
+func GetEnabledWebhookEndpoints(merchantID uint64) ([]mdb.WebhookEndpoint, error) {
+	var list []mdb.WebhookEndpoint
+	err := dao.Mdb.Model(&mdb.WebhookEndpoint{}).
+		Where("merchant_id = ? AND status = ?", merchantID, mdb.WebhookEndpointStatusEnable).
+		Find(&list).Error
+	return list, err
+}
+
+// UpdateMerchantWebhookEndpoint 更新商家的事件接收地址
+func UpdateMerchantWebhookEndpoint(merchantID, id uint64, updates map[string]interface{}) error {
+	return dao.Mdb.Model(&mdb.WebhookEndpoint{}).Where("id = ? AND merchant_id = ?", id, merchantID).Updates(updates).Error
+}
+
+// DeleteMerchantWebhookEndpoint 删除商家的事件接收地址
+func DeleteMerchantWebhookEndpoint(merchantID, id uint64) error {
+	return dao.Mdb.Where("id = ? AND merchant_id = ?", id, merchantID).Delete(&mdb.WebhookEndpoint{}).Error
+}
+
+// CreateWebhookDelivery 记录一次投递尝试
+func CreateWebhookDelivery(delivery *mdb.WebhookDelivery) error {
+	return dao.Mdb.Create(delivery).Error
+}
+
+// GetMerchantWebhookDeliveries 分页查询商家的投递记录
+func GetMerchantWebhookDeliveries(merchantID uint64, page, pageSize int, endpointID uint64, eventType, eventId string) ([]mdb.WebhookDelivery, int64, error) {
+	var list []mdb.WebhookDelivery
+	var total int64
+
+	query := dao.Mdb.Model(&mdb.WebhookDelivery{}).Where("merchant_id = ?", merchantID)
+	if endpointID > 0 {
+		query = query.Where("endpoint_id = ?", endpointID)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if eventId != "" {
+		query = query.Where("event_id = ?", eventId)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetMerchantWebhookDelivery 查询商家的投递记录
+func GetMerchantWebhookDelivery(merchantID, id uint64) (*mdb.WebhookDelivery, error) {
+	delivery := new(mdb.WebhookDelivery)
+	err := dao.Mdb.Model(delivery).Limit(1).Find(delivery, "id = ? AND merchant_id = ?", id, merchantID).Error
+	return delivery, err
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/page"
 	"gorm.io/gorm"
 )
 
@@ -31,17 +32,19 @@ func GetWithdrawalsByMerchantID(merchantID uint64, page, pageSize int) ([]mdb.Me
 	return list, total, err
 }
 
-// GetAllWithdrawals 获取所有提现记录（管理员）
-func GetAllWithdrawals(page, pageSize int) ([]mdb.MerchantWithdrawal, int64, error) {
-	var list []mdb.MerchantWithdrawal
-	var total int64
-
-	query := dao.Mdb.Model(&mdb.MerchantWithdrawal{})
-	query.Count(&total)
+var withdrawalListSpec = &listSpec{
+	status:   "status",
+	chain:    "chain",
+	amount:   "amount",
+	wallet:   anyColumn("to_wallet"),
+	merchant: anyColumn("merchant_id"),
+	keyword:  anyColumn("withdraw_no", "tx_hash"),
+	sorts:    []string{"created_at", "amount", "reviewed_at"},
+}
 
-	offset := (page - 1) * pageSize
-	err := query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&list).Error
-	return list, total, err
+// ListWithdrawals 获取提现记录（管理员）
+func ListWithdrawals(filter *ListFilter, q *page.Query) (*ListResult[mdb.MerchantWithdrawal], error) {
+	return listPage[mdb.MerchantWithdrawal](withdrawalListSpec, filter, q)
 }
 
 // UpdateWithdrawalStatus 更新提现状态
@@ -49,23 +52,28 @@ func UpdateWithdrawalStatus(tx *gorm.DB, withdrawNo string, updates map[string]i
 	return tx.Model(&mdb.MerchantWithdrawal{}).Where("withdraw_no = ?", withdrawNo).Updates(updates).Error
 }
 
-// AddMerchantBalance 增加商家余额
-func AddMerchantBalance(tx *gorm.DB, merchantID uint64, amount float64) error {
-	return tx.Model(&mdb.Merchant{}).Where("id = ?", merchantID).
-		Update("balance", gorm.Expr("balance + ?", amount)).Error
+// TransitWithdrawalStatus 按状态机流转提现状态，仅当当前状态为 from 时更新，返回是否由本次调用完成流转
+func TransitWithdrawalStatus(tx *gorm.DB, withdrawNo string, from int, updates map[string]interface{}) (bool, error) {
+	result := tx.Model(&mdb.MerchantWithdrawal{}).
+		Where("withdraw_no = ? AND status = ?", withdrawNo, from).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
 }
 
-// SubMerchantBalance 扣减商家余额
-func SubMerchantBalance(tx *gorm.DB, merchantID uint64, amount float64) error {
-	return tx.Model(&mdb.Merchant{}).Where("id = ? AND balance >= ?", merchantID, amount).
-		Update("balance", gorm.Expr("balance - ?", amount)).Error
+// UpdateWithdrawalLedgerPending 标记或清除提现的待补记状态
+func UpdateWithdrawalLedgerPending(id uint64, pending bool) error {
+	value := 0
+	if pending {
+		value = 1
+	}
+	return dao.Mdb.Model(&mdb.MerchantWithdrawal{}).Where("id = ?", id).Update("ledger_pending", value).Error
 }
 
-// GetMerchantBalance 获取商家余额
-func GetMerchantBalance(merchantID uint64) (float64, error) {
-	var balance float64
-	err := dao.Mdb.Model(&mdb.Merchant{}).Where("id = ?", merchantID).Pluck("balance", &balance).Error
-	return balance, err
+// GetLedgerPendingWithdrawals 记账失败等待补记的提现
+func GetLedgerPendingWithdrawals() ([]mdb.MerchantWithdrawal, error) {
+	var list []mdb.MerchantWithdrawal
+	err := dao.Mdb.Model(&mdb.MerchantWithdrawal{}).Where("ledger_pending = ?", 1).Order("id ASC").Find(&list).Error
+	return list, err
 }
 
 // GetMerchantIDByWallet 通过钱包地址反查商家ID
@@ -2,12 +2,23 @@ package mdb
 
 import (
 	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+func init() {
+	// 金额字段以 JSON 数字输出，与改用 decimal 之前的接口格式保持一致
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
 type BaseModel struct {
 	ID        uint64         `gorm:"column:id;primary_key;autoIncrement" json:"id"`
 	CreatedAt carbon.Time    `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt carbon.Time    `gorm:"column:updated_at" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
+
+// GetID 主键ID
+func (m BaseModel) GetID() uint64 {
+	return m.ID
+}
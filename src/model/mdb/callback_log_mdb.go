@@ -1,15 +1,30 @@
 package mdb
 
+// 回调类型
+const (
+	CallbackTypeOrder        = "order"        // 订单支付回调
+	CallbackTypeRefund       = "refund"       // 退款回调
+	CallbackTypeSubscription = "subscription" // 订阅事件通知
+)
+
 // CallbackLog 回调日志
 type CallbackLog struct {
-	TradeId       string `gorm:"column:trade_id;index" json:"trade_id"`
-	OrderId       string `gorm:"column:order_id;index" json:"order_id"`
-	NotifyUrl     string `gorm:"column:notify_url;type:varchar(255)" json:"notify_url"`
-	RequestBody   string `gorm:"column:request_body;type:text" json:"request_body"`
-	ResponseBody  string `gorm:"column:response_body;type:text" json:"response_body"`
-	StatusCode    int    `gorm:"column:status_code" json:"status_code"`
-	Success       int    `gorm:"column:success;default:0" json:"success"` // 1成功 0失败
-	ErrorMessage  string `gorm:"column:error_message;type:varchar(255)" json:"error_message"`
+	TradeId      string `gorm:"column:trade_id;index" json:"trade_id"`
+	OrderId      string `gorm:"column:order_id;index" json:"order_id"`
+	CallbackType string `gorm:"column:callback_type;type:varchar(20);index" json:"callback_type"` // 回调类型
+	BizNo        string `gorm:"column:biz_no;type:varchar(64);index" json:"biz_no"`               // 业务单号：订单号/退款单号/订阅编号
+	Event        string `gorm:"column:event;type:varchar(64)" json:"event"`                       // 订阅事件，仅订阅通知
+	MerchantID   uint64 `gorm:"column:merchant_id;index" json:"merchant_id"`                      // 所属商家，0 表示未归属
+	NotifyUrl    string `gorm:"column:notify_url;type:varchar(255)" json:"notify_url"`
+	RequestBody  string `gorm:"column:request_body;type:text" json:"request_body"`
+	ResponseBody string `gorm:"column:response_body;type:text" json:"response_body"`
+	StatusCode   int    `gorm:"column:status_code" json:"status_code"`
+	Success      int    `gorm:"column:success;default:0" json:"success"` // 1成功 0失败
+	ErrorMessage string `gorm:"column:error_message;type:varchar(255)" json:"error_message"`
+	AckRule      string `gorm:"column:ack_rule;type:varchar(255)" json:"ack_rule"` // 判定商户确认所用的规则
+	Attempt      int    `gorm:"column:attempt;default:1" json:"attempt"`           // 本轮投递第几次尝试
+	ParentId     uint64 `gorm:"column:parent_id;index;default:0" json:"parent_id"` // 手动重发时指向最初的回调日志
+	DeadLetter   int    `gorm:"column:dead_letter;default:0" json:"dead_letter"`   // 1:重试耗尽仍失败，进入死信
 	BaseModel
 }
 
@@ -0,0 +1,58 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+const (
+	FeeScheduleStatusEnable  = 1
+	FeeScheduleStatusDisable = 2
+)
+
+// 收费业务类型
+const (
+	FeeBizOrder      = "order"      // 订单收款
+	FeeBizDeduction  = "deduction"  // 授权扣款
+	FeeBizWithdrawal = "withdrawal" // 商家提现
+)
+
+// FeeBizTypes 全部收费业务类型
+var FeeBizTypes = []string{FeeBizOrder, FeeBizDeduction, FeeBizWithdrawal}
+
+// FeeSchedule 手续费方案，merchant_id 为 0 时为全局方案，商家方案优先
+type FeeSchedule struct {
+	MerchantID  uint64          `gorm:"column:merchant_id;uniqueIndex:idx_fee_schedule_biz" json:"merchant_id"`            // 商家ID，0 表示全局
+	BizType     string          `gorm:"column:biz_type;type:varchar(20);uniqueIndex:idx_fee_schedule_biz" json:"biz_type"` // order/deduction/withdrawal
+	Percent     decimal.Decimal `gorm:"column:percent;type:decimal(10,4);default:0" json:"percent"`                        // 按比例收取，单位 %
+	FixedAmount decimal.Decimal `gorm:"column:fixed_amount;type:decimal(19,6);default:0" json:"fixed_amount"`              // 每笔固定收取(USDT)
+	Status      int             `gorm:"column:status;default:1" json:"status"`                                             // 1:启用 2:停用
+	Remark      string          `gorm:"column:remark;type:varchar(255)" json:"remark"`                                     // 备注
+	BaseModel
+}
+
+func (f *FeeSchedule) TableName() string {
+	return "fee_schedules"
+}
+
+// Calculate 按方案计算手续费，保留6位小数，不超过交易金额
+func (f *FeeSchedule) Calculate(amount decimal.Decimal) decimal.Decimal {
+	if f == nil || f.ID == 0 || f.Status != FeeScheduleStatusEnable || !amount.IsPositive() {
+		return decimal.Zero
+	}
+	fee := amount.Mul(f.Percent).Div(decimal.NewFromInt(100)).Add(f.FixedAmount).Round(6)
+	if fee.IsNegative() {
+		return decimal.Zero
+	}
+	if fee.GreaterThan(amount) {
+		return amount
+	}
+	return fee
+}
+
+// IsFeeBizType 是否支持的收费业务类型
+func IsFeeBizType(bizType string) bool {
+	for _, t := range FeeBizTypes {
+		if t == bizType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package mdb
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFeeScheduleCalculate 测试按比例、固定金额、封顶及停用方案的手续费计算
+func TestFeeScheduleCalculate(t *testing.T) {
+	d := decimal.RequireFromString
+	schedule := func(percent, fixed string, status int) *FeeSchedule {
+		f := &FeeSchedule{Percent: d(percent), FixedAmount: d(fixed), Status: status}
+		f.ID = 1
+		return f
+	}
+
+	testCases := []struct {
+		name     string
+		schedule *FeeSchedule
+		amount   string
+		expected string
+	}{
+		{"按比例", schedule("1.5", "0", FeeScheduleStatusEnable), "100", "1.5"},
+		{"按比例保留6位小数", schedule("0.3333", "0", FeeScheduleStatusEnable), "0.01", "0.000033"},
+		{"固定金额", schedule("0", "0.5", FeeScheduleStatusEnable), "100", "0.5"},
+		{"比例加固定金额", schedule("1", "0.2", FeeScheduleStatusEnable), "50", "0.7"},
+		{"不超过交易金额", schedule("0", "5", FeeScheduleStatusEnable), "3", "3"},
+		{"负数按0计", schedule("0", "-1", FeeScheduleStatusEnable), "10", "0"},
+		{"停用方案", schedule("1.5", "0.5", FeeScheduleStatusDisable), "100", "0"},
+		{"未保存的方案", &FeeSchedule{Percent: d("1.5"), Status: FeeScheduleStatusEnable}, "100", "0"},
+		{"无方案", nil, "100", "0"},
+		{"金额为0", schedule("1.5", "0.5", FeeScheduleStatusEnable), "0", "0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fee := tc.schedule.Calculate(d(tc.amount))
+			assert.True(t, fee.Equal(d(tc.expected)), "fee=%s expected=%s", fee, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package mdb
+
+// HdDepositAddress HD钱包派生收款地址表（每笔订单独占一个地址）
+type HdDepositAddress struct {
+	Network         string `gorm:"column:network;type:varchar(20);uniqueIndex:idx_hd_network_index" json:"network"`  // 派生网络 EVM/TRON
+	DerivationIndex uint32 `gorm:"column:derivation_index;uniqueIndex:idx_hd_network_index" json:"derivation_index"` // 派生索引
+	DerivationPath  string `gorm:"column:derivation_path;type:varchar(64)" json:"derivation_path"`                   // 完整派生路径
+	Address         string `gorm:"column:address;type:varchar(128);index" json:"address"`                            // 派生地址
+	Chain           string `gorm:"column:chain;type:varchar(20)" json:"chain"`                                       // 使用该地址的链
+	TradeId         string `gorm:"column:trade_id;type:varchar(64);index" json:"trade_id"`                           // epusdt订单号
+	BaseModel
+}
+
+func (h *HdDepositAddress) TableName() string {
+	return "hd_deposit_addresses"
+}
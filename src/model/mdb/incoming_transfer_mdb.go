@@ -0,0 +1,31 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+const (
+	IncomingTransferUnmatched = 1 // 未匹配订单
+	IncomingTransferMatched   = 2 // 已自动匹配订单
+	IncomingTransferAttached  = 3 // 已由管理员手动关联订单
+	IncomingTransferReorged   = 4 // 区块重组后交易已不在主链
+)
+
+// IncomingTransfer 托管钱包收到的每一笔转账，用于对账
+type IncomingTransfer struct {
+	Chain          string          `gorm:"column:chain;type:varchar(20);index" json:"chain"`                                            // 链
+	TokenSymbol    string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"`                       // 代币符号
+	TxHash         string          `gorm:"column:tx_hash;type:varchar(128);uniqueIndex:idx_incoming_transfer_log" json:"tx_hash"`       // 交易哈希
+	ToAddress      string          `gorm:"column:to_address;type:varchar(128);uniqueIndex:idx_incoming_transfer_log" json:"to_address"` // 收款地址
+	LogIndex       uint            `gorm:"column:log_index;default:0;uniqueIndex:idx_incoming_transfer_log" json:"log_index"`           // 同一交易内转入该地址的第几笔，区分批量转账中的多笔
+	FromAddress    string          `gorm:"column:from_address;type:varchar(128)" json:"from_address"`                                   // 付款地址
+	Amount         decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                                              // 转账金额
+	BlockTimestamp int64           `gorm:"column:block_timestamp" json:"block_timestamp"`                                               // 区块时间(毫秒)
+	BlockNumber    uint64          `gorm:"column:block_number" json:"block_number"`                                                     // 区块高度
+	MatchStatus    int             `gorm:"column:match_status;default:1;index" json:"match_status"`                                     // 1：未匹配，2：已匹配，3：手动关联，4：已回滚
+	TradeId        string          `gorm:"column:trade_id;type:varchar(64);index" json:"trade_id"`                                      // 关联的epusdt订单号
+	Operator       string          `gorm:"column:operator;type:varchar(64)" json:"operator"`                                            // 手动关联的操作人
+	BaseModel
+}
+
+func (t *IncomingTransfer) TableName() string {
+	return "incoming_transfers"
+}
@@ -0,0 +1,108 @@
+package mdb
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// 科目类型
+const (
+	LedgerAccountAsset     = "asset"     // 资产，借方增加
+	LedgerAccountLiability = "liability" // 负债，贷方增加
+	LedgerAccountRevenue   = "revenue"   // 收入，贷方增加
+	LedgerAccountEquity    = "equity"    // 权益，贷方增加
+)
+
+// 分录方向
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
+
+// 商家科目
+const (
+	LedgerMerchantAvailable = "available" // 可用余额
+	LedgerMerchantPending   = "pending"   // 提现在途
+	LedgerMerchantRefunding = "refunding" // 退款在途
+)
+
+// 平台科目编码
+const (
+	LedgerCodeCompanyWallet = "company:wallet"      // 公司钱包
+	LedgerCodePlatformFees  = "platform:fees"       // 平台手续费收入
+	LedgerCodeAdjustment    = "platform:adjustment" // 期初及人工调账
+)
+
+// 记账业务类型
+const (
+	LedgerBizOpening           = "opening"            // 期初余额
+	LedgerBizDeduction         = "deduction"          // 授权扣款入账
+	LedgerBizOrderPayment      = "order_payment"      // 订单支付入账
+	LedgerBizWithdrawalHold    = "withdrawal_hold"    // 提现审批通过，转入在途
+	LedgerBizWithdrawalPaid    = "withdrawal_paid"    // 提现转账完成
+	LedgerBizWithdrawalRelease = "withdrawal_release" // 提现转账失败，退回可用余额
+	LedgerBizRefundHold        = "refund_hold"        // 发起退款，可用余额转入退款在途
+	LedgerBizRefundRelease     = "refund_release"     // 退款转账失败，退回可用余额
+	LedgerBizRefund            = "refund"             // 订单退款
+	LedgerBizAdjustment        = "adjustment"         // 人工调账
+	LedgerBizFee               = "fee"                // 平台手续费
+)
+
+// LedgerAccount 账本科目
+type LedgerAccount struct {
+	Code       string `gorm:"column:code;type:varchar(64);uniqueIndex" json:"code"` // 科目编码
+	Name       string `gorm:"column:name;type:varchar(64)" json:"name"`             // 科目名称
+	Type       string `gorm:"column:type;type:varchar(20)" json:"type"`             // 科目类型
+	MerchantID uint64 `gorm:"column:merchant_id;index" json:"merchant_id"`          // 商家ID，平台科目为 0
+	BaseModel
+}
+
+func (a *LedgerAccount) TableName() string {
+	return "ledger_accounts"
+}
+
+// DebitNormal 是否借方余额科目
+func (a *LedgerAccount) DebitNormal() bool {
+	return a.Type == LedgerAccountAsset
+}
+
+// MerchantLedgerCode 商家科目编码
+func MerchantLedgerCode(merchantID uint64, kind string) string {
+	return fmt.Sprintf("merchant:%d:%s", merchantID, kind)
+}
+
+// LedgerJournal 记账凭证，只增不改，同一业务单号只记一次
+type LedgerJournal struct {
+	JournalNo  string          `gorm:"column:journal_no;type:varchar(64);uniqueIndex" json:"journal_no"`                    // 凭证号
+	BizType    string          `gorm:"column:biz_type;type:varchar(32);uniqueIndex:idx_ledger_journal_biz" json:"biz_type"` // 业务类型
+	BizNo      string          `gorm:"column:biz_no;type:varchar(64);uniqueIndex:idx_ledger_journal_biz" json:"biz_no"`     // 业务单号
+	MerchantID uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                                         // 商家ID
+	Amount     decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                                      // 凭证金额（借方合计）
+	Memo       string          `gorm:"column:memo;type:varchar(255)" json:"memo"`                                           // 摘要
+	Operator   string          `gorm:"column:operator;type:varchar(64)" json:"operator"`                                    // 操作人
+	BaseModel
+}
+
+func (j *LedgerJournal) TableName() string {
+	return "ledger_journals"
+}
+
+// LedgerEntry 凭证分录
+type LedgerEntry struct {
+	JournalID    uint64          `gorm:"column:journal_id;index" json:"journal_id"`                    // 凭证ID
+	AccountID    uint64          `gorm:"column:account_id;index" json:"account_id"`                    // 科目ID
+	AccountCode  string          `gorm:"column:account_code;type:varchar(64)" json:"account_code"`     // 科目编码
+	MerchantID   uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                  // 科目所属商家ID
+	Direction    string          `gorm:"column:direction;type:varchar(10)" json:"direction"`           // debit/credit
+	Amount       decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`               // 金额
+	BalanceAfter decimal.Decimal `gorm:"column:balance_after;type:decimal(19,6)" json:"balance_after"` // 记账后科目余额
+	BizType      string          `gorm:"column:biz_type;type:varchar(32);index" json:"biz_type"`       // 业务类型
+	BizNo        string          `gorm:"column:biz_no;type:varchar(64);index" json:"biz_no"`           // 业务单号
+	Memo         string          `gorm:"column:memo;type:varchar(255)" json:"memo"`                    // 摘要
+	BaseModel
+}
+
+func (e *LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
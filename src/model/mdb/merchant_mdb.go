@@ -1,17 +1,33 @@
 package mdb
 
+import "github.com/shopspring/decimal"
+
 // Merchant 商家表
 type Merchant struct {
-	Username     string  `gorm:"column:username;type:varchar(64);uniqueIndex" json:"username"`                    // 商家用户名
-	PasswordHash string  `gorm:"column:password_hash;type:varchar(128)" json:"-"`                                 // 密码哈希
-	Email        string  `gorm:"column:email;type:varchar(128)" json:"email"`                                     // 邮箱
-	MerchantName string  `gorm:"column:merchant_name;type:varchar(128)" json:"merchant_name"`                     // 商家名称
-	WalletToken  string  `gorm:"column:wallet_token;type:varchar(100)" json:"wallet_token"`                       // 关联钱包地址
-	Status       int     `gorm:"column:status;default:1" json:"status"`                                           // 1:启用 2:禁用
-	ApiToken     string  `gorm:"column:api_token;type:varchar(128);uniqueIndex" json:"api_token"`                 // API令牌
-	UsdtRate     float64 `gorm:"column:usdt_rate;type:decimal(10,4);default:6.5" json:"usdt_rate"`                // USDT汇率（默认6.5）
-	Balance      float64 `gorm:"column:balance;type:decimal(19,6);default:0" json:"balance"`                      // 商家余额（USDT）
-	LastLoginAt  int64   `gorm:"column:last_login_at" json:"last_login_at"`                                       // 最后登录时间
+	Username     string          `gorm:"column:username;type:varchar(64);uniqueIndex" json:"username"`     // 商家用户名
+	PasswordHash string          `gorm:"column:password_hash;type:varchar(128)" json:"-"`                  // 密码哈希
+	Email        string          `gorm:"column:email;type:varchar(128)" json:"email"`                      // 邮箱
+	MerchantName string          `gorm:"column:merchant_name;type:varchar(128)" json:"merchant_name"`      // 商家名称
+	WalletToken  string          `gorm:"column:wallet_token;type:varchar(100)" json:"wallet_token"`        // 关联钱包地址
+	Status       int             `gorm:"column:status;default:1" json:"status"`                            // 1:启用 2:禁用
+	ApiToken     string          `gorm:"column:api_token;type:varchar(128);uniqueIndex" json:"api_token"`  // API令牌
+	UsdtRate     float64         `gorm:"column:usdt_rate;type:decimal(10,4);default:6.5" json:"usdt_rate"` // USDT汇率（默认6.5）
+	Balance      decimal.Decimal `gorm:"column:balance;type:decimal(19,6);default:0" json:"balance"`       // 商家余额（USDT）
+	LastLoginAt  int64           `gorm:"column:last_login_at" json:"last_login_at"`                        // 最后登录时间
+
+	// 收款钱包选择策略，为空时使用链配置
+	WalletStrategy string `gorm:"column:wallet_strategy;type:varchar(20)" json:"wallet_strategy"`
+
+	// 收银台品牌，为空时使用全局配置
+	CheckoutBrandName  string `gorm:"column:checkout_brand_name;type:varchar(64)" json:"checkout_brand_name"`   // 品牌名称
+	CheckoutLogoUrl    string `gorm:"column:checkout_logo_url;type:varchar(255)" json:"checkout_logo_url"`      // Logo 地址
+	CheckoutThemeColor string `gorm:"column:checkout_theme_color;type:varchar(20)" json:"checkout_theme_color"` // 主题色
+
+	// 回调确认规则，未配置时要求响应体为 ok
+	CallbackAckMode      string `gorm:"column:callback_ack_mode;type:varchar(10)" json:"callback_ack_mode"`              // body/2xx/json
+	CallbackAckBody      string `gorm:"column:callback_ack_body;type:varchar(255)" json:"callback_ack_body"`             // body 方式期望的响应体
+	CallbackAckJsonPath  string `gorm:"column:callback_ack_json_path;type:varchar(128)" json:"callback_ack_json_path"`   // json 方式的取值路径
+	CallbackAckJsonValue string `gorm:"column:callback_ack_json_value;type:varchar(255)" json:"callback_ack_json_value"` // json 方式的期望值
 	BaseModel
 }
 
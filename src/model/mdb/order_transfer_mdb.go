@@ -0,0 +1,32 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+const (
+	TransferStatusConfirming = 1 // 等待区块确认
+	TransferStatusConfirmed  = 2 // 已确认入账
+	TransferStatusReorged    = 3 // 区块重组后交易已不在主链
+)
+
+// OrderTransfer 订单入账转账记录（一笔订单可由多笔转账累计支付）
+type OrderTransfer struct {
+	TradeId        string          `gorm:"column:trade_id;type:varchar(64);index" json:"trade_id"`                                   // epusdt订单号
+	Chain          string          `gorm:"column:chain;type:varchar(20)" json:"chain"`                                               // 链
+	TokenSymbol    string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"`                    // 代币符号
+	TxHash         string          `gorm:"column:tx_hash;type:varchar(128);uniqueIndex:idx_order_transfer_log" json:"tx_hash"`       // 交易哈希
+	ToAddress      string          `gorm:"column:to_address;type:varchar(128);uniqueIndex:idx_order_transfer_log" json:"to_address"` // 收款地址
+	LogIndex       uint            `gorm:"column:log_index;default:0;uniqueIndex:idx_order_transfer_log" json:"log_index"`           // 同一交易内转入该地址的第几笔，区分批量转账中的多笔
+	FromAddress    string          `gorm:"column:from_address;type:varchar(128)" json:"from_address"`                                // 付款地址
+	Amount         decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                                           // 转账金额
+	BlockTimestamp int64           `gorm:"column:block_timestamp" json:"block_timestamp"`                                            // 区块时间(毫秒)
+	BlockNumber    uint64          `gorm:"column:block_number" json:"block_number"`                                                  // 区块高度
+	BlockHash      string          `gorm:"column:block_hash;type:varchar(128)" json:"block_hash"`                                    // 区块哈希
+	Status         int             `gorm:"column:status;default:2;index" json:"status"`                                              // 1：确认中，2：已确认，3：已回滚
+	MissingCount   int             `gorm:"column:missing_count;default:0" json:"missing_count"`                                      // 确认中复核时连续查不到交易的次数
+	MissingSince   uint64          `gorm:"column:missing_since;default:0" json:"missing_since"`                                      // 首次查不到交易时的最新区块高度
+	BaseModel
+}
+
+func (o *OrderTransfer) TableName() string {
+	return "order_transfers"
+}
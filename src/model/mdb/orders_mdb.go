@@ -1,26 +1,43 @@
 package mdb
 
+import "github.com/shopspring/decimal"
+
 const (
-	StatusWaitPay     = 1
-	StatusPaySuccess  = 2
-	StatusExpired     = 3
-	CallBackConfirmOk = 1
-	CallBackConfirmNo = 2
+	StatusWaitPay         = 1
+	StatusPaySuccess      = 2
+	StatusExpired         = 3
+	StatusRefunded        = 4 // 已退款
+	StatusPartialRefunded = 5 // 部分退款
+	StatusPartiallyPaid   = 6 // 部分支付
+	StatusOverpaid        = 7 // 超额支付
+	StatusConfirming      = 8 // 链上确认中
+	CallBackConfirmOk     = 1
+	CallBackConfirmNo     = 2
 )
 
 type Orders struct {
-	TradeId            string  `gorm:"column:trade_id" json:"trade_id"`                                                                 //  epusdt订单号
-	OrderId            string  `gorm:"column:order_id" json:"order_id"`                                                                 //  客户交易id
-	BlockTransactionId string  `gorm:"index:orders_block_transaction_id_index;column:block_transaction_id" json:"block_transaction_id"` // 区块id
-	Amount             float64 `gorm:"column:amount" json:"amount"`                                                                     //  订单金额，保留4位小数
-	ActualAmount       float64 `gorm:"column:actual_amount" json:"actual_amount"`                                                       //  订单实际需要支付的金额，保留4位小数
-	Token              string  `gorm:"column:token" json:"token"`                                                                       //  所属钱包地址
-	Chain              string  `gorm:"column:chain;type:varchar(20);default:TRON" json:"chain"`                                         // 链
-	Status             int     `gorm:"column:status;default:1" json:"status"`                                                           //  1：等待支付，2：支付成功，3：已过期
-	NotifyUrl          string  `gorm:"column:notify_url" json:"notify_url"`                                                             //  异步回调地址
-	RedirectUrl        string  `gorm:"column:redirect_url" json:"redirect_url"`                                                         //  同步回调地址
-	CallbackNum        int     `gorm:"column:callback_num;default:0" json:"callback_num"`                                               // 回调次数
-	CallBackConfirm    int     `gorm:"column:callback_confirm;default:2" json:"callback_confirm"`                                       // 回调是否已确认 1是 2否
+	TradeId            string          `gorm:"column:trade_id" json:"trade_id"`                                                                 //  epusdt订单号
+	OrderId            string          `gorm:"column:order_id" json:"order_id"`                                                                 //  客户交易id
+	BlockTransactionId string          `gorm:"index:orders_block_transaction_id_index;column:block_transaction_id" json:"block_transaction_id"` // 区块id
+	Amount             decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                                                  //  订单法币金额，最多2位小数
+	ActualAmount       decimal.Decimal `gorm:"column:actual_amount;type:decimal(19,6)" json:"actual_amount"`                                    //  订单实际需要支付的代币金额，保留2位小数
+	Currency           string          `gorm:"column:currency;type:varchar(10);default:CNY" json:"currency"`                                    // 订单金额法币币种
+	UsdtRate           decimal.Decimal `gorm:"column:usdt_rate;type:decimal(19,6);default:0" json:"usdt_rate"`                                  // 下单时使用的法币兑USDT汇率
+	Token              string          `gorm:"column:token" json:"token"`                                                                       //  所属钱包地址
+	Chain              string          `gorm:"column:chain;type:varchar(20);default:TRON" json:"chain"`                                         // 链
+	TokenSymbol        string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"`                           // 代币符号
+	Status             int             `gorm:"column:status;default:1" json:"status"`                                                           //  1：等待支付，2：支付成功，3：已过期，4：已退款，5：部分退款，6：部分支付，7：超额支付，8：确认中
+	FromAddress        string          `gorm:"column:from_address;type:varchar(128)" json:"from_address"`                                       // 付款钱包地址
+	DerivationPath     string          `gorm:"column:derivation_path;type:varchar(64)" json:"derivation_path"`                                  // HD派生路径，为空表示使用固定钱包
+	ReceivedAmount     decimal.Decimal `gorm:"column:received_amount;type:decimal(19,6);default:0" json:"received_amount"`                      // 已收到金额(USDT)
+	RefundedAmount     decimal.Decimal `gorm:"column:refunded_amount;type:decimal(19,6);default:0" json:"refunded_amount"`                      // 已退款金额(USDT)
+	FeeAmount          decimal.Decimal `gorm:"column:fee_amount;type:decimal(19,6);default:0" json:"fee_amount"`                                // 平台手续费(USDT)，下单时按方案计算
+	PaymentLinkId      uint64          `gorm:"column:payment_link_id;index;default:0" json:"payment_link_id"`                                   // 来源收款链接，0 表示接口下单
+	MerchantID         uint64          `gorm:"column:merchant_id;index;default:0" json:"merchant_id"`                                           // 所属商家，0 表示使用全局密钥下单
+	NotifyUrl          string          `gorm:"column:notify_url" json:"notify_url"`                                                             //  异步回调地址
+	RedirectUrl        string          `gorm:"column:redirect_url" json:"redirect_url"`                                                         //  同步回调地址
+	CallbackNum        int             `gorm:"column:callback_num;default:0" json:"callback_num"`                                               // 回调次数
+	CallBackConfirm    int             `gorm:"column:callback_confirm;default:2" json:"callback_confirm"`                                       // 回调是否已确认 1是 2否
 	BaseModel
 }
 
@@ -28,3 +45,13 @@ type Orders struct {
 func (o *Orders) TableName() string {
 	return "orders"
 }
+
+// IsOrderPaid 订单是否已足额支付
+func IsOrderPaid(status int) bool {
+	return status == StatusPaySuccess || status == StatusOverpaid
+}
+
+// IsOrderPending 订单是否仍在等待入账
+func IsOrderPending(status int) bool {
+	return status == StatusWaitPay || status == StatusPartiallyPaid || status == StatusConfirming
+}
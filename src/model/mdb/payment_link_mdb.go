@@ -0,0 +1,33 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+const (
+	PaymentLinkStatusEnable  = 1
+	PaymentLinkStatusDisable = 2
+)
+
+// PaymentLink 可重复使用的收款链接，每位访问者打开时生成一笔新订单
+type PaymentLink struct {
+	MerchantID  uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                           // 所属商家
+	Slug        string          `gorm:"column:slug;type:varchar(64);uniqueIndex" json:"slug"`                  // 链接标识 /pay/link/{slug}
+	Title       string          `gorm:"column:title;type:varchar(128)" json:"title"`                           // 标题
+	Description string          `gorm:"column:description;type:varchar(512)" json:"description"`               // 描述
+	Amount      decimal.Decimal `gorm:"column:amount;type:decimal(19,2);default:0" json:"amount"`              // 固定金额，0 表示由付款人填写
+	MinAmount   decimal.Decimal `gorm:"column:min_amount;type:decimal(19,2);default:0" json:"min_amount"`      // 付款人填写金额下限，0 表示不限
+	MaxAmount   decimal.Decimal `gorm:"column:max_amount;type:decimal(19,2);default:0" json:"max_amount"`      // 付款人填写金额上限，0 表示使用系统默认上限
+	Currency    string          `gorm:"column:currency;type:varchar(10);default:CNY" json:"currency"`          // 金额法币币种
+	Chain       string          `gorm:"column:chain;type:varchar(20);default:ANY" json:"chain"`                // 链，ANY 由付款人选择
+	TokenSymbol string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"` // 代币符号
+	NotifyUrl   string          `gorm:"column:notify_url;type:varchar(255)" json:"notify_url"`                 // 异步回调地址
+	RedirectUrl string          `gorm:"column:redirect_url;type:varchar(255)" json:"redirect_url"`             // 支付完成跳转地址
+	MaxUses     int             `gorm:"column:max_uses;default:0" json:"max_uses"`                             // 最多可生成订单数，0 表示不限
+	UsedCount   int             `gorm:"column:used_count;default:0" json:"used_count"`                         // 已生成订单数
+	ExpiresAt   int64           `gorm:"column:expires_at;default:0" json:"expires_at"`                         // 过期时间(秒)，0 表示永久有效
+	Status      int             `gorm:"column:status;default:1" json:"status"`                                 // 1:启用 2:停用
+	BaseModel
+}
+
+func (p *PaymentLink) TableName() string {
+	return "payment_links"
+}
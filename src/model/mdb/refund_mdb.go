@@ -0,0 +1,46 @@
+package mdb
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RefundStatusPending    = 1 // 待处理
+	RefundStatusProcessing = 2 // 转账中
+	RefundStatusSuccess    = 3 // 退款成功
+	RefundStatusFailed     = 4 // 退款失败
+)
+
+// OrderRefund 订单退款表
+type OrderRefund struct {
+	RefundNo        string          `gorm:"column:refund_no;type:varchar(64);uniqueIndex" json:"refund_no"`        // 退款单号
+	TradeId         string          `gorm:"column:trade_id;type:varchar(64);index" json:"trade_id"`                // epusdt订单号
+	OrderId         string          `gorm:"column:order_id;type:varchar(64)" json:"order_id"`                      // 客户交易id
+	MerchantID      uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                           // 商家ID
+	Chain           string          `gorm:"column:chain;type:varchar(20)" json:"chain"`                            // 链
+	TokenSymbol     string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"` // 代币符号
+	ToWallet        string          `gorm:"column:to_wallet;type:varchar(128)" json:"to_wallet"`                   // 退款目标钱包（原付款地址）
+	Amount          decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                        // 退款金额(USDT)
+	Status          int             `gorm:"column:status;default:1" json:"status"`                                 // 1:待处理 2:转账中 3:退款成功 4:退款失败
+	TxHash          string          `gorm:"column:tx_hash;type:varchar(128)" json:"tx_hash"`                       // 退款交易哈希
+	Reason          string          `gorm:"column:reason;type:varchar(256)" json:"reason"`                         // 退款原因
+	FailReason      string          `gorm:"column:fail_reason;type:varchar(256)" json:"fail_reason"`               // 失败原因
+	Operator        string          `gorm:"column:operator;type:varchar(64)" json:"operator"`                      // 发起人
+	RefundTime      int64           `gorm:"column:refund_time" json:"refund_time"`                                 // 退款完成时间
+	CallbackNum     int             `gorm:"column:callback_num;default:0" json:"callback_num"`                     // 回调次数
+	CallBackConfirm int             `gorm:"column:callback_confirm;default:2" json:"callback_confirm"`             // 回调是否已确认 1是 2否
+	LedgerPending   int             `gorm:"column:ledger_pending;default:0;index" json:"ledger_pending"`           // 1:退款已完成但记账失败，等待补记
+	HoldAttempt     int             `gorm:"column:hold_attempt;default:0" json:"hold_attempt"`                     // 冻结商家余额的次数，每次重试重新冻结；0 表示未冻结（平台订单或早期退款）
+	BaseModel
+}
+
+func (r *OrderRefund) TableName() string {
+	return "order_refunds"
+}
+
+// HoldBizNo 本次冻结商家余额的记账单号，每次重试各记一次冻结与释放
+func (r *OrderRefund) HoldBizNo() string {
+	return fmt.Sprintf("%s-%d", r.RefundNo, r.HoldAttempt)
+}
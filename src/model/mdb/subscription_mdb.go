@@ -0,0 +1,47 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+// 订阅状态
+const (
+	SubscriptionStatusActive   = 1 // 正常
+	SubscriptionStatusPastDue  = 2 // 逾期，扣款失败重试中
+	SubscriptionStatusUnpaid   = 3 // 重试耗尽，停止扣款
+	SubscriptionStatusCanceled = 4 // 已取消
+)
+
+// 计费周期单位
+const (
+	SubscriptionIntervalDay   = "day"
+	SubscriptionIntervalWeek  = "week"
+	SubscriptionIntervalMonth = "month"
+)
+
+// Subscription 基于授权的周期扣款订阅
+type Subscription struct {
+	SubscriptionNo       string          `gorm:"column:subscription_no;type:varchar(50);uniqueIndex" json:"subscription_no"` // 订阅编号
+	MerchantID           uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                                // 商家ID
+	AuthID               uint64          `gorm:"column:auth_id;index" json:"auth_id"`                                        // 授权ID
+	AuthNo               string          `gorm:"column:auth_no;type:varchar(50)" json:"auth_no"`                             // 授权编号
+	PlanName             string          `gorm:"column:plan_name;type:varchar(128)" json:"plan_name"`                        // 套餐名称
+	AmountUsdt           decimal.Decimal `gorm:"column:amount_usdt;type:decimal(19,6)" json:"amount_usdt"`                   // 每期扣款金额(USDT)
+	IntervalUnit         string          `gorm:"column:interval_unit;type:varchar(10)" json:"interval_unit"`                 // 周期单位 day/week/month
+	IntervalCount        int             `gorm:"column:interval_count;default:1" json:"interval_count"`                      // 周期数
+	MaxRetries           int             `gorm:"column:max_retries" json:"max_retries"`                                      // 扣款失败最大重试次数
+	RetryIntervalMinutes int             `gorm:"column:retry_interval_minutes" json:"retry_interval_minutes"`                // 重试间隔(分钟)
+	RetryCount           int             `gorm:"column:retry_count;default:0" json:"retry_count"`                            // 当期已重试次数
+	PeriodEnd            int64           `gorm:"column:period_end" json:"period_end"`                                        // 已付周期结束时间，即本期应扣款时间
+	NextBillingTime      int64           `gorm:"column:next_billing_time;index" json:"next_billing_time"`                    // 下次扣款时间（含重试）
+	Billing              int             `gorm:"column:billing;default:0" json:"billing"`                                    // 1:扣款任务执行中
+	BillingStartedAt     int64           `gorm:"column:billing_started_at;default:0" json:"billing_started_at"`              // 本次扣款任务开始时间
+	LastDeductNo         string          `gorm:"column:last_deduct_no;type:varchar(50)" json:"last_deduct_no"`               // 最近一次扣款单号
+	LastFailReason       string          `gorm:"column:last_fail_reason;type:varchar(255)" json:"last_fail_reason"`          // 最近一次失败原因
+	NotifyUrl            string          `gorm:"column:notify_url;type:varchar(255)" json:"notify_url"`                      // 订阅事件通知地址
+	Status               int             `gorm:"column:status;default:1" json:"status"`                                      // 状态
+	CanceledAt           int64           `gorm:"column:canceled_at" json:"canceled_at"`                                      // 取消时间
+	BaseModel
+}
+
+func (s *Subscription) TableName() string {
+	return "subscriptions"
+}
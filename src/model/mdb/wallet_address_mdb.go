@@ -12,6 +12,7 @@ type WalletAddress struct {
 	ChainID    int64  `gorm:"column:chain_id" json:"chain_id"`                                        // 链ID (56=BSC, 1=ETH, 137=Polygon)
 	MerchantID uint64 `gorm:"column:merchant_id;index" json:"merchant_id"`                            // 所属商家ID
 	Status     int64  `gorm:"column:status;default:1" json:"status"`                                  // 1:启用 2:禁用
+	Weight     int    `gorm:"column:weight;default:1" json:"weight"`                                  // 按权重选择钱包时的权重
 	BaseModel
 }
 
@@ -0,0 +1,28 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+// 余额监控的钱包类型
+const (
+	WalletKindReceive = "receive" // 收款钱包
+	WalletKindCompany = "company" // 公司钱包
+)
+
+// WalletBalance 钱包链上余额快照，每条链每个地址一条，定时刷新
+type WalletBalance struct {
+	Chain         string          `gorm:"column:chain;type:varchar(20);uniqueIndex:idx_wallet_balance_addr" json:"chain"`      // 链
+	Address       string          `gorm:"column:address;type:varchar(128);uniqueIndex:idx_wallet_balance_addr" json:"address"` // 钱包地址
+	Kind          string          `gorm:"column:kind;type:varchar(20)" json:"kind"`                                            // receive:收款钱包 company:公司钱包
+	MerchantID    uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                                         // 所属商家ID，平台钱包为 0
+	UsdtBalance   decimal.Decimal `gorm:"column:usdt_balance;type:decimal(19,6);default:0" json:"usdt_balance"`                // USDT 余额
+	NativeSymbol  string          `gorm:"column:native_symbol;type:varchar(20)" json:"native_symbol"`                          // 原生币符号
+	NativeBalance decimal.Decimal `gorm:"column:native_balance;type:decimal(30,18);default:0" json:"native_balance"`           // 原生币余额
+	GasThreshold  decimal.Decimal `gorm:"column:gas_threshold;type:decimal(30,18);default:0" json:"gas_threshold"`             // 原生币告警阈值
+	LowGas        bool            `gorm:"column:low_gas;default:false" json:"low_gas"`                                         // 原生币余额低于阈值
+	Error         string          `gorm:"column:error;type:varchar(255)" json:"error"`                                         // 最近一次查询失败原因
+	BaseModel
+}
+
+func (w *WalletBalance) TableName() string {
+	return "wallet_balances"
+}
@@ -0,0 +1,35 @@
+package mdb
+
+import "github.com/shopspring/decimal"
+
+const (
+	SweepStatusGasPending = 1 // 已补充 gas，等待到账
+	SweepStatusSubmitted  = 2 // 归集交易已广播
+	SweepStatusSuccess    = 3 // 归集成功
+	SweepStatusFailed     = 4 // 归集失败
+)
+
+// WalletSweep 收款钱包归集记录
+type WalletSweep struct {
+	SweepNo     string          `gorm:"column:sweep_no;type:varchar(64);uniqueIndex" json:"sweep_no"`      // 归集单号
+	Chain       string          `gorm:"column:chain;type:varchar(20);index" json:"chain"`                  // 链
+	TokenSymbol string          `gorm:"column:token_symbol;type:varchar(20)" json:"token_symbol"`          // 代币符号
+	FromAddress string          `gorm:"column:from_address;type:varchar(128);index" json:"from_address"`   // 收款钱包地址
+	ToAddress   string          `gorm:"column:to_address;type:varchar(128)" json:"to_address"`             // 冷钱包地址
+	Amount      decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                    // 归集金额
+	GasAmount   decimal.Decimal `gorm:"column:gas_amount;type:decimal(30,18);default:0" json:"gas_amount"` // 公司钱包补充的原生币数量
+	GasTxHash   string          `gorm:"column:gas_tx_hash;type:varchar(128)" json:"gas_tx_hash"`           // 补充 gas 交易哈希
+	TxHash      string          `gorm:"column:tx_hash;type:varchar(128)" json:"tx_hash"`                   // 归集交易哈希
+	Status      int             `gorm:"column:status;default:1;index" json:"status"`                       // 1:等待gas 2:已广播 3:成功 4:失败
+	FailReason  string          `gorm:"column:fail_reason;type:varchar(255)" json:"fail_reason"`           // 失败原因
+	BaseModel
+}
+
+func (w *WalletSweep) TableName() string {
+	return "wallet_sweeps"
+}
+
+// IsSweepOpen 归集是否仍在进行中
+func IsSweepOpen(status int) bool {
+	return status == SweepStatusGasPending || status == SweepStatusSubmitted
+}
@@ -0,0 +1,102 @@
+package mdb
+
+import "strings"
+
+const (
+	WebhookEndpointStatusEnable  = 1
+	WebhookEndpointStatusDisable = 2
+)
+
+// 订阅全部事件
+const WebhookEventAll = "*"
+
+// 商户可订阅的事件类型
+const (
+	WebhookEventOrderPaid            = "order.paid"
+	WebhookEventOrderExpired         = "order.expired"
+	WebhookEventAuthActivated        = "auth.activated"
+	WebhookEventDeductionSucceeded   = "deduction.succeeded"
+	WebhookEventDeductionFailed      = "deduction.failed"
+	WebhookEventWithdrawalCompleted  = "withdrawal.completed"
+	WebhookEventWithdrawalFailed     = "withdrawal.failed"
+	WebhookEventSubscriptionCharged  = "subscription.charged"
+	WebhookEventSubscriptionPastDue  = "subscription.past_due"
+	WebhookEventSubscriptionUnpaid   = "subscription.unpaid"
+	WebhookEventSubscriptionCanceled = "subscription.canceled"
+	WebhookEventWalletLowGas         = "wallet.low_gas"
+)
+
+// WebhookEventTypes 全部事件类型
+var WebhookEventTypes = []string{
+	WebhookEventOrderPaid,
+	WebhookEventOrderExpired,
+	WebhookEventAuthActivated,
+	WebhookEventDeductionSucceeded,
+	WebhookEventDeductionFailed,
+	WebhookEventWithdrawalCompleted,
+	WebhookEventWithdrawalFailed,
+	WebhookEventSubscriptionCharged,
+	WebhookEventSubscriptionPastDue,
+	WebhookEventSubscriptionUnpaid,
+	WebhookEventSubscriptionCanceled,
+	WebhookEventWalletLowGas,
+}
+
+// IsWebhookEventType 是否为支持的事件类型
+func IsWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint 商户注册的事件接收地址
+type WebhookEndpoint struct {
+	MerchantID  uint64 `gorm:"column:merchant_id;index" json:"merchant_id"`             // 所属商家
+	Url         string `gorm:"column:url;type:varchar(255)" json:"url"`                 // 接收地址
+	Secret      string `gorm:"column:secret;type:varchar(64)" json:"secret"`            // 签名密钥
+	Events      string `gorm:"column:events;type:varchar(512)" json:"events"`           // 订阅的事件，逗号分隔，* 表示全部
+	Description string `gorm:"column:description;type:varchar(255)" json:"description"` // 备注
+	Status      int    `gorm:"column:status;default:1" json:"status"`                   // 1:启用 2:停用
+	BaseModel
+}
+
+func (w *WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Subscribes 是否订阅了该事件
+func (w *WebhookEndpoint) Subscribes(eventType string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == WebhookEventAll || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 事件投递记录，每次尝试一条
+type WebhookDelivery struct {
+	EventId      string `gorm:"column:event_id;type:varchar(64);index" json:"event_id"`      // 事件ID
+	EventType    string `gorm:"column:event_type;type:varchar(64);index" json:"event_type"`  // 事件类型
+	EndpointID   uint64 `gorm:"column:endpoint_id;index" json:"endpoint_id"`                 // 接收地址ID
+	MerchantID   uint64 `gorm:"column:merchant_id;index" json:"merchant_id"`                 // 所属商家
+	Url          string `gorm:"column:url;type:varchar(255)" json:"url"`                     // 投递地址
+	Attempt      int    `gorm:"column:attempt" json:"attempt"`                               // 第几次尝试
+	RequestBody  string `gorm:"column:request_body;type:text" json:"request_body"`           // 请求体
+	ResponseBody string `gorm:"column:response_body;type:text" json:"response_body"`         // 响应体
+	StatusCode   int    `gorm:"column:status_code" json:"status_code"`                       // HTTP状态码
+	Success      int    `gorm:"column:success;default:0" json:"success"`                     // 1成功 0失败
+	ErrorMessage string `gorm:"column:error_message;type:varchar(255)" json:"error_message"` // 错误信息
+	DurationMs   int64  `gorm:"column:duration_ms" json:"duration_ms"`                       // 耗时(毫秒)
+	ParentId     uint64 `gorm:"column:parent_id;index;default:0" json:"parent_id"`           // 手动重发时指向最初的投递记录
+	DeadLetter   int    `gorm:"column:dead_letter;default:0" json:"dead_letter"`             // 1:重试耗尽仍失败，进入死信
+	BaseModel
+}
+
+func (w *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
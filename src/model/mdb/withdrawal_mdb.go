@@ -1,5 +1,7 @@
 package mdb
 
+import "github.com/shopspring/decimal"
+
 const (
 	WithdrawalStatusPending   = 1 // 待审核
 	WithdrawalStatusApproved  = 2 // 已批准（转账中）
@@ -9,19 +11,27 @@ const (
 
 // MerchantWithdrawal 商家提现表
 type MerchantWithdrawal struct {
-	WithdrawNo   string  `gorm:"column:withdraw_no;type:varchar(64);uniqueIndex" json:"withdraw_no"` // 提现单号
-	MerchantID   uint64  `gorm:"column:merchant_id;index" json:"merchant_id"`                        // 商家ID
-	Amount       float64 `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                     // 提现金额(USDT)
-	ToWallet     string  `gorm:"column:to_wallet;type:varchar(128)" json:"to_wallet"`                // 提现目标钱包地址
-	Chain        string  `gorm:"column:chain;type:varchar(20);default:BSC" json:"chain"`             // 链(BSC)
-	Status       int     `gorm:"column:status;default:1" json:"status"`                              // 1:待审核 2:已批准(转账中) 3:已完成 4:已拒绝
-	TxHash       string  `gorm:"column:tx_hash;type:varchar(128)" json:"tx_hash"`                    // 转账交易哈希
-	RejectReason string  `gorm:"column:reject_reason;type:varchar(256)" json:"reject_reason"`        // 拒绝原因
-	ReviewedBy   string  `gorm:"column:reviewed_by;type:varchar(64)" json:"reviewed_by"`             // 审核人
-	ReviewedAt   int64   `gorm:"column:reviewed_at" json:"reviewed_at"`                              // 审核时间
+	WithdrawNo    string          `gorm:"column:withdraw_no;type:varchar(64);uniqueIndex" json:"withdraw_no"`    // 提现单号
+	MerchantID    uint64          `gorm:"column:merchant_id;index" json:"merchant_id"`                           // 商家ID
+	Amount        decimal.Decimal `gorm:"column:amount;type:decimal(19,6)" json:"amount"`                        // 提现金额(USDT)
+	FeeAmount     decimal.Decimal `gorm:"column:fee_amount;type:decimal(19,6);default:0" json:"fee_amount"`      // 平台手续费(USDT)，另从余额扣除
+	ToWallet      string          `gorm:"column:to_wallet;type:varchar(128)" json:"to_wallet"`                   // 提现目标钱包地址
+	Chain         string          `gorm:"column:chain;type:varchar(20);default:BSC" json:"chain"`                // 链(BSC)
+	TokenSymbol   string          `gorm:"column:token_symbol;type:varchar(20);default:USDT" json:"token_symbol"` // 代币符号
+	Status        int             `gorm:"column:status;default:1" json:"status"`                                 // 1:待审核 2:已批准(转账中) 3:已完成 4:已拒绝
+	TxHash        string          `gorm:"column:tx_hash;type:varchar(128)" json:"tx_hash"`                       // 转账交易哈希
+	RejectReason  string          `gorm:"column:reject_reason;type:varchar(256)" json:"reject_reason"`           // 拒绝原因
+	ReviewedBy    string          `gorm:"column:reviewed_by;type:varchar(64)" json:"reviewed_by"`                // 审核人
+	ReviewedAt    int64           `gorm:"column:reviewed_at" json:"reviewed_at"`                                 // 审核时间
+	LedgerPending int             `gorm:"column:ledger_pending;default:0;index" json:"ledger_pending"`           // 1:转账已结束但记账失败，等待补记
 	BaseModel
 }
 
 func (m *MerchantWithdrawal) TableName() string {
 	return "merchant_withdrawals"
 }
+
+// DebitAmount 从商家余额扣除的总额，手续费另外收取，到账金额为 Amount
+func (m *MerchantWithdrawal) DebitAmount() decimal.Decimal {
+	return m.Amount.Add(m.FeeAmount)
+}
@@ -0,0 +1,20 @@
+package request
+
+import "github.com/gookit/validate"
+
+// CallbackAckRequest 设置回调确认规则
+type CallbackAckRequest struct {
+	Mode      string `json:"mode" validate:"in:body,2xx,json"`
+	Body      string `json:"body" validate:"maxLen:255"`
+	JsonPath  string `json:"json_path" validate:"maxLen:128"`
+	JsonValue string `json:"json_value" validate:"maxLen:255"`
+}
+
+func (r CallbackAckRequest) Translates() map[string]string {
+	return validate.MS{
+		"Mode":      "确认方式",
+		"Body":      "响应内容",
+		"JsonPath":  "JSON路径",
+		"JsonValue": "JSON期望值",
+	}
+}
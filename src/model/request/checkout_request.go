@@ -0,0 +1,18 @@
+package request
+
+import "github.com/gookit/validate"
+
+// CheckoutBrandingRequest 设置收银台品牌，字段为空时使用全局配置
+type CheckoutBrandingRequest struct {
+	BrandName  string `json:"brand_name" validate:"maxLen:64"`
+	LogoUrl    string `json:"logo_url" validate:"fullUrl|maxLen:255"`
+	ThemeColor string `json:"theme_color" validate:"maxLen:20"`
+}
+
+func (r CheckoutBrandingRequest) Translates() map[string]string {
+	return validate.MS{
+		"BrandName":  "品牌名称",
+		"LogoUrl":    "Logo地址",
+		"ThemeColor": "主题色",
+	}
+}
@@ -0,0 +1,23 @@
+package request
+
+import (
+	"github.com/gookit/validate"
+	"github.com/shopspring/decimal"
+)
+
+// FeeScheduleRequest 创建/更新手续费方案
+type FeeScheduleRequest struct {
+	MerchantID  uint64          `json:"merchant_id"` // 0 表示全局方案
+	BizType     string          `json:"biz_type" validate:"required|in:order,deduction,withdrawal"`
+	Percent     decimal.Decimal `json:"percent"`      // 按比例收取，单位 %
+	FixedAmount decimal.Decimal `json:"fixed_amount"` // 每笔固定收取(USDT)
+	Status      int             `json:"status"`
+	Remark      string          `json:"remark" validate:"maxLen:255"`
+}
+
+func (r FeeScheduleRequest) Translates() map[string]string {
+	return validate.MS{
+		"BizType": "业务类型",
+		"Remark":  "备注",
+	}
+}
@@ -2,9 +2,9 @@ package request
 
 import (
 	"fmt"
-	"math"
 
 	"github.com/gookit/validate"
+	"github.com/shopspring/decimal"
 )
 
 // 金额安全限制常量
@@ -15,23 +15,30 @@ const (
 
 // CreateTransactionRequest 创建交易请求
 type CreateTransactionRequest struct {
-	OrderId     string  `json:"order_id" validate:"required|maxLen:32"`
-	Amount      float64 `json:"amount" validate:"required|isFloat|gt:0.01"`
-	NotifyUrl   string  `json:"notify_url" validate:"required"`
-	Signature   string  `json:"signature"  validate:"required"`
-	RedirectUrl string  `json:"redirect_url"`
-	Chain       string  `json:"chain"`
-	Timestamp   int64   `json:"timestamp"`
-	Nonce       string  `json:"nonce"`
+	OrderId     string          `json:"order_id" validate:"required|maxLen:32"`
+	Amount      decimal.Decimal `json:"amount" validate:"required|amountGt:0.01"`
+	Currency    string          `json:"currency"`
+	NotifyUrl   string          `json:"notify_url" validate:"required"`
+	Signature   string          `json:"signature"  validate:"required"`
+	RedirectUrl string          `json:"redirect_url"`
+	Chain       string          `json:"chain"`
+	TokenSymbol string          `json:"token_symbol"`
+	Timestamp   int64           `json:"timestamp"`
+	Nonce       string          `json:"nonce"`
+
+	PaymentLinkId uint64 `json:"-"` // 收款链接下单时由服务端填写
+	MerchantID    uint64 `json:"-"` // 签名识别出的商家，由服务端填写
 }
 
 func (r CreateTransactionRequest) Translates() map[string]string {
 	return validate.MS{
-		"OrderId":   "订单号",
-		"Amount":    "支付金额",
-		"NotifyUrl": "异步回调网址",
-		"Signature": "签名",
-		"Chain":     "链",
+		"OrderId":     "订单号",
+		"Amount":      "支付金额",
+		"NotifyUrl":   "异步回调网址",
+		"Signature":   "签名",
+		"Chain":       "链",
+		"Currency":    "法币币种",
+		"TokenSymbol": "代币",
 	}
 }
 
@@ -39,12 +46,11 @@ func (r CreateTransactionRequest) Translates() map[string]string {
 // 在业务层调用此方法进行额外校验
 func (r CreateTransactionRequest) ValidateAmount() error {
 	// 验证金额上限
-	if r.Amount > MaxOrderAmount {
+	if r.Amount.GreaterThan(decimal.NewFromFloat(MaxOrderAmount)) {
 		return fmt.Errorf("支付金额不能超过 %.0f", MaxOrderAmount)
 	}
 	// 验证小数位数（最多2位）
-	rounded := math.Round(r.Amount*100) / 100
-	if math.Abs(r.Amount-rounded) > 1e-9 {
+	if !r.Amount.Equal(r.Amount.Truncate(MaxDecimalPlaces)) {
 		return fmt.Errorf("支付金额小数位数不能超过 %d 位", MaxDecimalPlaces)
 	}
 	return nil
@@ -53,7 +59,14 @@ func (r CreateTransactionRequest) ValidateAmount() error {
 // OrderProcessingRequest 订单处理
 type OrderProcessingRequest struct {
 	Token              string
-	Amount             float64
+	Chain              string
+	TokenSymbol        string
+	Amount             decimal.Decimal
 	TradeId            string
 	BlockTransactionId string
+	LogIndex           uint   // 同一交易内转入该地址的第几笔，从 0 开始
+	FromAddress        string // 付款钱包地址
+	BlockTimestamp     int64  // 区块时间(毫秒)
+	BlockNumber        uint64 // 区块高度
+	BlockHash          string // 区块哈希
 }
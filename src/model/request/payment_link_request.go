@@ -0,0 +1,31 @@
+package request
+
+import (
+	"github.com/gookit/validate"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentLinkRequest 创建/更新收款链接
+type PaymentLinkRequest struct {
+	Slug        string          `json:"slug"`
+	Title       string          `json:"title" validate:"required|maxLen:128"`
+	Description string          `json:"description" validate:"maxLen:512"`
+	Amount      decimal.Decimal `json:"amount"`
+	MinAmount   decimal.Decimal `json:"min_amount"`
+	MaxAmount   decimal.Decimal `json:"max_amount"`
+	Currency    string          `json:"currency"`
+	Chain       string          `json:"chain"`
+	TokenSymbol string          `json:"token_symbol"`
+	NotifyUrl   string          `json:"notify_url"`
+	RedirectUrl string          `json:"redirect_url"`
+	MaxUses     int             `json:"max_uses"`
+	ExpiresAt   int64           `json:"expires_at"`
+	Status      int             `json:"status"`
+}
+
+func (r PaymentLinkRequest) Translates() map[string]string {
+	return validate.MS{
+		"Title":       "标题",
+		"Description": "描述",
+	}
+}
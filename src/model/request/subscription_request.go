@@ -0,0 +1,28 @@
+package request
+
+import (
+	"github.com/gookit/validate"
+	"github.com/shopspring/decimal"
+)
+
+// SubscriptionRequest 创建订阅
+type SubscriptionRequest struct {
+	AuthNo               string          `json:"auth_no" validate:"required"`
+	PlanName             string          `json:"plan_name" validate:"required|maxLen:128"`
+	AmountUsdt           decimal.Decimal `json:"amount_usdt" validate:"required|amountGt:0"`
+	IntervalUnit         string          `json:"interval_unit" validate:"required|in:day,week,month"`
+	IntervalCount        int             `json:"interval_count"`
+	StartTime            int64           `json:"start_time"`
+	MaxRetries           *int            `json:"max_retries"`
+	RetryIntervalMinutes int             `json:"retry_interval_minutes"`
+	NotifyUrl            string          `json:"notify_url"`
+}
+
+func (r SubscriptionRequest) Translates() map[string]string {
+	return validate.MS{
+		"AuthNo":       "授权编号",
+		"PlanName":     "套餐名称",
+		"AmountUsdt":   "扣款金额",
+		"IntervalUnit": "周期单位",
+	}
+}
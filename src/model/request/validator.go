@@ -0,0 +1,21 @@
+package request
+
+import (
+	"github.com/gookit/validate"
+	"github.com/shopspring/decimal"
+)
+
+// 金额字段为 decimal.Decimal，内置的 isFloat/gt 规则不识别，注册按十进制比较的规则
+func init() {
+	validate.AddValidator("amountGt", func(val interface{}, min string) bool {
+		amount, ok := val.(decimal.Decimal)
+		if !ok {
+			return false
+		}
+		limit, err := decimal.NewFromString(min)
+		return err == nil && amount.GreaterThan(limit)
+	})
+	validate.AddGlobalMessages(map[string]string{
+		"amountGt": "{field} 必须大于 %v",
+	})
+}
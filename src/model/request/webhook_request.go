@@ -0,0 +1,19 @@
+package request
+
+import "github.com/gookit/validate"
+
+// WebhookEndpointRequest 创建/更新事件接收地址
+type WebhookEndpointRequest struct {
+	Url         string   `json:"url" validate:"required|fullUrl|maxLen:255"`
+	Events      []string `json:"events" validate:"required"`
+	Description string   `json:"description" validate:"maxLen:255"`
+	Status      int      `json:"status"`
+}
+
+func (r WebhookEndpointRequest) Translates() map[string]string {
+	return validate.MS{
+		"Url":         "接收地址",
+		"Events":      "订阅事件",
+		"Description": "备注",
+	}
+}
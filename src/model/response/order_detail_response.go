@@ -5,20 +5,27 @@ import (
 	"strings"
 
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
 )
 
 // OrderDetailResponse 订单详情响应结构体
 type OrderDetailResponse struct {
 	// 订单基本信息
-	TradeId            string  `json:"trade_id"`             // epusdt订单号
-	OrderId            string  `json:"order_id"`             // 客户交易id
-	Amount             float64 `json:"amount"`               // 订单金额
-	ActualAmount       float64 `json:"actual_amount"`        // 实际支付金额
-	Token              string  `json:"token"`                // 收款钱包地址
-	Chain              string  `json:"chain"`                // 链
-	Status             int     `json:"status"`               // 订单状态 1:等待支付 2:支付成功 3:已过期
-	StatusText         string  `json:"status_text"`          // 状态文本
-	BlockTransactionId string  `json:"block_transaction_id"` // 区块交易ID
+	TradeId            string          `json:"trade_id"`             // epusdt订单号
+	OrderId            string          `json:"order_id"`             // 客户交易id
+	Amount             decimal.Decimal `json:"amount"`               // 订单金额
+	Currency           string          `json:"currency"`             // 订单金额法币币种
+	UsdtRate           decimal.Decimal `json:"usdt_rate"`            // 下单时使用的汇率
+	ActualAmount       decimal.Decimal `json:"actual_amount"`        // 实际支付金额
+	Token              string          `json:"token"`                // 收款钱包地址
+	Chain              string          `json:"chain"`                // 链
+	TokenSymbol        string          `json:"token_symbol"`         // 收款代币
+	Status             int             `json:"status"`               // 订单状态 1:等待支付 2:支付成功 3:已过期
+	StatusText         string          `json:"status_text"`          // 状态文本
+	BlockTransactionId string          `json:"block_transaction_id"` // 区块交易ID
+	FromAddress        string          `json:"from_address"`         // 付款钱包地址
+	ReceivedAmount     decimal.Decimal `json:"received_amount"`      // 已收到金额
+	RefundedAmount     decimal.Decimal `json:"refunded_amount"`      // 已退款金额
 
 	// 回调信息
 	NotifyUrl       string `json:"notify_url"`        // 异步回调地址
@@ -57,6 +64,16 @@ func GetStatusText(status int) string {
 		return "支付成功"
 	case mdb.StatusExpired:
 		return "已过期"
+	case mdb.StatusRefunded:
+		return "已退款"
+	case mdb.StatusPartialRefunded:
+		return "部分退款"
+	case mdb.StatusPartiallyPaid:
+		return "部分支付"
+	case mdb.StatusOverpaid:
+		return "超额支付"
+	case mdb.StatusConfirming:
+		return "确认中"
 	default:
 		return "未知状态"
 	}
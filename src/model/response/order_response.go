@@ -1,26 +1,66 @@
 package response
 
+import "github.com/shopspring/decimal"
+
 // CreateTransactionResponse 创建订单成功返回
 type CreateTransactionResponse struct {
-	TradeId        string  `json:"trade_id"`        //  epusdt订单号
-	OrderId        string  `json:"order_id"`        //  客户交易id
-	Amount         float64 `json:"amount"`          //  订单金额，保留4位小数
-	ActualAmount   float64 `json:"actual_amount"`   //  订单实际需要支付的金额，保留4位小数
-	Token          string  `json:"token"`           //  收款钱包地址
-	Chain          string  `json:"chain"`           //  链
-	ExpirationTime int64   `json:"expiration_time"` // 过期时间 时间戳
-	PaymentUrl     string  `json:"payment_url"`     // 收银台地址
+	TradeId        string          `json:"trade_id"`        //  epusdt订单号
+	OrderId        string          `json:"order_id"`        //  客户交易id
+	Amount         decimal.Decimal `json:"amount"`          //  订单法币金额，最多2位小数
+	Currency       string          `json:"currency"`        //  订单金额法币币种
+	UsdtRate       decimal.Decimal `json:"usdt_rate"`       //  下单时使用的汇率
+	ActualAmount   decimal.Decimal `json:"actual_amount"`   //  订单实际需要支付的代币金额，保留2位小数
+	Token          string          `json:"token"`           //  收款钱包地址
+	Chain          string          `json:"chain"`           //  链
+	TokenSymbol    string          `json:"token_symbol"`    //  代币符号
+	ExpirationTime int64           `json:"expiration_time"` // 过期时间 时间戳
+	PaymentUrl     string          `json:"payment_url"`     // 收银台地址
 }
 
 // OrderNotifyResponse 订单异步回调结构体
 type OrderNotifyResponse struct {
-	TradeId            string  `json:"trade_id"`             //  epusdt订单号
-	OrderId            string  `json:"order_id"`             //  客户交易id
-	Amount             float64 `json:"amount"`               //  订单金额，保留4位小数
-	ActualAmount       float64 `json:"actual_amount"`        //  订单实际需要支付的金额，保留4位小数
-	Token              string  `json:"token"`                //  收款钱包地址
-	Chain              string  `json:"chain"`                //  链
-	BlockTransactionId string  `json:"block_transaction_id"` // 区块id
-	Signature          string  `json:"signature"`            // 签名
-	Status             int     `json:"status"`               //  1：等待支付，2：支付成功，3：已过期
+	TradeId            string          `json:"trade_id"`             //  epusdt订单号
+	OrderId            string          `json:"order_id"`             //  客户交易id
+	Amount             decimal.Decimal `json:"amount"`               //  订单法币金额，最多2位小数
+	Currency           string          `json:"currency"`             //  订单金额法币币种
+	UsdtRate           decimal.Decimal `json:"usdt_rate"`            //  下单时使用的汇率
+	ActualAmount       decimal.Decimal `json:"actual_amount"`        //  订单实际需要支付的代币金额，保留2位小数
+	ReceivedAmount     decimal.Decimal `json:"received_amount"`      //  实际收到的金额
+	Token              string          `json:"token"`                //  收款钱包地址
+	Chain              string          `json:"chain"`                //  链
+	TokenSymbol        string          `json:"token_symbol"`         //  代币符号
+	BlockTransactionId string          `json:"block_transaction_id"` // 区块id
+	Signature          string          `json:"signature"`            // 签名
+	Status             int             `json:"status"`               //  2：支付成功，6：部分支付，7：超额支付
+}
+
+// RefundNotifyResponse 退款异步回调结构体
+type RefundNotifyResponse struct {
+	TradeId        string          `json:"trade_id"`        //  epusdt订单号
+	OrderId        string          `json:"order_id"`        //  客户交易id
+	RefundNo       string          `json:"refund_no"`       //  退款单号
+	RefundAmount   decimal.Decimal `json:"refund_amount"`   //  本次退款金额(USDT)
+	RefundedAmount decimal.Decimal `json:"refunded_amount"` //  订单累计已退款金额(USDT)
+	ToWallet       string          `json:"to_wallet"`       //  退款目标钱包
+	Chain          string          `json:"chain"`           //  链
+	TxHash         string          `json:"tx_hash"`         //  退款交易哈希
+	Signature      string          `json:"signature"`       //  签名
+	Status         int             `json:"status"`          //  订单状态 4：已退款，5：部分退款
+}
+
+// SubscriptionNotifyResponse 订阅事件异步通知结构体
+type SubscriptionNotifyResponse struct {
+	Event           string          `json:"event"`             //  事件类型
+	SubscriptionNo  string          `json:"subscription_no"`   //  订阅编号
+	AuthNo          string          `json:"auth_no"`           //  授权编号
+	PlanName        string          `json:"plan_name"`         //  套餐名称
+	AmountUsdt      decimal.Decimal `json:"amount_usdt"`       //  每期扣款金额(USDT)
+	DeductNo        string          `json:"deduct_no"`         //  扣款单号
+	TxHash          string          `json:"tx_hash"`           //  扣款交易哈希
+	FailReason      string          `json:"fail_reason"`       //  失败原因
+	RetryCount      int             `json:"retry_count"`       //  当期已重试次数
+	PeriodEnd       int64           `json:"period_end"`        //  已付周期结束时间
+	NextBillingTime int64           `json:"next_billing_time"` //  下次扣款时间
+	Signature       string          `json:"signature"`         //  签名
+	Status          int             `json:"status"`            //  订阅状态 1：正常，2：逾期，3：停止扣款，4：已取消
 }
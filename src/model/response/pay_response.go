@@ -1,11 +1,38 @@
 package response
 
+import "github.com/shopspring/decimal"
+
 type CheckoutCounterResponse struct {
-	TradeId        string  `json:"trade_id"`        //  epusdt订单号
-	ActualAmount   float64 `json:"actual_amount"`   //  订单实际需要支付的金额，保留4位小数
-	Token          string  `json:"token"`           //  收款钱包地址
-	ExpirationTime int64   `json:"expiration_time"` // 过期时间 时间戳
-	RedirectUrl    string  `json:"redirect_url"`
+	TradeId        string          `json:"trade_id"`        //  epusdt订单号
+	OrderId        string          `json:"order_id"`        //  客户交易id
+	Amount         decimal.Decimal `json:"amount"`          //  订单法币金额
+	Currency       string          `json:"currency"`        //  订单法币币种
+	ActualAmount   decimal.Decimal `json:"actual_amount"`   //  订单实际需要支付的代币金额，保留2位小数
+	ReceivedAmount decimal.Decimal `json:"received_amount"` //  已收到金额
+	PayableAmount  decimal.Decimal `json:"payable_amount"`  //  当前仍需支付的金额
+	Token          string          `json:"token"`           //  收款钱包地址
+	Chain          string          `json:"chain"`           //  链
+	ChainName      string          `json:"chain_name"`      //  链显示名称
+	TokenSymbol    string          `json:"token_symbol"`    //  收款代币
+	Status         int             `json:"status"`          //  订单状态
+	ExpirationTime int64           `json:"expiration_time"` // 过期时间 时间戳
+	RedirectUrl    string          `json:"redirect_url"`
+	MerchantID     uint64          `json:"-"` // 所属商家，用于收银台品牌
+
+	Chains []CheckoutChainOption `json:"chains"` // 未选择链时可供选择的链
+}
+
+// CheckoutBranding 收银台品牌
+type CheckoutBranding struct {
+	BrandName  string `json:"brand_name"`
+	LogoUrl    string `json:"logo_url"`
+	ThemeColor string `json:"theme_color"`
+}
+
+// CheckoutChainOption 收银台可选链
+type CheckoutChainOption struct {
+	Chain     string `json:"chain"`      // 链
+	ChainName string `json:"chain_name"` // 链显示名称
 }
 
 type CheckStatusResponse struct {
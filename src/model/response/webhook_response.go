@@ -0,0 +1,27 @@
+package response
+
+import "github.com/shopspring/decimal"
+
+// WebhookEvent 商户事件通知信封
+type WebhookEvent struct {
+	Id         string      `json:"id"`          //  事件ID，重试时不变，可用于去重
+	Type       string      `json:"type"`        //  事件类型
+	ApiVersion string      `json:"api_version"` //  信封版本
+	CreatedAt  int64       `json:"created_at"`  //  事件时间戳
+	Data       interface{} `json:"data"`        //  事件对象
+}
+
+// OrderEventData 订单事件对象
+type OrderEventData struct {
+	TradeId            string          `json:"trade_id"`             //  epusdt订单号
+	OrderId            string          `json:"order_id"`             //  客户交易id
+	Amount             decimal.Decimal `json:"amount"`               //  订单金额
+	Currency           string          `json:"currency"`             //  订单金额法币币种
+	ActualAmount       decimal.Decimal `json:"actual_amount"`        //  应付代币金额
+	ReceivedAmount     decimal.Decimal `json:"received_amount"`      //  实际收到的金额
+	Token              string          `json:"token"`                //  收款钱包地址
+	Chain              string          `json:"chain"`                //  链
+	TokenSymbol        string          `json:"token_symbol"`         //  代币符号
+	BlockTransactionId string          `json:"block_transaction_id"` //  交易哈希
+	Status             int             `json:"status"`               //  订单状态
+}
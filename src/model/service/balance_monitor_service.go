@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/tron"
+	"github.com/shopspring/decimal"
+)
+
+// monitoredWallet 需要监控余额的钱包
+type monitoredWallet struct {
+	address    string
+	kind       string
+	merchantID uint64
+}
+
+// RunBalanceMonitor 刷新所有启用的收款钱包与公司钱包的 USDT、原生币余额，原生币低于阈值时告警
+func RunBalanceMonitor() {
+	keepIDs := make([]uint64, 0)
+	complete := true
+	for _, info := range chain.GetAllChains() {
+		client, err := getWalletClient(info.Name)
+		if err != nil {
+			continue
+		}
+		wallets, err := monitoredWallets(info.Name)
+		if err != nil {
+			log.Sugar.Errorf("[balance] %s 查询钱包失败, err=%v", info.Name, err)
+			complete = false
+			continue
+		}
+		for _, wallet := range wallets {
+			id, err := refreshWalletBalance(client, info, wallet)
+			if err != nil {
+				log.Sugar.Errorf("[balance] 保存余额快照失败, chain=%s, address=%s, err=%v", info.Name, wallet.address, err)
+				complete = false
+				continue
+			}
+			keepIDs = append(keepIDs, id)
+		}
+	}
+	// 钱包被禁用或删除后清理快照，查询不完整时保留以免误删
+	if complete {
+		if err := data.DeleteWalletBalancesExcept(keepIDs); err != nil {
+			log.Sugar.Errorf("[balance] 清理余额快照失败, err=%v", err)
+		}
+	}
+}
+
+// monitoredWallets 链上需要监控的钱包：启用的收款钱包与公司钱包
+func monitoredWallets(chainName string) ([]monitoredWallet, error) {
+	list, err := data.GetAvailableWalletAddressByChain(chainName)
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]monitoredWallet, 0, len(list)+1)
+	seen := make(map[string]bool, len(list))
+	for _, w := range list {
+		if seen[strings.ToLower(w.Token)] {
+			continue
+		}
+		seen[strings.ToLower(w.Token)] = true
+		wallets = append(wallets, monitoredWallet{address: w.Token, kind: mdb.WalletKindReceive, merchantID: w.MerchantID})
+	}
+	if company := companyWalletAddress(chainName); company != "" && !seen[strings.ToLower(company)] {
+		wallets = append(wallets, monitoredWallet{address: company, kind: mdb.WalletKindCompany})
+	}
+	return wallets, nil
+}
+
+// companyWalletAddress 公司钱包在链上的地址，EVM 链优先使用 company_wallet，TRON 由私钥推导
+func companyWalletAddress(chainName string) string {
+	privateKey := config.GetCompanyPrivateKey()
+	if chain.IsTronChain(chainName) {
+		if privateKey == "" {
+			return ""
+		}
+		address, err := tron.PrivateKeyToAddress(privateKey)
+		if err != nil {
+			return ""
+		}
+		return address
+	}
+	if wallet := config.GetCompanyWallet(); chain.ValidateAddress(chainName, wallet) == nil {
+		return wallet
+	}
+	if privateKey == "" {
+		return ""
+	}
+	address, err := evm.AddressFromPrivateKey(privateKey)
+	if err != nil {
+		return ""
+	}
+	return address
+}
+
+// refreshWalletBalance 查询链上余额并更新快照，返回快照ID；查询失败时保留上次余额并记录原因
+func refreshWalletBalance(client walletClient, info *chain.ChainInfo, wallet monitoredWallet) (uint64, error) {
+	snapshot, err := data.GetWalletBalance(info.Name, wallet.address)
+	if err != nil {
+		return 0, err
+	}
+	snapshot.Chain = info.Name
+	snapshot.Address = wallet.address
+	snapshot.Kind = wallet.kind
+	snapshot.MerchantID = wallet.merchantID
+	snapshot.NativeSymbol = info.NativeSymbol
+	snapshot.GasThreshold = decimal.NewFromFloat(info.GasAlert)
+
+	usdtBalance, err := client.tokenBalance(chain.TokenUsdt, wallet.address)
+	if err == nil {
+		var nativeBalance decimal.Decimal
+		nativeBalance, err = client.nativeBalance(wallet.address)
+		if err == nil {
+			snapshot.UsdtBalance = usdtBalance
+			snapshot.NativeBalance = nativeBalance
+		}
+	}
+	if err != nil {
+		snapshot.Error = truncateFailReason(err.Error())
+		log.Sugar.Warnf("[balance] %s 查询余额失败, address=%s, err=%v", info.Name, wallet.address, err)
+		if err := data.SaveWalletBalance(snapshot); err != nil {
+			return 0, err
+		}
+		return snapshot.ID, nil
+	}
+	snapshot.Error = ""
+
+	lowGas := snapshot.GasThreshold.IsPositive() && snapshot.NativeBalance.LessThan(snapshot.GasThreshold)
+	changed := lowGas != snapshot.LowGas
+	snapshot.LowGas = lowGas
+	if err := data.SaveWalletBalance(snapshot); err != nil {
+		return 0, err
+	}
+	// 仅在状态变化时通知，避免每次巡检重复告警
+	if changed {
+		notifyWalletGas(snapshot)
+		if lowGas && snapshot.MerchantID > 0 {
+			publishWebhookEvent(snapshot.MerchantID, mdb.WebhookEventWalletLowGas, snapshot)
+		}
+	}
+	return snapshot.ID, nil
+}
+
+func notifyWalletGas(snapshot *mdb.WalletBalance) {
+	kind := "收款钱包"
+	if snapshot.Kind == mdb.WalletKindCompany {
+		kind = "公司钱包"
+	}
+	if snapshot.LowGas {
+		msgTpl := `
+<b>⚠️ 钱包 gas 余额不足!</b>
+<pre>类型: %s</pre>
+<pre>商家ID: %d</pre>
+<pre>链: %s</pre>
+<pre>地址: %s</pre>
+<pre>余额: %s %s</pre>
+<pre>阈值: %s %s</pre>
+`
+		telegram.SendToBot(fmt.Sprintf(msgTpl, kind, snapshot.MerchantID, snapshot.Chain, snapshot.Address,
+			snapshot.NativeBalance.String(), snapshot.NativeSymbol, snapshot.GasThreshold.String(), snapshot.NativeSymbol))
+		return
+	}
+	msgTpl := `
+<b>✅ 钱包 gas 余额已恢复</b>
+<pre>类型: %s</pre>
+<pre>链: %s</pre>
+<pre>地址: %s</pre>
+<pre>余额: %s %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, kind, snapshot.Chain, snapshot.Address, snapshot.NativeBalance.String(), snapshot.NativeSymbol))
+}
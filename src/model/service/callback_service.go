@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/util/ack"
+)
+
+// 单次批量重发的最大回调数
+const callbackRedeliverBatch = 500
+
+// RedeliverCallback 手动重发一条回调，merchantID 为 0 时为管理员操作
+func RedeliverCallback(merchantID, id uint64) error {
+	callbackLog, err := data.GetCallbackLogById(id)
+	if err != nil {
+		return err
+	}
+	if callbackLog.ID <= 0 || (merchantID > 0 && callbackLog.MerchantID != merchantID) {
+		return errors.New("回调记录不存在")
+	}
+	return handle.EnqueueCallbackRedelivery(callbackLog)
+}
+
+// RedeliverFailedCallbacks 批量重发时间范围内进入死信且未送达的回调
+// 订单、退款重发时发送最新数据，同一业务单只重发一次；订阅通知按原内容重发，同一条通知只重发一次
+func RedeliverFailedCallbacks(merchantID uint64, startTime, endTime int64) (int, error) {
+	if startTime <= 0 || endTime <= 0 || startTime > endTime {
+		return 0, errors.New("时间范围有误")
+	}
+	logs, err := data.GetUnresolvedDeadLetterCallbackLogs(merchantID, time.Unix(startTime, 0), time.Unix(endTime, 0), callbackRedeliverBatch)
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[string]bool)
+	count := 0
+	for i := range logs {
+		key := redeliverKey(&logs[i])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err = handle.EnqueueCallbackRedelivery(&logs[i]); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// redeliverKey 批量重发的去重键
+func redeliverKey(callbackLog *mdb.CallbackLog) string {
+	if callbackLog.CallbackType == mdb.CallbackTypeSubscription {
+		rootId := callbackLog.ID
+		if callbackLog.ParentId > 0 {
+			rootId = callbackLog.ParentId
+		}
+		return fmt.Sprintf("%s:%d", callbackLog.CallbackType, rootId)
+	}
+	return callbackLog.CallbackType + ":" + callbackLog.BizNo
+}
+
+// GetMerchantCallbackLogs 商家回调日志
+func GetMerchantCallbackLogs(merchantID uint64, page, pageSize int, callbackType string, deadLetter int) ([]mdb.CallbackLog, int64, error) {
+	return data.GetMerchantCallbackLogs(merchantID, page, pageSize, callbackType, deadLetter)
+}
+
+// RedeliverWebhookDelivery 商家手动重发一条事件投递
+func RedeliverWebhookDelivery(merchantID, id uint64) error {
+	delivery, err := data.GetMerchantWebhookDelivery(merchantID, id)
+	if err != nil {
+		return err
+	}
+	if delivery.ID <= 0 {
+		return errors.New("投递记录不存在")
+	}
+	return handle.EnqueueWebhookRedelivery(delivery)
+}
+
+// GetMerchantCallbackAck 商家的回调确认规则
+func GetMerchantCallbackAck(merchantID uint64) (map[string]interface{}, error) {
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	rule := ack.Rule{
+		Mode:      merchant.CallbackAckMode,
+		Body:      merchant.CallbackAckBody,
+		JsonPath:  merchant.CallbackAckJsonPath,
+		JsonValue: merchant.CallbackAckJsonValue,
+	}.Normalize()
+	return map[string]interface{}{
+		"mode":       rule.Mode,
+		"body":       rule.Body,
+		"json_path":  rule.JsonPath,
+		"json_value": rule.JsonValue,
+		"rule":       rule.String(),
+	}, nil
+}
+
+// UpdateMerchantCallbackAck 设置商家的回调确认规则
+func UpdateMerchantCallbackAck(merchantID uint64, req *request.CallbackAckRequest) error {
+	rule := ack.Rule{
+		Mode:      req.Mode,
+		Body:      req.Body,
+		JsonPath:  req.JsonPath,
+		JsonValue: req.JsonValue,
+	}
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	rule = rule.Normalize()
+	// 只保留当前方式用到的字段
+	if rule.Mode != ack.ModeBody {
+		rule.Body = ""
+	}
+	if rule.Mode != ack.ModeJson {
+		rule.JsonPath, rule.JsonValue = "", ""
+	}
+	return data.UpdateMerchantCallbackAck(merchantID, map[string]interface{}{
+		"callback_ack_mode":       rule.Mode,
+		"callback_ack_body":       rule.Body,
+		"callback_ack_json_path":  rule.JsonPath,
+		"callback_ack_json_value": rule.JsonValue,
+	})
+}
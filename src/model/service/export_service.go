@@ -0,0 +1,256 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/export"
+	"github.com/assimon/luuu/util/log"
+)
+
+// 可导出的数据类型
+const (
+	ExportTypeOrders      = "orders"
+	ExportTypeDeductions  = "deductions"
+	ExportTypeWithdrawals = "withdrawals"
+	ExportTypeCallbacks   = "callbacks"
+)
+
+// ExportParams 导出参数，筛选条件与对应列表接口一致，零值表示不筛选
+type ExportParams struct {
+	Type         string
+	MerchantID   uint64 // 0 表示管理员导出全部商家
+	Status       int
+	Chain        string
+	OrderId      string
+	TxHash       string
+	CallbackType string
+	DeadLetter   int
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// CheckExportType 校验导出类型
+func CheckExportType(exportType string) error {
+	switch exportType {
+	case ExportTypeOrders, ExportTypeDeductions, ExportTypeWithdrawals, ExportTypeCallbacks:
+		return nil
+	}
+	return errors.New("不支持的导出类型")
+}
+
+// Export 按类型逐批写出数据，返回写出的数据行数
+func Export(w export.Writer, params *ExportParams) (int, error) {
+	switch params.Type {
+	case ExportTypeOrders:
+		return exportOrders(w, params)
+	case ExportTypeDeductions:
+		return exportDeductions(w, params)
+	case ExportTypeWithdrawals:
+		return exportWithdrawals(w, params)
+	case ExportTypeCallbacks:
+		return exportCallbackLogs(w, params)
+	}
+	return 0, errors.New("不支持的导出类型")
+}
+
+func exportOrders(w export.Writer, params *ExportParams) (int, error) {
+	rows := 0
+	err := w.Write([]interface{}{"交易号", "商户订单号", "商家ID", "链", "代币", "订单金额", "币种", "汇率", "应付金额", "已收金额", "已退款金额", "手续费", "收款地址", "付款地址", "交易哈希", "状态", "回调确认", "创建时间"})
+	if err != nil {
+		return rows, err
+	}
+	filter := &data.OrderFilter{
+		Status:    params.Status,
+		Chain:     params.Chain,
+		OrderId:   params.OrderId,
+		TxHash:    params.TxHash,
+		StartTime: params.StartTime,
+		EndTime:   params.EndTime,
+	}
+	err = data.EachOrders(params.MerchantID, filter, func(list []mdb.Orders) error {
+		for _, o := range list {
+			err := w.Write([]interface{}{
+				o.TradeId, o.OrderId, o.MerchantID, o.Chain, o.TokenSymbol, o.Amount, o.Currency, o.UsdtRate,
+				o.ActualAmount, o.ReceivedAmount, o.RefundedAmount, o.FeeAmount, o.Token, o.FromAddress, o.BlockTransactionId,
+				response.GetStatusText(o.Status), response.GetCallbackText(o.CallBackConfirm), o.CreatedAt.ToDateTimeString(),
+			})
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+func exportDeductions(w export.Writer, params *ExportParams) (int, error) {
+	rows := 0
+	err := w.Write([]interface{}{"扣款单号", "授权编号", "金额(USDT)", "金额(CNY)", "手续费(USDT)", "交易哈希", "状态", "失败原因", "消费内容", "操作员", "扣款时间"})
+	if err != nil {
+		return rows, err
+	}
+	merchantWallet := ""
+	if params.MerchantID > 0 {
+		merchant, err := data.GetMerchantByID(params.MerchantID)
+		if err != nil {
+			return rows, err
+		}
+		// 未绑定钱包的商家没有扣款记录
+		if merchant.WalletToken == "" {
+			return rows, nil
+		}
+		merchantWallet = merchant.WalletToken
+	}
+	filter := &data.DeductionFilter{Status: params.Status}
+	if !params.StartTime.IsZero() {
+		filter.StartTime = params.StartTime.Unix()
+	}
+	if !params.EndTime.IsZero() {
+		filter.EndTime = params.EndTime.Unix()
+	}
+	err = data.EachDeductions(merchantWallet, filter, func(list []mdb.KtvDeduction) error {
+		for _, d := range list {
+			err := w.Write([]interface{}{
+				d.DeductNo, d.AuthNo, d.AmountUsdt, d.AmountCny, d.FeeAmount, d.TxHash, deductionStatusText(d.Status),
+				d.FailReason, d.ProductInfo, d.OperatorID, formatUnix(d.DeductTime),
+			})
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+func exportWithdrawals(w export.Writer, params *ExportParams) (int, error) {
+	rows := 0
+	err := w.Write([]interface{}{"提现单号", "商家ID", "金额", "手续费", "代币", "链", "目标地址", "状态", "交易哈希", "拒绝原因", "审核人", "审核时间", "创建时间"})
+	if err != nil {
+		return rows, err
+	}
+	filter := &data.WithdrawalFilter{
+		Status:    params.Status,
+		StartTime: params.StartTime,
+		EndTime:   params.EndTime,
+	}
+	err = data.EachWithdrawals(params.MerchantID, filter, func(list []mdb.MerchantWithdrawal) error {
+		for _, wd := range list {
+			err := w.Write([]interface{}{
+				wd.WithdrawNo, wd.MerchantID, wd.Amount, wd.FeeAmount, wd.TokenSymbol, wd.Chain, wd.ToWallet, withdrawalStatusText(wd.Status),
+				wd.TxHash, wd.RejectReason, wd.ReviewedBy, formatUnix(wd.ReviewedAt), wd.CreatedAt.ToDateTimeString(),
+			})
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+func exportCallbackLogs(w export.Writer, params *ExportParams) (int, error) {
+	rows := 0
+	err := w.Write([]interface{}{"ID", "类型", "业务单号", "订阅事件", "交易号", "商家ID", "回调地址", "第几次尝试", "重发来源", "状态码", "是否成功", "死信", "确认规则", "错误信息", "响应内容", "时间"})
+	if err != nil {
+		return rows, err
+	}
+	filter := &data.CallbackLogFilter{
+		CallbackType: params.CallbackType,
+		DeadLetter:   params.DeadLetter,
+		StartTime:    params.StartTime,
+		EndTime:      params.EndTime,
+	}
+	err = data.EachCallbackLogs(params.MerchantID, filter, func(list []mdb.CallbackLog) error {
+		for _, l := range list {
+			err := w.Write([]interface{}{
+				l.ID, l.CallbackType, l.BizNo, l.Event, l.TradeId, l.MerchantID, l.NotifyUrl, l.Attempt, l.ParentId, l.StatusCode,
+				l.Success, l.DeadLetter, l.AckRule, l.ErrorMessage, truncate(l.ResponseBody, 500), l.CreatedAt.ToDateTimeString(),
+			})
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// RecordDataExport 记录导出审计日志
+func RecordDataExport(operator, ip, userAgent string, params *ExportParams, format string, rows int, exportErr error) {
+	details := fmt.Sprintf("type=%s format=%s merchant_id=%d rows=%d status=%d chain=%s order_id=%s tx_hash=%s callback_type=%s dead_letter=%d start=%s end=%s",
+		params.Type, format, params.MerchantID, rows, params.Status, params.Chain, params.OrderId, params.TxHash,
+		params.CallbackType, params.DeadLetter, formatTime(params.StartTime), formatTime(params.EndTime))
+	log.AuditLog(log.EventDataExport, operator, ip, details)
+	auditLog := &mdb.AuditLog{
+		EventType:      string(log.EventDataExport),
+		OperatorID:     operator,
+		IPAddress:      ip,
+		UserAgent:      userAgent,
+		RequestData:    details,
+		ResponseStatus: 200,
+		Timestamp:      time.Now().Unix(),
+	}
+	if exportErr != nil {
+		auditLog.ResponseStatus = 500
+		auditLog.ErrorMessage = truncate(exportErr.Error(), 500)
+	}
+	_ = data.CreateAuditLog(auditLog)
+}
+
+func deductionStatusText(status int) string {
+	switch status {
+	case mdb.DeductionStatusProcessing:
+		return "处理中"
+	case mdb.DeductionStatusSuccess:
+		return "成功"
+	case mdb.DeductionStatusFailed:
+		return "失败"
+	}
+	return "未知"
+}
+
+func withdrawalStatusText(status int) string {
+	switch status {
+	case mdb.WithdrawalStatusPending:
+		return "待审核"
+	case mdb.WithdrawalStatusApproved:
+		return "转账中"
+	case mdb.WithdrawalStatusCompleted:
+		return "已完成"
+	case mdb.WithdrawalStatusRejected:
+		return "已拒绝"
+	}
+	return "未知"
+}
+
+func formatUnix(ts int64) string {
+	if ts <= 0 {
+		return ""
+	}
+	return time.Unix(ts, 0).Format("2006-01-02 15:04:05")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/shopspring/decimal"
+)
+
+// maxFeePercent 比例上限，防止误配
+var maxFeePercent = decimal.NewFromInt(100)
+
+// GetEffectiveFeeSchedule 商家某业务生效的手续费方案：商家方案优先，其次全局方案，均未配置时返回 nil
+func GetEffectiveFeeSchedule(merchantID uint64, bizType string) (*mdb.FeeSchedule, error) {
+	if merchantID > 0 {
+		schedule, err := data.GetFeeSchedule(merchantID, bizType)
+		if err != nil {
+			return nil, err
+		}
+		if schedule.ID > 0 {
+			return schedule, nil
+		}
+	}
+	schedule, err := data.GetFeeSchedule(0, bizType)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.ID == 0 {
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+// CalculateFee 按生效方案计算手续费，未配置或停用时为 0
+func CalculateFee(merchantID uint64, bizType string, amount decimal.Decimal) (decimal.Decimal, error) {
+	schedule, err := GetEffectiveFeeSchedule(merchantID, bizType)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return schedule.Calculate(amount), nil
+}
+
+// GetMerchantFeeSchedules 商家各业务生效的手续费方案
+func GetMerchantFeeSchedules(merchantID uint64) ([]mdb.FeeSchedule, error) {
+	list := make([]mdb.FeeSchedule, 0, len(mdb.FeeBizTypes))
+	for _, bizType := range mdb.FeeBizTypes {
+		schedule, err := GetEffectiveFeeSchedule(merchantID, bizType)
+		if err != nil {
+			return nil, err
+		}
+		if schedule != nil && schedule.Status == mdb.FeeScheduleStatusEnable {
+			list = append(list, *schedule)
+		}
+	}
+	return list, nil
+}
+
+// ListFeeSchedules 管理后台手续费方案列表
+func ListFeeSchedules(merchantID *uint64) ([]mdb.FeeSchedule, error) {
+	return data.GetFeeSchedules(merchantID)
+}
+
+// SaveFeeSchedule 按商家和业务类型新增或更新手续费方案
+func SaveFeeSchedule(req *request.FeeScheduleRequest) (*mdb.FeeSchedule, error) {
+	if !mdb.IsFeeBizType(req.BizType) {
+		return nil, errors.New("不支持的业务类型")
+	}
+	if req.Percent.IsNegative() || req.Percent.GreaterThan(maxFeePercent) {
+		return nil, errors.New("手续费比例须在 0-100 之间")
+	}
+	if req.FixedAmount.IsNegative() {
+		return nil, errors.New("固定手续费不能为负数")
+	}
+	if req.MerchantID > 0 {
+		merchant, err := data.GetMerchantByID(req.MerchantID)
+		if err != nil || merchant.ID == 0 {
+			return nil, errors.New("商家不存在")
+		}
+	}
+	schedule, err := data.GetFeeSchedule(req.MerchantID, req.BizType)
+	if err != nil {
+		return nil, err
+	}
+	schedule.MerchantID = req.MerchantID
+	schedule.BizType = req.BizType
+	schedule.Percent = req.Percent
+	schedule.FixedAmount = req.FixedAmount
+	schedule.Remark = req.Remark
+	schedule.Status = mdb.FeeScheduleStatusEnable
+	if req.Status == mdb.FeeScheduleStatusDisable {
+		schedule.Status = mdb.FeeScheduleStatusDisable
+	}
+	if err = data.SaveFeeSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteFeeSchedule 删除手续费方案
+func DeleteFeeSchedule(id uint64) error {
+	schedule, err := data.GetFeeScheduleById(id)
+	if err != nil {
+		return err
+	}
+	if schedule.ID == 0 {
+		return errors.New("手续费方案不存在")
+	}
+	return data.DeleteFeeSchedule(id)
+}
+
+// GetMerchantFeeStatement 商家对账单：各业务的总额、手续费与净额
+func GetMerchantFeeStatement(merchantID uint64, start, end time.Time) ([]data.FeeStatementLine, error) {
+	return data.GetMerchantFeeStatement(merchantID, start, end)
+}
+
+// deductionFee 授权扣款手续费，收款钱包未关联商家时不收取
+func deductionFee(merchantWallet string, amount decimal.Decimal) (decimal.Decimal, error) {
+	merchantID, _ := data.GetMerchantIDByWallet(merchantWallet)
+	if merchantID == 0 {
+		return decimal.Zero, nil
+	}
+	return CalculateFee(merchantID, mdb.FeeBizDeduction, amount)
+}
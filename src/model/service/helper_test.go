@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/util/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestDB 内存数据库，只建指定的表，并替换 dao.Mdb，测试结束后还原
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// 内存库每个连接各自独立，限制为单连接
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(models...))
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	setForTest(t, &dao.Mdb, db)
+	if log.Sugar == nil {
+		setForTest(t, &log.Sugar, zap.NewNop().Sugar())
+	}
+	return db
+}
+
+// newTestRedis 内存 redis，并替换 dao.Rdb，测试结束后还原
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	setForTest(t, &dao.Rdb, client)
+	return mr
+}
+
+// setForTest 替换包级变量，测试结束后还原
+func setForTest[T any](t *testing.T, target *T, value T) {
+	original := *target
+	*target = value
+	t.Cleanup(func() { *target = original })
+}
+
+// setConfigForTest 修改配置项，测试结束后还原
+func setConfigForTest(t *testing.T, key string, value interface{}) {
+	original := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() { viper.Set(key, original) })
+}
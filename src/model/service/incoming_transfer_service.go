@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+)
+
+// recordIncomingTransfer 登记监听到的入账转账，已登记的直接返回
+func recordIncomingTransfer(req *request.OrderProcessingRequest) (*mdb.IncomingTransfer, error) {
+	incoming, err := data.GetIncomingTransferByTxHash(dao.Mdb, req.BlockTransactionId, req.Token, req.LogIndex)
+	if err != nil {
+		return nil, err
+	}
+	if incoming.ID > 0 {
+		return incoming, nil
+	}
+	incoming = &mdb.IncomingTransfer{
+		Chain:          req.Chain,
+		TokenSymbol:    chain.NormalizeTokenSymbol(req.TokenSymbol),
+		TxHash:         req.BlockTransactionId,
+		ToAddress:      req.Token,
+		LogIndex:       req.LogIndex,
+		FromAddress:    req.FromAddress,
+		Amount:         req.Amount,
+		BlockTimestamp: req.BlockTimestamp,
+		BlockNumber:    req.BlockNumber,
+		MatchStatus:    mdb.IncomingTransferUnmatched,
+	}
+	if err = data.CreateIncomingTransfer(incoming); err != nil {
+		return nil, err
+	}
+	return incoming, nil
+}
+
+// ListIncomingTransfers 分页查询入账转账
+func ListIncomingTransfers(filter *data.ListFilter, q *page.Query) (*data.ListResult[mdb.IncomingTransfer], error) {
+	return data.ListIncomingTransfers(filter, q)
+}
+
+// AttachIncomingTransfer 管理员将未匹配的入账转账手动关联到订单，入账后按正常流程回调
+func AttachIncomingTransfer(id uint64, tradeId, operator string) (*mdb.Orders, error) {
+	tx := dao.Mdb.Begin()
+	incoming, err := data.GetIncomingTransferByIdWithTransaction(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if incoming.ID <= 0 {
+		tx.Rollback()
+		return nil, errors.New("入账转账不存在")
+	}
+	if incoming.MatchStatus != mdb.IncomingTransferUnmatched {
+		tx.Rollback()
+		return nil, errors.New("该转账已关联订单")
+	}
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, tradeId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if order.ID <= 0 {
+		tx.Rollback()
+		return nil, constant.OrderNotExists
+	}
+	if order.Status != mdb.StatusExpired && order.Status != mdb.StatusWaitPay && order.Status != mdb.StatusPartiallyPaid {
+		tx.Rollback()
+		return nil, errors.New("仅可关联已过期或待支付的订单")
+	}
+	if order.Chain != incoming.Chain || order.TokenSymbol != incoming.TokenSymbol || order.Token != incoming.ToAddress {
+		tx.Rollback()
+		return nil, errors.New("转账的链、代币或收款地址与订单不一致")
+	}
+	exist, err := data.GetOrderTransferByTxHash(tx, incoming.TxHash, incoming.ToAddress, incoming.LogIndex)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if exist.ID > 0 {
+		tx.Rollback()
+		return nil, constant.OrderBlockAlreadyProcess
+	}
+	rows, err := data.AttachIncomingTransferWithTransaction(tx, incoming.ID, order.TradeId, operator)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if rows == 0 {
+		tx.Rollback()
+		return nil, errors.New("该转账已关联订单")
+	}
+	transfer := &mdb.OrderTransfer{
+		TradeId:        order.TradeId,
+		Chain:          order.Chain,
+		TokenSymbol:    order.TokenSymbol,
+		TxHash:         incoming.TxHash,
+		ToAddress:      incoming.ToAddress,
+		LogIndex:       incoming.LogIndex,
+		FromAddress:    incoming.FromAddress,
+		Amount:         incoming.Amount,
+		BlockTimestamp: incoming.BlockTimestamp,
+		BlockNumber:    incoming.BlockNumber,
+		Status:         mdb.TransferStatusConfirmed,
+	}
+	if err = data.CreateOrderTransferWithTransaction(tx, transfer); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = creditOrderTransfer(tx, order, transfer); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	telegram.SendToBot(fmt.Sprintf("<b>📢📢入账转账已手动关联订单</b>\n<pre>交易号：%s</pre>\n<pre>交易哈希：%s</pre>\n<pre>操作人：%s</pre>",
+		order.TradeId, incoming.TxHash, operator))
+	notifyOrderPayment(order)
+	// 过期订单关联后仍未足额，按部分支付通知商户
+	if order.Status == mdb.StatusPartiallyPaid && isOrderExpired(order) {
+		if err = handle.EnqueueOrderCallback(order); err != nil {
+			return order, err
+		}
+	}
+	return order, nil
+}
@@ -19,7 +19,6 @@ import (
 	"github.com/assimon/luuu/util/evm"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/log"
-	"github.com/assimon/luuu/util/math"
 	"github.com/assimon/luuu/util/tron"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/shopspring/decimal"
@@ -27,11 +26,25 @@ import (
 
 var authLock sync.Mutex
 
-// USDT TRC20 合约地址
-const USDT_CONTRACT = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+// authDepletedThreshold 剩余额度不超过该值时视为用尽
+var authDepletedThreshold = decimal.New(1, -2)
+
+// getTronToken 获取 TRON 链上已启用的代币配置
+func getTronToken(tokenSymbol string) (*chain.TokenInfo, error) {
+	token := chain.GetTokenInfo(chain.ChainTron, tokenSymbol)
+	if token == nil {
+		return nil, errors.New("TRON链未启用此代币")
+	}
+	return token, nil
+}
 
 // CreateAuthorization 创建授权请求
-func CreateAuthorization(amountUsdt float64, tableNo, customerName, remark, chainName string) (*AuthorizationResponse, error) {
+func CreateAuthorization(amountUsdt decimal.Decimal, tableNo, customerName, remark, chainName, tokenSymbol string) (*AuthorizationResponse, error) {
+	return createAuthorization(0, amountUsdt, tableNo, customerName, remark, chainName, tokenSymbol)
+}
+
+// createAuthorization 创建授权请求，merchantID 为发起授权的商家（0 为平台），用于钱包选择策略
+func createAuthorization(merchantID uint64, amountUsdt decimal.Decimal, tableNo, customerName, remark, chainName, tokenSymbol string) (*AuthorizationResponse, error) {
 	authLock.Lock()
 	defer authLock.Unlock()
 
@@ -42,6 +55,10 @@ func CreateAuthorization(amountUsdt float64, tableNo, customerName, remark, chai
 	if !chain.IsSupported(chainName) {
 		return nil, errors.New("不支持的链")
 	}
+	tokenSymbol = chain.NormalizeTokenSymbol(tokenSymbol)
+	if !chain.IsTokenEnabled(chainName, tokenSymbol) {
+		return nil, errors.New("该链未启用此代币")
+	}
 
 	// 获取商家钱包
 	wallets, err := data.GetAvailableWalletAddressByChain(chainName)
@@ -52,10 +69,15 @@ func CreateAuthorization(amountUsdt float64, tableNo, customerName, remark, chai
 	if len(wallets) == 0 {
 		return nil, errors.New("无可用收款钱包（缺少私钥配置）")
 	}
-	wallet := wallets[rand.Intn(len(wallets))]
+	wallets, strategy := orderWalletsByStrategy(walletPurposeAuthorize, merchantID, chainName, wallets)
+	if len(wallets) == 0 {
+		return nil, errors.New("无可用收款钱包")
+	}
+	wallet := wallets[0]
 
 	// 生成授权编号和密码
 	authNo := generateAuthNo()
+	recordWalletSelection(walletPurposeAuthorize, merchantID, chainName, strategy, authNo, wallets, wallet.Token)
 	password := generateAuthPassword()
 	expireTime := time.Now().Add(24 * time.Hour).Unix() // 授权24小时有效
 
@@ -67,6 +89,7 @@ func CreateAuthorization(amountUsdt float64, tableNo, customerName, remark, chai
 		RemainingUsdt:  amountUsdt,
 		Status:         mdb.AuthorizeStatusPending,
 		Chain:          chainName,
+		TokenSymbol:    tokenSymbol,
 		TableNo:        tableNo,
 		CustomerName:   customerName,
 		ExpireTime:     expireTime,
@@ -88,6 +111,7 @@ func CreateAuthorization(amountUsdt float64, tableNo, customerName, remark, chai
 		ExpireTime:     expireTime,
 		AuthUrl:        authUrl,
 		Chain:          chainName,
+		TokenSymbol:    tokenSymbol,
 	}, nil
 }
 
@@ -120,11 +144,12 @@ func ConfirmAuthorization(authNo, customerWallet, txHash string) error {
 <b>✅ 新授权成功!</b>
 <pre>密码凭证: %s</pre>
 <pre>客户钱包: %s</pre>
-<pre>授权额度: %.2f USDT</pre>
+<pre>授权额度: %s USDT</pre>
 <pre>桌号: %s</pre>
 `
-	msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt, auth.TableNo)
+	msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt.StringFixed(2), auth.TableNo)
 	telegram.SendToBot(msg)
+	publishAuthorizeEvent(auth, mdb.WebhookEventAuthActivated, auth)
 
 	return nil
 }
@@ -152,12 +177,12 @@ func ConfirmAuthorizationAuto(authNo, customerWallet string) (*AuthorizationAuto
 		if !tron.IsValidTronAddress(customerWallet) {
 			return nil, errors.New("客户钱包地址无效")
 		}
-		allowance, err := getTrc20Allowance(customerWallet, auth.MerchantWallet)
+		allowance, err := getTrc20Allowance(auth.TokenSymbol, customerWallet, auth.MerchantWallet)
 		if err != nil {
 			return nil, err
 		}
 
-		if allowance < auth.AuthorizedUsdt {
+		if allowance.LessThan(auth.AuthorizedUsdt) {
 			return &AuthorizationAutoStatus{
 				Status:         "pending",
 				AuthorizedUsdt: auth.AuthorizedUsdt,
@@ -178,11 +203,12 @@ func ConfirmAuthorizationAuto(authNo, customerWallet string) (*AuthorizationAuto
 <b>✅ 新授权成功!</b>
 <pre>密码凭证: %s</pre>
 <pre>客户钱包: %s</pre>
-<pre>授权额度: %.2f USDT</pre>
+<pre>授权额度: %s USDT</pre>
 <pre>桌号: %s</pre>
 `
-		msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt, auth.TableNo)
+		msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt.StringFixed(2), auth.TableNo)
 		telegram.SendToBot(msg)
+		publishAuthorizeEvent(auth, mdb.WebhookEventAuthActivated, auth)
 
 		return &AuthorizationAutoStatus{
 			Status:         "active",
@@ -195,12 +221,12 @@ func ConfirmAuthorizationAuto(authNo, customerWallet string) (*AuthorizationAuto
 		return nil, errors.New("不支持的链")
 	}
 
-	allowance, err := evm.GetAllowance(auth.Chain, customerWallet, auth.MerchantWallet)
+	allowance, err := evm.GetAllowance(auth.Chain, auth.TokenSymbol, customerWallet, auth.MerchantWallet)
 	if err != nil {
 		return nil, err
 	}
 
-	if allowance < auth.AuthorizedUsdt {
+	if allowance.LessThan(auth.AuthorizedUsdt) {
 		return &AuthorizationAutoStatus{
 			Status:         "pending",
 			AuthorizedUsdt: auth.AuthorizedUsdt,
@@ -221,11 +247,12 @@ func ConfirmAuthorizationAuto(authNo, customerWallet string) (*AuthorizationAuto
 <b>✅ 新授权成功!</b>
 <pre>密码凭证: %s</pre>
 <pre>客户钱包: %s</pre>
-<pre>授权额度: %.2f USDT</pre>
+<pre>授权额度: %s USDT</pre>
 <pre>桌号: %s</pre>
 `
-	msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt, auth.TableNo)
+	msg := fmt.Sprintf(msgTpl, auth.Password, customerWallet, auth.AuthorizedUsdt.StringFixed(2), auth.TableNo)
 	telegram.SendToBot(msg)
+	publishAuthorizeEvent(auth, mdb.WebhookEventAuthActivated, auth)
 
 	return &AuthorizationAutoStatus{
 		Status:         "active",
@@ -235,7 +262,7 @@ func ConfirmAuthorizationAuto(authNo, customerWallet string) (*AuthorizationAuto
 }
 
 // DeductFromAuthorization 从授权中扣款
-func DeductFromAuthorization(password string, amountCny float64, productInfo, operatorID string) (*DeductionResponse, error) {
+func DeductFromAuthorization(password string, amountCny decimal.Decimal, productInfo, operatorID string) (*DeductionResponse, error) {
 	authLock.Lock()
 	defer authLock.Unlock()
 
@@ -246,14 +273,17 @@ func DeductFromAuthorization(password string, amountCny float64, productInfo, op
 	}
 
 	// 计算 USDT 金额
-	rate := config.GetUsdtRate()
-	decimalAmount := decimal.NewFromFloat(amountCny)
-	decimalRate := decimal.NewFromFloat(rate)
-	amountUsdt := math.MustParsePrecFloat64(decimalAmount.Div(decimalRate).InexactFloat64(), 4)
+	rate := decimal.NewFromFloat(config.GetUsdtRate())
+	amountUsdt := amountCny.Div(rate).Round(4)
 
 	// 检查余额
-	if auth.RemainingUsdt < amountUsdt {
-		return nil, fmt.Errorf("授权余额不足，剩余 %.2f USDT，需要 %.4f USDT", auth.RemainingUsdt, amountUsdt)
+	if auth.RemainingUsdt.LessThan(amountUsdt) {
+		return nil, fmt.Errorf("授权余额不足，剩余 %s USDT，需要 %s USDT", auth.RemainingUsdt.StringFixed(2), amountUsdt.StringFixed(4))
+	}
+
+	fee, err := deductionFee(auth.MerchantWallet, amountUsdt)
+	if err != nil {
+		return nil, err
 	}
 
 	// 生成扣款单号
@@ -267,6 +297,7 @@ func DeductFromAuthorization(password string, amountCny float64, productInfo, op
 		Password:    password,
 		AmountUsdt:  amountUsdt,
 		AmountCny:   amountCny,
+		FeeAmount:   fee,
 		Status:      1, // 处理中
 		ProductInfo: productInfo,
 		OperatorID:  operatorID,
@@ -285,7 +316,7 @@ func DeductFromAuthorization(password string, amountCny float64, productInfo, op
 		Password:       password,
 		AmountCny:      amountCny,
 		AmountUsdt:     amountUsdt,
-		RemainingUsdt:  auth.RemainingUsdt - amountUsdt,
+		RemainingUsdt:  auth.RemainingUsdt.Sub(amountUsdt),
 		Status:         "processing",
 		CustomerWallet: auth.CustomerWallet,
 	}, nil
@@ -303,6 +334,9 @@ func executeTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 	privateKey := config.GetMerchantPrivateKeyForWallet(auth.MerchantWallet)
 	if privateKey == "" {
 		data.UpdateDeductionFailed(deduct.DeductNo, "商家私钥未配置")
+		deduct.Status = mdb.DeductionStatusFailed
+		deduct.FailReason = "商家私钥未配置"
+		publishAuthorizeEvent(auth, mdb.WebhookEventDeductionFailed, deduct)
 		return
 	}
 
@@ -315,6 +349,7 @@ func executeTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 
 	// 调用波场 API 执行 transferFrom
 	txHash, err := tronTransferFrom(
+		auth.TokenSymbol,
 		privateKey,
 		auth.CustomerWallet,
 		targetWallet,
@@ -328,11 +363,14 @@ func executeTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 		msgTpl := `
 <b>❌ 扣款失败!</b>
 <pre>密码: %s</pre>
-<pre>金额: %.4f USDT</pre>
+<pre>金额: %s USDT</pre>
 <pre>原因: %s</pre>
 `
-		msg := fmt.Sprintf(msgTpl, deduct.Password, deduct.AmountUsdt, err.Error())
+		msg := fmt.Sprintf(msgTpl, deduct.Password, deduct.AmountUsdt.StringFixed(4), err.Error())
 		telegram.SendToBot(msg)
+		deduct.Status = mdb.DeductionStatusFailed
+		deduct.FailReason = err.Error()
+		publishAuthorizeEvent(auth, mdb.WebhookEventDeductionFailed, deduct)
 		return
 	}
 
@@ -346,15 +384,19 @@ func executeTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 		tx.Rollback()
 		return
 	}
-	// 累加商家余额
+	// 扣款记入商家余额
 	merchantID, _ := data.GetMerchantIDByWallet(auth.MerchantWallet)
 	if merchantID > 0 {
-		_ = data.AddMerchantBalance(tx, merchantID, deduct.AmountUsdt)
+		if err := postDeductionLedger(tx, merchantID, deduct); err != nil {
+			tx.Rollback()
+			log.Sugar.Errorf("[deduct] 记账失败, deductNo=%s, err=%v", deduct.DeductNo, err)
+			return
+		}
 	}
 	tx.Commit()
 
 	// 检查是否额度用尽
-	if auth.RemainingUsdt-deduct.AmountUsdt <= 0.01 {
+	if auth.RemainingUsdt.Sub(deduct.AmountUsdt).LessThanOrEqual(authDepletedThreshold) {
 		data.UpdateAuthorizeDepleted(uint64(auth.ID))
 	}
 
@@ -362,25 +404,31 @@ func executeTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 	msgTpl := `
 <b>💰 扣款成功!</b>
 <pre>密码: %s</pre>
-<pre>金额: ¥%.2f (%.4f USDT)</pre>
+<pre>金额: ¥%s (%s USDT)</pre>
 <pre>消费: %s</pre>
-<pre>剩余: %.2f USDT</pre>
+<pre>剩余: %s USDT</pre>
 <pre>TxHash: %s</pre>
 `
 	msg := fmt.Sprintf(msgTpl,
 		deduct.Password,
-		deduct.AmountCny,
-		deduct.AmountUsdt,
+		deduct.AmountCny.StringFixed(2),
+		deduct.AmountUsdt.StringFixed(4),
 		deduct.ProductInfo,
-		auth.RemainingUsdt-deduct.AmountUsdt,
+		auth.RemainingUsdt.Sub(deduct.AmountUsdt).StringFixed(2),
 		txHash)
 	telegram.SendToBot(msg)
+	deduct.Status = mdb.DeductionStatusSuccess
+	deduct.TxHash = txHash
+	publishAuthorizeEvent(auth, mdb.WebhookEventDeductionSucceeded, deduct)
 }
 
 func executeEvmTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 	privateKey := config.GetMerchantPrivateKeyForWallet(auth.MerchantWallet)
 	if privateKey == "" {
 		data.UpdateDeductionFailed(deduct.DeductNo, "商家私钥未配置")
+		deduct.Status = mdb.DeductionStatusFailed
+		deduct.FailReason = "商家私钥未配置"
+		publishAuthorizeEvent(auth, mdb.WebhookEventDeductionFailed, deduct)
 		return
 	}
 
@@ -391,17 +439,20 @@ func executeEvmTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 		evmTarget = auth.MerchantWallet
 	}
 
-	txHash, err := evm.TransferFrom(auth.Chain, privateKey, auth.CustomerWallet, evmTarget, deduct.AmountUsdt)
+	txHash, err := evm.TransferFrom(auth.Chain, auth.TokenSymbol, privateKey, auth.CustomerWallet, evmTarget, deduct.AmountUsdt)
 	if err != nil {
 		data.UpdateDeductionFailed(deduct.DeductNo, err.Error())
 		msgTpl := `
 <b>❌ 扣款失败!</b>
 <pre>密码: %s</pre>
-<pre>金额: %.4f USDT</pre>
+<pre>金额: %s USDT</pre>
 <pre>原因: %s</pre>
 `
-		msg := fmt.Sprintf(msgTpl, deduct.Password, deduct.AmountUsdt, err.Error())
+		msg := fmt.Sprintf(msgTpl, deduct.Password, deduct.AmountUsdt.StringFixed(4), err.Error())
 		telegram.SendToBot(msg)
+		deduct.Status = mdb.DeductionStatusFailed
+		deduct.FailReason = err.Error()
+		publishAuthorizeEvent(auth, mdb.WebhookEventDeductionFailed, deduct)
 		return
 	}
 
@@ -414,33 +465,40 @@ func executeEvmTransferFrom(auth *mdb.KtvAuthorize, deduct *mdb.KtvDeduction) {
 		tx.Rollback()
 		return
 	}
-	// 累加商家余额
+	// 扣款记入商家余额
 	merchantID, _ := data.GetMerchantIDByWallet(auth.MerchantWallet)
 	if merchantID > 0 {
-		_ = data.AddMerchantBalance(tx, merchantID, deduct.AmountUsdt)
+		if err := postDeductionLedger(tx, merchantID, deduct); err != nil {
+			tx.Rollback()
+			log.Sugar.Errorf("[deduct] 记账失败, deductNo=%s, err=%v", deduct.DeductNo, err)
+			return
+		}
 	}
 	tx.Commit()
 
-	if auth.RemainingUsdt-deduct.AmountUsdt <= 0.01 {
+	if auth.RemainingUsdt.Sub(deduct.AmountUsdt).LessThanOrEqual(authDepletedThreshold) {
 		data.UpdateAuthorizeDepleted(uint64(auth.ID))
 	}
 
 	msgTpl := `
 <b>💰 扣款成功!</b>
 <pre>密码: %s</pre>
-<pre>金额: ¥%.2f (%.4f USDT)</pre>
+<pre>金额: ¥%s (%s USDT)</pre>
 <pre>消费: %s</pre>
-<pre>剩余: %.2f USDT</pre>
+<pre>剩余: %s USDT</pre>
 <pre>TxHash: %s</pre>
 `
 	msg := fmt.Sprintf(msgTpl,
 		deduct.Password,
-		deduct.AmountCny,
-		deduct.AmountUsdt,
+		deduct.AmountCny.StringFixed(2),
+		deduct.AmountUsdt.StringFixed(4),
 		deduct.ProductInfo,
-		auth.RemainingUsdt-deduct.AmountUsdt,
+		auth.RemainingUsdt.Sub(deduct.AmountUsdt).StringFixed(2),
 		txHash)
 	telegram.SendToBot(msg)
+	deduct.Status = mdb.DeductionStatusSuccess
+	deduct.TxHash = txHash
+	publishAuthorizeEvent(auth, mdb.WebhookEventDeductionSucceeded, deduct)
 }
 
 func filterWalletsWithPrivateKey(chainName string, wallets []mdb.WalletAddress) []mdb.WalletAddress {
@@ -462,11 +520,15 @@ func filterWalletsWithPrivateKey(chainName string, wallets []mdb.WalletAddress)
 
 // tronTransferFrom 调用波场 transferFrom
 // 安全修复: 私钥仅在本地签名，不再发送到第三方 API
-func tronTransferFrom(privateKeyHex, from, to string, amount float64) (string, error) {
+func tronTransferFrom(tokenSymbol, privateKeyHex, from, to string, amount decimal.Decimal) (string, error) {
 	client := http_client.GetHttpClient()
+	token, err := getTronToken(tokenSymbol)
+	if err != nil {
+		return "", err
+	}
 
-	// 将 USDT 金额转换为最小单位（6位小数）
-	amountSun := int64(amount * 1e6)
+	// 将代币金额转换为最小单位
+	amountSun := evm.ToBaseUnits(amount, token.Decimals)
 
 	// 1. 构建 transferFrom 参数
 	// function transferFrom(address from, address to, uint256 value)
@@ -487,7 +549,7 @@ func tronTransferFrom(privateKeyHex, from, to string, amount float64) (string, e
 	// 2. 调用 triggersmartcontract（仅构建未签名交易，不发送私钥）
 	triggerBody := map[string]interface{}{
 		"owner_address":     to, // 商家地址（有授权的地址）
-		"contract_address":  USDT_CONTRACT,
+		"contract_address":  token.Contract,
 		"function_selector": "transferFrom(address,address,uint256)",
 		"parameter":         parameter,
 		"fee_limit":         30000000, // 30 TRX
@@ -550,6 +612,81 @@ func tronTransferFrom(privateKeyHex, from, to string, amount float64) (string, e
 	return txID, nil
 }
 
+// tronTransfer 调用波场 transfer，从签名者钱包直接转账到目标地址
+func tronTransfer(tokenSymbol, privateKeyHex, to string, amount decimal.Decimal) (string, error) {
+	client := http_client.GetHttpClient()
+	token, err := getTronToken(tokenSymbol)
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := tron.PrivateKeyToAddress(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+	toHex, err := tron.AddressToHex(to)
+	if err != nil {
+		return "", err
+	}
+	// 将代币金额转换为最小单位
+	amountSun := evm.ToBaseUnits(amount, token.Decimals)
+	parameter := toHex + fmt.Sprintf("%064x", amountSun)
+
+	triggerBody := map[string]interface{}{
+		"owner_address":     owner,
+		"contract_address":  token.Contract,
+		"function_selector": "transfer(address,uint256)",
+		"parameter":         parameter,
+		"fee_limit":         30000000, // 30 TRX
+		"call_value":        0,
+		"visible":           true,
+	}
+
+	var resp map[string]interface{}
+	_, err = client.R().
+		SetBody(triggerBody).
+		SetResult(&resp).
+		Post("https://api.trongrid.io/wallet/triggersmartcontract")
+	if err != nil {
+		return "", fmt.Errorf("构建交易失败: %v", err)
+	}
+	if result, ok := resp["result"].(map[string]interface{}); ok {
+		if result["result"] == false {
+			if msg, ok := result["message"].(string); ok {
+				decoded, _ := hex.DecodeString(msg)
+				return "", fmt.Errorf("交易失败: %s", string(decoded))
+			}
+		}
+	}
+	transaction, ok := resp["transaction"].(map[string]interface{})
+	if !ok {
+		return "", errors.New("获取交易数据失败")
+	}
+
+	txID, signature, err := tronLocalSign(transaction, privateKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("本地签名失败: %v", err)
+	}
+	transaction["signature"] = []string{signature}
+
+	var broadcastResp map[string]interface{}
+	_, err = client.R().
+		SetBody(transaction).
+		SetResult(&broadcastResp).
+		Post("https://api.trongrid.io/wallet/broadcasttransaction")
+	if err != nil {
+		return "", fmt.Errorf("广播交易失败: %v", err)
+	}
+	if result, ok := broadcastResp["result"].(bool); !ok || !result {
+		if msg, ok := broadcastResp["message"].(string); ok {
+			return "", fmt.Errorf("广播失败: %s", msg)
+		}
+		return "", errors.New("广播交易失败")
+	}
+
+	return txID, nil
+}
+
 // tronLocalSign 在本地对 TRON 交易进行签名
 // 使用 secp256k1 + SHA256 完成签名，私钥不离开本地内存
 func tronLocalSign(transaction map[string]interface{}, privateKeyHex string) (string, string, error) {
@@ -609,20 +746,24 @@ func tronLocalSignRawData(rawDataHex string, privateKeyHex string) (string, stri
 	return txID, hex.EncodeToString(sig), nil
 }
 
-func getTrc20Allowance(owner, spender string) (float64, error) {
+func getTrc20Allowance(tokenSymbol, owner, spender string) (decimal.Decimal, error) {
+	token, err := getTronToken(tokenSymbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
 	ownerHex, err := tron.AddressToHex(owner)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 	spenderHex, err := tron.AddressToHex(spender)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	parameter := ownerHex + spenderHex
 	triggerBody := map[string]interface{}{
 		"owner_address":     owner,
-		"contract_address":  USDT_CONTRACT,
+		"contract_address":  token.Contract,
 		"function_selector": "allowance(address,address)",
 		"parameter":         parameter,
 		"call_value":        0,
@@ -636,32 +777,31 @@ func getTrc20Allowance(owner, spender string) (float64, error) {
 		SetResult(&resp).
 		Post("https://api.trongrid.io/wallet/triggersmartcontract")
 	if err != nil {
-		return 0, fmt.Errorf("查询授权失败: %v", err)
+		return decimal.Zero, fmt.Errorf("查询授权失败: %v", err)
 	}
 
 	if result, ok := resp["result"].(map[string]interface{}); ok {
 		if result["result"] == false {
 			if msg, ok := result["message"].(string); ok {
 				decoded, _ := hex.DecodeString(msg)
-				return 0, fmt.Errorf("查询授权失败: %s", string(decoded))
+				return decimal.Zero, fmt.Errorf("查询授权失败: %s", string(decoded))
 			}
 		}
 	}
 
 	constantResult, ok := resp["constant_result"].([]interface{})
 	if !ok || len(constantResult) == 0 {
-		return 0, errors.New("查询授权失败: 无结果")
+		return decimal.Zero, errors.New("查询授权失败: 无结果")
 	}
 
 	hexStr, ok := constantResult[0].(string)
 	if !ok || hexStr == "" {
-		return 0, errors.New("查询授权失败: 结果格式错误")
+		return decimal.Zero, errors.New("查询授权失败: 结果格式错误")
 	}
 
 	val := new(big.Int)
 	val.SetString(hexStr, 16)
-	amountSun := val.Int64()
-	return float64(amountSun) / 1e6, nil
+	return evm.ToDecimalAmount(val, token.Decimals), nil
 }
 
 // GetAuthorizationInfo 获取授权信息
@@ -687,31 +827,32 @@ func GetActiveAuthorizations() ([]mdb.KtvAuthorize, error) {
 // ==================== 响应结构体 ====================
 
 type AuthorizationResponse struct {
-	AuthNo         string  `json:"auth_no"`
-	Password       string  `json:"password,omitempty"`
-	AmountUsdt     float64 `json:"amount_usdt"`
-	MerchantWallet string  `json:"merchant_wallet"`
-	ExpireTime     int64   `json:"expire_time"`
-	AuthUrl        string  `json:"auth_url"`
-	Chain          string  `json:"chain"`
-	QRCodeContent  string  `json:"qr_code_content,omitempty"`  // 二维码内容（可选）
-	QRCodeFormat   string  `json:"qr_code_format,omitempty"`   // 二维码格式（可选）
+	AuthNo         string          `json:"auth_no"`
+	Password       string          `json:"password,omitempty"`
+	AmountUsdt     decimal.Decimal `json:"amount_usdt"`
+	MerchantWallet string          `json:"merchant_wallet"`
+	ExpireTime     int64           `json:"expire_time"`
+	AuthUrl        string          `json:"auth_url"`
+	Chain          string          `json:"chain"`
+	TokenSymbol    string          `json:"token_symbol"`
+	QRCodeContent  string          `json:"qr_code_content,omitempty"` // 二维码内容（可选）
+	QRCodeFormat   string          `json:"qr_code_format,omitempty"`  // 二维码格式（可选）
 }
 
 type DeductionResponse struct {
-	DeductNo       string  `json:"deduct_no"`
-	Password       string  `json:"password"`
-	AmountCny      float64 `json:"amount_cny"`
-	AmountUsdt     float64 `json:"amount_usdt"`
-	RemainingUsdt  float64 `json:"remaining_usdt"`
-	Status         string  `json:"status"`
-	CustomerWallet string  `json:"customer_wallet"`
+	DeductNo       string          `json:"deduct_no"`
+	Password       string          `json:"password"`
+	AmountCny      decimal.Decimal `json:"amount_cny"`
+	AmountUsdt     decimal.Decimal `json:"amount_usdt"`
+	RemainingUsdt  decimal.Decimal `json:"remaining_usdt"`
+	Status         string          `json:"status"`
+	CustomerWallet string          `json:"customer_wallet"`
 }
 
 type AuthorizationAutoStatus struct {
-	Status         string  `json:"status"`
-	AuthorizedUsdt float64 `json:"authorized_usdt"`
-	AllowanceUsdt  float64 `json:"allowance_usdt"`
+	Status         string          `json:"status"`
+	AuthorizedUsdt decimal.Decimal `json:"authorized_usdt"`
+	AllowanceUsdt  decimal.Decimal `json:"allowance_usdt"`
 }
 
 // ==================== 工具函数 ====================
@@ -1,13 +1,22 @@
 package service
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/mq"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/hibiken/asynq"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestGenerateAuthNo 测试授权编号生成
@@ -19,13 +28,10 @@ func TestGenerateAuthNo(t *testing.T) {
 	assert.Equal(t, 'A', rune(authNo[0]))
 	assert.Equal(t, 18, len(authNo)) // A(1) + 时间(14) + 随机(3)
 
-	// 验证唯一性
-	authNo2 := generateAuthNo()
+	// 验证唯一性（大概率不同，随机数可能重复）
 	time.Sleep(1 * time.Millisecond)
-	authNo3 := generateAuthNo()
-
-	// 大概率不同（随机数可能重复，但时间不同）
-	assert.NotEqual(t, authNo, authNo3)
+	authNo2 := generateAuthNo()
+	assert.NotEqual(t, authNo, authNo2)
 }
 
 // TestGenerateAuthPassword 测试密码生成
@@ -59,28 +65,20 @@ func TestGenerateDeductNo(t *testing.T) {
 	assert.Equal(t, 18, len(deductNo))
 
 	// 验证唯一性
-	deductNo2 := generateDeductNo()
 	time.Sleep(1 * time.Millisecond)
-	deductNo3 := generateDeductNo()
-
-	assert.NotEqual(t, deductNo, deductNo3)
+	deductNo2 := generateDeductNo()
+	assert.NotEqual(t, deductNo, deductNo2)
 }
 
 // TestFilterWalletsWithPrivateKey 测试私钥过滤
 func TestFilterWalletsWithPrivateKey(t *testing.T) {
-	// Mock配置
-	config.GetMerchantPrivateKeyForWallet = func(wallet string) string {
-		if wallet == "TXyZ123" {
-			return "0xprivatekey1"
-		}
-		if wallet == "TXyZ456" {
-			return "0xprivatekey2"
-		}
-		return ""
-	}
-	config.HasMerchantPrivateKeyMap = func() bool {
-		return true
+	// Mock配置：按钱包配置私钥，未配置全局私钥
+	originalKeys := config.MerchantPrivateKeyMap
+	config.MerchantPrivateKeyMap = map[string]string{
+		"txyz123": "0xprivatekey1",
+		"txyz456": "0xprivatekey2",
 	}
+	defer func() { config.MerchantPrivateKeyMap = originalKeys }()
 
 	wallets := []mdb.WalletAddress{
 		{Token: "TXyZ123"},
@@ -106,11 +104,7 @@ func TestFilterWalletsWithPrivateKey(t *testing.T) {
 // TestCalculateUsdtAmount 测试人民币转USDT计算
 func TestCalculateUsdtAmount(t *testing.T) {
 	// Mock汇率: 1 USDT = 6.5 CNY
-	originalRate := config.GetUsdtRate
-	config.GetUsdtRate = func() float64 {
-		return 6.5
-	}
-	defer func() { config.GetUsdtRate = originalRate }()
+	setConfigForTest(t, "forced_usdt_rate", 6.5)
 
 	testCases := []struct {
 		cny      float64
@@ -123,7 +117,7 @@ func TestCalculateUsdtAmount(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		usdt := tc.cny / 6.5
+		usdt := tc.cny / config.GetUsdtRate()
 		assert.InDelta(t, tc.expected, usdt, 0.0001)
 	}
 }
@@ -139,11 +133,11 @@ func TestAuthorizationLifecycle(t *testing.T) {
 	defer dao.Mdb.Exec("DELETE FROM ktv_deductions")
 
 	// 1. 创建授权
-	auth, err := CreateAuthorization(100.0, "A01", "张三", "测试授权", "TRON")
+	auth, err := CreateAuthorization(decimal.NewFromInt(100), "A01", "张三", "测试授权", "TRON", "USDT")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, auth.AuthNo)
 	assert.NotEmpty(t, auth.Password)
-	assert.Equal(t, 100.0, auth.AmountUsdt)
+	assert.True(t, auth.AmountUsdt.Equal(decimal.NewFromInt(100)))
 
 	// 2. 查询授权信息（未确认状态）
 	authInfo, err := GetAuthorizationInfo(auth.Password)
@@ -157,10 +151,10 @@ func TestAuthorizationLifecycle(t *testing.T) {
 	authInfo, err = GetAuthorizationInfo(auth.Password)
 	assert.NoError(t, err)
 	assert.Equal(t, mdb.AuthorizeStatusActive, authInfo.Status)
-	assert.Equal(t, 100.0, authInfo.RemainingUsdt)
+	assert.True(t, authInfo.RemainingUsdt.Equal(decimal.NewFromInt(100)))
 
 	// 5. 扣款
-	deduct, err := DeductFromAuthorization(auth.Password, 50.0, "啤酒2瓶", "waiter_001")
+	deduct, err := DeductFromAuthorization(auth.Password, decimal.NewFromInt(50), "啤酒2瓶", "waiter_001")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, deduct.DeductNo)
 	assert.Equal(t, "processing", deduct.Status)
@@ -176,27 +170,51 @@ func TestAuthorizationLifecycle(t *testing.T) {
 	// 注意: 扣款是异步的，used_usdt可能还未更新
 }
 
-// TestOrderCreationFlow 测试订单创建流程
+// TestOrderCreationFlow 测试订单创建流程：金额校验与不指定链的下单
 func TestOrderCreationFlow(t *testing.T) {
-	t.Skip("需要数据库和Redis连接，跳过单元测试")
-
-	// 初始化
-	dao.DBInit()
-	dao.RedisInit()
+	db := newTestDB(t, &mdb.Orders{}, &mdb.WalletAddress{})
+	mr := newTestRedis(t)
+	setConfigForTest(t, "forced_usdt_rate", 6.5)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	setForTest(t, &mq.MClient, client)
+	chain.InitRegistry()
+	require.NoError(t, db.Create(&mdb.WalletAddress{Token: "TWallet1", Chain: chain.ChainTron, Status: mdb.TokenStatusEnable}).Error)
 
 	// 测试用例
 	testCases := []struct {
-		amount      float64
-		shouldError bool
-		errorMsg    string
+		name     string
+		amount   string
+		wantErr  error
+		wantUsdt string
 	}{
-		{100.0, false, ""},
-		{0.001, true, "支付金额错误"}, // 低于最低金额
-		{-10.0, true, "支付金额错误"}, // 负数
+		{"正常下单", "100", nil, "15.38"},
+		{"低于法币最低金额", "0.001", constant.PayAmountErr, ""},
+		{"负数", "-10", constant.PayAmountErr, ""},
+		{"折合 USDT 低于最低金额", "0.05", constant.PayAmountErr, ""},
 	}
 
-	for _, tc := range testCases {
-		// TODO: 实现订单创建测试
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := CreateTransaction(&request.CreateTransactionRequest{
+				OrderId:     fmt.Sprintf("flow-%d", i),
+				Amount:      decimal.RequireFromString(tc.amount),
+				NotifyUrl:   "https://merchant.example.com/notify",
+				Chain:       chain.ChainAny,
+				TokenSymbol: "USDT",
+			})
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, chain.ChainAny, resp.Chain)
+			assert.Empty(t, resp.Token) // 付款人选择链后才分配钱包
+			assert.True(t, resp.ActualAmount.Equal(decimal.RequireFromString(tc.wantUsdt)), "actual=%s", resp.ActualAmount)
+			order, err := data.GetOrderInfoByTradeId(resp.TradeId)
+			require.NoError(t, err)
+			assert.Equal(t, mdb.StatusWaitPay, order.Status)
+		})
 	}
 }
 
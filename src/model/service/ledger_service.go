@@ -0,0 +1,503 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/page"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrLedgerInsufficient 商家余额不足
+var ErrLedgerInsufficient = errors.New("商家余额不足")
+
+// ErrAlreadyPosted 同一业务单据已记过账，由调用方判断是否属于正常的重复处理
+var ErrAlreadyPosted = errors.New("业务单据已记账")
+
+// platformLedgerNames 平台科目名称与类型
+var platformLedgerNames = map[string][2]string{
+	mdb.LedgerCodeCompanyWallet: {"公司钱包", mdb.LedgerAccountAsset},
+	mdb.LedgerCodePlatformFees:  {"平台手续费收入", mdb.LedgerAccountRevenue},
+	mdb.LedgerCodeAdjustment:    {"期初及调账", mdb.LedgerAccountEquity},
+}
+
+// ledgerLine 凭证分录
+type ledgerLine struct {
+	account   *mdb.LedgerAccount
+	direction string
+	amount    decimal.Decimal
+}
+
+// ledgerPosting 待记账凭证
+type ledgerPosting struct {
+	bizType    string
+	bizNo      string
+	merchantID uint64
+	memo       string
+	operator   string
+	lines      []ledgerLine
+	strict     bool // 商家科目余额不允许为负
+}
+
+// merchantLedgerAccount 商家科目
+func merchantLedgerAccount(merchantID uint64, kind string) *mdb.LedgerAccount {
+	name := "商家可用余额"
+	switch kind {
+	case mdb.LedgerMerchantPending:
+		name = "商家提现在途"
+	case mdb.LedgerMerchantRefunding:
+		name = "商家退款在途"
+	}
+	return &mdb.LedgerAccount{
+		Code:       mdb.MerchantLedgerCode(merchantID, kind),
+		Name:       name,
+		Type:       mdb.LedgerAccountLiability,
+		MerchantID: merchantID,
+	}
+}
+
+// platformLedgerAccount 平台科目
+func platformLedgerAccount(code string) *mdb.LedgerAccount {
+	spec := platformLedgerNames[code]
+	return &mdb.LedgerAccount{Code: code, Name: spec[0], Type: spec[1]}
+}
+
+func debit(account *mdb.LedgerAccount, amount decimal.Decimal) ledgerLine {
+	return ledgerLine{account: account, direction: mdb.LedgerDebit, amount: amount}
+}
+
+func credit(account *mdb.LedgerAccount, amount decimal.Decimal) ledgerLine {
+	return ledgerLine{account: account, direction: mdb.LedgerCredit, amount: amount}
+}
+
+// postLedger 在事务中记一张凭证：借贷必须平衡，同一业务单据只记一次（重复时返回 ErrAlreadyPosted），记账后同步商家余额
+// 记账失败时只回滚凭证本身（保存点），不影响外层事务中的其他更新
+func postLedger(tx *gorm.DB, p *ledgerPosting) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		return writeLedger(tx, p)
+	})
+}
+
+// writeLedger 校验并写入凭证
+func writeLedger(tx *gorm.DB, p *ledgerPosting) error {
+	if len(p.lines) < 2 {
+		return errors.New("凭证至少需要两条分录")
+	}
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, line := range p.lines {
+		if !line.amount.IsPositive() {
+			return errors.New("分录金额必须大于0")
+		}
+		if line.direction == mdb.LedgerDebit {
+			debits = debits.Add(line.amount)
+		} else {
+			credits = credits.Add(line.amount)
+		}
+	}
+	if !debits.Equal(credits) {
+		return fmt.Errorf("凭证借贷不平衡: 借 %s 贷 %s", debits, credits)
+	}
+	posted, err := data.ExistsLedgerJournal(tx, p.bizType, p.bizNo)
+	if err != nil {
+		return err
+	}
+	if posted {
+		return ErrAlreadyPosted
+	}
+
+	// 建立科目并按ID加锁，同一科目的记账串行执行
+	accounts := make(map[string]*mdb.LedgerAccount)
+	var ids []uint64
+	for _, line := range p.lines {
+		if exist, ok := accounts[line.account.Code]; ok {
+			line.account.ID = exist.ID
+			continue
+		}
+		if err := data.GetOrCreateLedgerAccount(tx, line.account); err != nil {
+			return err
+		}
+		accounts[line.account.Code] = line.account
+		ids = append(ids, line.account.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if err := data.LockLedgerAccounts(tx, ids); err != nil {
+		return err
+	}
+	balances := make(map[string]decimal.Decimal)
+	for code, account := range accounts {
+		balance, err := data.GetLedgerAccountBalance(tx, account)
+		if err != nil {
+			return err
+		}
+		balances[code] = balance
+	}
+
+	entries := make([]mdb.LedgerEntry, 0, len(p.lines))
+	for _, line := range p.lines {
+		account := accounts[line.account.Code]
+		balance := balances[account.Code]
+		if (line.direction == mdb.LedgerDebit) == account.DebitNormal() {
+			balance = balance.Add(line.amount)
+		} else {
+			balance = balance.Sub(line.amount)
+		}
+		if p.strict && account.MerchantID > 0 && balance.IsNegative() {
+			return ErrLedgerInsufficient
+		}
+		balances[account.Code] = balance
+		entries = append(entries, mdb.LedgerEntry{
+			AccountID:    account.ID,
+			AccountCode:  account.Code,
+			MerchantID:   account.MerchantID,
+			Direction:    line.direction,
+			Amount:       line.amount,
+			BalanceAfter: balance,
+			BizType:      p.bizType,
+			BizNo:        p.bizNo,
+			Memo:         truncate(p.memo, 255),
+		})
+	}
+	journal := &mdb.LedgerJournal{
+		JournalNo:  generateJournalNo(),
+		BizType:    p.bizType,
+		BizNo:      p.bizNo,
+		MerchantID: p.merchantID,
+		Amount:     debits,
+		Memo:       truncate(p.memo, 255),
+		Operator:   p.operator,
+	}
+	if err := data.CreateLedgerJournal(tx, journal, entries); err != nil {
+		return err
+	}
+	for code, account := range accounts {
+		if account.MerchantID > 0 && code == mdb.MerchantLedgerCode(account.MerchantID, mdb.LedgerMerchantAvailable) {
+			if err := data.SyncMerchantBalance(tx, account.MerchantID, balances[code]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// commitLedger 在独立事务中记一张凭证，已记过账视为成功
+// 用于链上转账完成、业务状态已提交之后的记账，记账失败不影响业务状态
+func commitLedger(post func(tx *gorm.DB) error) error {
+	tx := dao.Mdb.Begin()
+	if err := post(tx); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrAlreadyPosted) {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// notifyLedgerPending 转账后记账失败告警，单据已标记待补记
+func notifyLedgerPending(bizName, bizNo string, merchantID uint64, amount decimal.Decimal, err error) {
+	msgTpl := `
+<b>⚠️ %s记账失败，已标记待补记!</b>
+<pre>单号: %s</pre>
+<pre>商家ID: %d</pre>
+<pre>金额: %s USDT</pre>
+<pre>原因: %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, bizName, bizNo, merchantID, amount.StringFixed(4), err.Error()))
+}
+
+// RetryPendingLedger 补记转账已结束但记账失败的退款与提现
+func RetryPendingLedger() {
+	refunds, err := data.GetLedgerPendingRefunds()
+	if err != nil {
+		log.Sugar.Errorf("[ledger] 查询待补记退款失败, err=%v", err)
+	}
+	for i := range refunds {
+		if err := settleRefundLedger(&refunds[i]); err != nil {
+			log.Sugar.Errorf("[ledger] 退款补记失败, refundNo=%s, err=%v", refunds[i].RefundNo, err)
+		}
+	}
+	withdrawals, err := data.GetLedgerPendingWithdrawals()
+	if err != nil {
+		log.Sugar.Errorf("[ledger] 查询待补记提现失败, err=%v", err)
+	}
+	for i := range withdrawals {
+		if err := settleWithdrawalLedger(&withdrawals[i]); err != nil {
+			log.Sugar.Errorf("[ledger] 提现补记失败, withdrawNo=%s, err=%v", withdrawals[i].WithdrawNo, err)
+		}
+	}
+}
+
+// postDeductionLedger 授权扣款成功：资金进入公司钱包，计入商家可用余额，再扣除平台手续费
+func postDeductionLedger(tx *gorm.DB, merchantID uint64, deduct *mdb.KtvDeduction) error {
+	amount := deduct.AmountUsdt
+	err := postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizDeduction,
+		bizNo:      deduct.DeductNo,
+		merchantID: merchantID,
+		memo:       "授权扣款 " + deduct.AuthNo,
+		lines: []ledgerLine{
+			debit(platformLedgerAccount(mdb.LedgerCodeCompanyWallet), amount),
+			credit(merchantLedgerAccount(merchantID, mdb.LedgerMerchantAvailable), amount),
+		},
+	})
+	if err != nil && !errors.Is(err, ErrAlreadyPosted) {
+		return err
+	}
+	// 扣款已记账时仍补记可能缺失的手续费凭证，返回值只反映扣款凭证是否重复
+	feeErr := postFeeLedger(tx, merchantID, deduct.DeductNo, deduct.FeeAmount, "扣款手续费 "+deduct.DeductNo)
+	if feeErr != nil && !errors.Is(feeErr, ErrAlreadyPosted) {
+		return feeErr
+	}
+	return err
+}
+
+// postWithdrawalHold 提现审批通过：提现金额与手续费从可用余额转入提现在途，余额不足时返回 ErrLedgerInsufficient
+func postWithdrawalHold(tx *gorm.DB, withdrawal *mdb.MerchantWithdrawal, operator string) error {
+	amount := withdrawal.DebitAmount()
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizWithdrawalHold,
+		bizNo:      withdrawal.WithdrawNo,
+		merchantID: withdrawal.MerchantID,
+		memo:       "提现审批通过",
+		operator:   operator,
+		strict:     true,
+		lines: []ledgerLine{
+			debit(merchantLedgerAccount(withdrawal.MerchantID, mdb.LedgerMerchantAvailable), amount),
+			credit(merchantLedgerAccount(withdrawal.MerchantID, mdb.LedgerMerchantPending), amount),
+		},
+	})
+}
+
+// postWithdrawalPaid 提现转账完成：提现金额从公司钱包转出，手续费计入平台收入
+func postWithdrawalPaid(tx *gorm.DB, withdrawal *mdb.MerchantWithdrawal) error {
+	lines := []ledgerLine{
+		debit(merchantLedgerAccount(withdrawal.MerchantID, mdb.LedgerMerchantPending), withdrawal.DebitAmount()),
+		credit(platformLedgerAccount(mdb.LedgerCodeCompanyWallet), withdrawal.Amount),
+	}
+	if withdrawal.FeeAmount.IsPositive() {
+		lines = append(lines, credit(platformLedgerAccount(mdb.LedgerCodePlatformFees), withdrawal.FeeAmount))
+	}
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizWithdrawalPaid,
+		bizNo:      withdrawal.WithdrawNo,
+		merchantID: withdrawal.MerchantID,
+		memo:       "提现转账完成",
+		lines:      lines,
+	})
+}
+
+// postWithdrawalRelease 提现转账失败：在途金额连同手续费退回可用余额
+func postWithdrawalRelease(tx *gorm.DB, withdrawal *mdb.MerchantWithdrawal, reason string) error {
+	amount := withdrawal.DebitAmount()
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizWithdrawalRelease,
+		bizNo:      withdrawal.WithdrawNo,
+		merchantID: withdrawal.MerchantID,
+		memo:       "提现失败退回: " + reason,
+		lines: []ledgerLine{
+			debit(merchantLedgerAccount(withdrawal.MerchantID, mdb.LedgerMerchantPending), amount),
+			credit(merchantLedgerAccount(withdrawal.MerchantID, mdb.LedgerMerchantAvailable), amount),
+		},
+	})
+}
+
+// postRefundHold 发起退款：退款金额从可用余额转入退款在途，余额不足时返回 ErrLedgerInsufficient
+func postRefundHold(tx *gorm.DB, refund *mdb.OrderRefund) error {
+	amount := refund.Amount
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizRefundHold,
+		bizNo:      refund.HoldBizNo(),
+		merchantID: refund.MerchantID,
+		memo:       "订单退款 " + refund.TradeId,
+		operator:   refund.Operator,
+		strict:     true,
+		lines: []ledgerLine{
+			debit(merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantAvailable), amount),
+			credit(merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantRefunding), amount),
+		},
+	})
+}
+
+// postRefundRelease 退款转账失败：在途金额退回可用余额
+func postRefundRelease(tx *gorm.DB, refund *mdb.OrderRefund) error {
+	amount := refund.Amount
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizRefundRelease,
+		bizNo:      refund.HoldBizNo(),
+		merchantID: refund.MerchantID,
+		memo:       "退款失败退回: " + refund.FailReason,
+		lines: []ledgerLine{
+			debit(merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantRefunding), amount),
+			credit(merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantAvailable), amount),
+		},
+	})
+}
+
+// postRefundLedger 退款成功：在途金额从公司钱包转出；早期未冻结余额的退款直接从可用余额扣回
+func postRefundLedger(tx *gorm.DB, refund *mdb.OrderRefund) error {
+	amount := refund.Amount
+	source := merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantRefunding)
+	if refund.HoldAttempt == 0 {
+		source = merchantLedgerAccount(refund.MerchantID, mdb.LedgerMerchantAvailable)
+	}
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizRefund,
+		bizNo:      refund.RefundNo,
+		merchantID: refund.MerchantID,
+		memo:       "订单退款 " + refund.TradeId,
+		operator:   refund.Operator,
+		lines: []ledgerLine{
+			debit(source, amount),
+			credit(platformLedgerAccount(mdb.LedgerCodeCompanyWallet), amount),
+		},
+	})
+}
+
+// postOrderPaymentLedger 订单足额支付：实收金额进入公司钱包，计入商家可用余额
+func postOrderPaymentLedger(tx *gorm.DB, order *mdb.Orders) error {
+	if order.MerchantID == 0 || !order.ReceivedAmount.IsPositive() {
+		return nil
+	}
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizOrderPayment,
+		bizNo:      order.TradeId,
+		merchantID: order.MerchantID,
+		memo:       "订单收款 " + order.TradeId,
+		lines: []ledgerLine{
+			debit(platformLedgerAccount(mdb.LedgerCodeCompanyWallet), order.ReceivedAmount),
+			credit(merchantLedgerAccount(order.MerchantID, mdb.LedgerMerchantAvailable), order.ReceivedAmount),
+		},
+	})
+}
+
+// postFeeLedger 平台手续费：从商家可用余额扣除，计入平台手续费收入，余额可为负
+func postFeeLedger(tx *gorm.DB, merchantID uint64, bizNo string, fee decimal.Decimal, memo string) error {
+	if merchantID == 0 || !fee.IsPositive() {
+		return nil
+	}
+	return postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizFee,
+		bizNo:      bizNo,
+		merchantID: merchantID,
+		memo:       memo,
+		lines: []ledgerLine{
+			debit(merchantLedgerAccount(merchantID, mdb.LedgerMerchantAvailable), fee),
+			credit(platformLedgerAccount(mdb.LedgerCodePlatformFees), fee),
+		},
+	})
+}
+
+// AdjustMerchantBalance 人工调整商家可用余额，amount 为正时增加、为负时减少
+func AdjustMerchantBalance(merchantID uint64, amount decimal.Decimal, memo, operator string) (*mdb.LedgerJournal, error) {
+	value := amount
+	if value.IsZero() {
+		return nil, errors.New("调账金额不能为0")
+	}
+	if memo == "" {
+		return nil, errors.New("调账原因不能为空")
+	}
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil || merchant.ID == 0 {
+		return nil, errors.New("商家不存在")
+	}
+	available := merchantLedgerAccount(merchantID, mdb.LedgerMerchantAvailable)
+	adjustment := platformLedgerAccount(mdb.LedgerCodeAdjustment)
+	lines := []ledgerLine{debit(adjustment, value), credit(available, value)}
+	if value.IsNegative() {
+		value = value.Neg()
+		lines = []ledgerLine{debit(available, value), credit(adjustment, value)}
+	}
+	bizNo := generateAdjustmentNo()
+	tx := dao.Mdb.Begin()
+	err = postLedger(tx, &ledgerPosting{
+		bizType:    mdb.LedgerBizAdjustment,
+		bizNo:      bizNo,
+		merchantID: merchantID,
+		memo:       memo,
+		operator:   operator,
+		strict:     amount.IsNegative(),
+		lines:      lines,
+	})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return data.GetLedgerJournalByBiz(mdb.LedgerBizAdjustment, bizNo)
+}
+
+// InitMerchantLedger 为账本上线前已有余额的商家记期初凭证，重复执行不会重复记账，有商家记账失败时返回错误
+func InitMerchantLedger() error {
+	merchants, err := data.GetMerchantsWithoutLedger()
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, merchant := range merchants {
+		value := merchant.Balance
+		available := merchantLedgerAccount(merchant.ID, mdb.LedgerMerchantAvailable)
+		adjustment := platformLedgerAccount(mdb.LedgerCodeAdjustment)
+		lines := []ledgerLine{debit(adjustment, value), credit(available, value)}
+		if value.IsNegative() {
+			value = value.Neg()
+			lines = []ledgerLine{debit(available, value), credit(adjustment, value)}
+		}
+		tx := dao.Mdb.Begin()
+		err := postLedger(tx, &ledgerPosting{
+			bizType:    mdb.LedgerBizOpening,
+			bizNo:      fmt.Sprintf("%d", merchant.ID),
+			merchantID: merchant.ID,
+			memo:       "期初余额",
+			operator:   "system",
+			lines:      lines,
+		})
+		if err != nil {
+			tx.Rollback()
+			if !errors.Is(err, ErrAlreadyPosted) {
+				log.Sugar.Errorf("[ledger] 期初余额记账失败, merchantID=%d, err=%v", merchant.ID, err)
+				failed++
+			}
+			continue
+		}
+		if err = tx.Commit().Error; err != nil {
+			log.Sugar.Errorf("[ledger] 期初余额记账失败, merchantID=%d, err=%v", merchant.ID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个商家期初余额记账失败", failed)
+	}
+	return nil
+}
+
+// GetMerchantLedgerEntries 商家账单
+func GetMerchantLedgerEntries(merchantID uint64, page, pageSize int, filter *data.LedgerEntryFilter) ([]mdb.LedgerEntry, int64, error) {
+	return data.GetMerchantLedgerEntries(merchantID, page, pageSize, filter)
+}
+
+// ListLedgerEntries 管理后台账本分录
+func ListLedgerEntries(filter *data.ListFilter, q *page.Query) (*data.ListResult[mdb.LedgerEntry], error) {
+	return data.ListLedgerEntries(filter, q)
+}
+
+func generateJournalNo() string {
+	return fmt.Sprintf("J%s%06d", time.Now().Format("20060102150405"), rand.Intn(1000000))
+}
+
+// generateAdjustmentNo 调账单号即业务单号，使用 UUID 避免同一秒内多次调账冲突
+func generateAdjustmentNo() string {
+	return "A" + strings.ReplaceAll(uuid.NewV4().String(), "-", "")
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestWriteLedger 测试凭证校验、重复记账、余额不足及商家余额同步
+func TestWriteLedger(t *testing.T) {
+	db := newTestDB(t, &mdb.Merchant{}, &mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{})
+	merchant := &mdb.Merchant{Username: "ledger_test", ApiToken: "ledger_test_token"}
+	require.NoError(t, db.Create(merchant).Error)
+
+	amount := func(v int64) decimal.Decimal { return decimal.NewFromInt(v) }
+	available := func() *mdb.LedgerAccount { return merchantLedgerAccount(merchant.ID, mdb.LedgerMerchantAvailable) }
+	wallet := func() *mdb.LedgerAccount { return platformLedgerAccount(mdb.LedgerCodeCompanyWallet) }
+
+	// 按顺序执行，后面的用例依赖前面已记的账
+	testCases := []struct {
+		name        string
+		posting     *ledgerPosting
+		wantErr     error
+		wantAnyErr  bool
+		wantBalance int64
+	}{
+		{
+			name:        "分录不足两条",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizAdjustment, bizNo: "A1", lines: []ledgerLine{debit(wallet(), amount(10))}},
+			wantAnyErr:  true,
+			wantBalance: 0,
+		},
+		{
+			name:        "分录金额不为正",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizAdjustment, bizNo: "A2", lines: []ledgerLine{debit(wallet(), amount(0)), credit(available(), amount(0))}},
+			wantAnyErr:  true,
+			wantBalance: 0,
+		},
+		{
+			name:        "借贷不平衡",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizAdjustment, bizNo: "A3", lines: []ledgerLine{debit(wallet(), amount(10)), credit(available(), amount(9))}},
+			wantAnyErr:  true,
+			wantBalance: 0,
+		},
+		{
+			name:        "入账",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizDeduction, bizNo: "D1", merchantID: merchant.ID, lines: []ledgerLine{debit(wallet(), amount(100)), credit(available(), amount(100))}},
+			wantBalance: 100,
+		},
+		{
+			name:        "同一单据重复记账",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizDeduction, bizNo: "D1", merchantID: merchant.ID, lines: []ledgerLine{debit(wallet(), amount(100)), credit(available(), amount(100))}},
+			wantErr:     ErrAlreadyPosted,
+			wantBalance: 100,
+		},
+		{
+			name:        "严格模式余额不足",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizRefund, bizNo: "R1", merchantID: merchant.ID, strict: true, lines: []ledgerLine{debit(available(), amount(150)), credit(wallet(), amount(150))}},
+			wantErr:     ErrLedgerInsufficient,
+			wantBalance: 100,
+		},
+		{
+			name:        "严格模式余额充足",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizRefund, bizNo: "R2", merchantID: merchant.ID, strict: true, lines: []ledgerLine{debit(available(), amount(60)), credit(wallet(), amount(60))}},
+			wantBalance: 40,
+		},
+		{
+			name:        "非严格模式允许为负",
+			posting:     &ledgerPosting{bizType: mdb.LedgerBizRefund, bizNo: "R3", merchantID: merchant.ID, lines: []ledgerLine{debit(available(), amount(50)), credit(wallet(), amount(50))}},
+			wantBalance: -10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := postLedger(db, tc.posting)
+			switch {
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			case tc.wantAnyErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+
+			var got mdb.Merchant
+			require.NoError(t, db.First(&got, merchant.ID).Error)
+			assert.True(t, got.Balance.Equal(amount(tc.wantBalance)), "balance=%s", got.Balance)
+		})
+	}
+
+	// 失败的凭证不留下任何分录，成功的凭证借贷平衡
+	var journals int64
+	require.NoError(t, db.Model(&mdb.LedgerJournal{}).Count(&journals).Error)
+	assert.Equal(t, int64(3), journals)
+	var entries []mdb.LedgerEntry
+	require.NoError(t, db.Find(&entries).Error)
+	sums := map[string]decimal.Decimal{}
+	for _, e := range entries {
+		sums[e.Direction] = sums[e.Direction].Add(e.Amount)
+	}
+	assert.True(t, sums[mdb.LedgerDebit].Equal(sums[mdb.LedgerCredit]))
+
+	// 科目余额取最近一条分录的记账后余额，与逐笔累计一致
+	for _, account := range []*mdb.LedgerAccount{available(), wallet()} {
+		balance, err := data.GetLedgerBalanceByCode(account.Code)
+		require.NoError(t, err)
+		assert.True(t, balance.Equal(amount(-10)), "%s balance=%s", account.Code, balance)
+	}
+}
+
+// TestWriteLedgerConcurrentAccount 测试查询科目后被其它实例抢先创建时，唯一索引冲突后重新查询
+func TestWriteLedgerConcurrentAccount(t *testing.T) {
+	db := newTestDB(t, &mdb.Merchant{}, &mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{})
+	inserted := false
+	err := db.Callback().Query().After("gorm:query").Register("test:concurrent_account", func(tx *gorm.DB) {
+		if inserted || tx.Statement.Table != "ledger_accounts" {
+			return
+		}
+		inserted = true
+		tx.Session(&gorm.Session{NewDB: true}).Create(platformLedgerAccount(mdb.LedgerCodeCompanyWallet))
+	})
+	require.NoError(t, err)
+
+	err = postLedger(db, &ledgerPosting{bizType: mdb.LedgerBizAdjustment, bizNo: "A1", lines: []ledgerLine{
+		debit(platformLedgerAccount(mdb.LedgerCodeCompanyWallet), decimal.NewFromInt(10)),
+		credit(platformLedgerAccount(mdb.LedgerCodeAdjustment), decimal.NewFromInt(10)),
+	}})
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	var count int64
+	require.NoError(t, db.Model(&mdb.LedgerAccount{}).Where("code = ?", mdb.LedgerCodeCompanyWallet).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	balance, err := data.GetLedgerBalanceByCode(mdb.LedgerCodeCompanyWallet)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(10)), "balance=%s", balance)
+}
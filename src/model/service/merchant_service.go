@@ -12,6 +12,7 @@ import (
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -75,7 +76,7 @@ func MerchantRegister(req *MerchantRegisterRequest) (*mdb.Merchant, string, erro
 		Status:       1,
 		ApiToken:     apiToken,
 		UsdtRate:     config.GetUsdtRate(), // 使用系统默认汇率
-		Balance:      0,
+		Balance:      decimal.Zero,
 		LastLoginAt:  time.Now().Unix(),
 	}
 
@@ -128,7 +129,7 @@ func GetMerchantProfile(merchantID uint64) (*mdb.Merchant, error) {
 }
 
 // GenerateMerchantQRCode 生成授权二维码
-func GenerateMerchantQRCode(merchantID uint64, amountUsdt float64, tableNo, customerName string, expireMinutes int) (*AuthorizationResponse, error) {
+func GenerateMerchantQRCode(merchantID uint64, amountUsdt decimal.Decimal, tableNo, customerName string, expireMinutes int, tokenSymbol string) (*AuthorizationResponse, error) {
 	// 获取商家信息
 	merchant, err := data.GetMerchantByID(merchantID)
 	if err != nil {
@@ -140,7 +141,7 @@ func GenerateMerchantQRCode(merchantID uint64, amountUsdt float64, tableNo, cust
 	}
 
 	// 创建授权
-	return CreateAuthorization(amountUsdt, tableNo, customerName, fmt.Sprintf("商家:%s", merchant.MerchantName), "TRON")
+	return createAuthorization(merchantID, amountUsdt, tableNo, customerName, fmt.Sprintf("商家:%s", merchant.MerchantName), "TRON", tokenSymbol)
 }
 
 // GetMerchantAuthorizations 获取商家授权列表
@@ -212,7 +213,7 @@ func GetMerchantDeductions(merchantID uint64, page, pageSize int, status int, st
 }
 
 // MerchantDeduct 商家发起扣款
-func MerchantDeduct(merchantID uint64, password string, amountCny float64, productInfo string) (*DeductionResponse, error) {
+func MerchantDeduct(merchantID uint64, password string, amountCny decimal.Decimal, productInfo string) (*DeductionResponse, error) {
 	// 获取商家信息
 	merchant, err := data.GetMerchantByID(merchantID)
 	if err != nil {
@@ -355,6 +356,15 @@ func ValidateMerchantJWT(tokenString string) (*MerchantClaims, error) {
 	return nil, errors.New("无效的token")
 }
 
+// RollMerchantApiToken 重新生成商家API密钥，旧密钥立即失效
+func RollMerchantApiToken(merchantID uint64) (string, error) {
+	apiToken := generateMerchantApiToken()
+	if err := data.UpdateMerchantApiToken(merchantID, apiToken); err != nil {
+		return "", err
+	}
+	return apiToken, nil
+}
+
 // generateMerchantApiToken 生成商家API Token
 func generateMerchantApiToken() string {
 	b := make([]byte, 32)
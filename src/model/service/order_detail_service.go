@@ -43,12 +43,18 @@ func GetOrderDetailByTradeId(tradeId string) (*response.OrderDetailResponse, err
 		TradeId:            order.TradeId,
 		OrderId:            order.OrderId,
 		Amount:             order.Amount,
+		Currency:           order.Currency,
+		UsdtRate:           order.UsdtRate,
 		ActualAmount:       order.ActualAmount,
 		Token:              order.Token,
 		Chain:              order.Chain,
+		TokenSymbol:        order.TokenSymbol,
 		Status:             order.Status,
 		StatusText:         response.GetStatusText(order.Status),
 		BlockTransactionId: order.BlockTransactionId,
+		FromAddress:        order.FromAddress,
+		ReceivedAmount:     order.ReceivedAmount,
+		RefundedAmount:     order.RefundedAmount,
 		NotifyUrl:          order.NotifyUrl,
 		RedirectUrl:        order.RedirectUrl,
 		CallbackNum:        order.CallbackNum,
@@ -63,3 +69,20 @@ func GetOrderDetailByTradeId(tradeId string) (*response.OrderDetailResponse, err
 
 	return resp, nil
 }
+
+// GetMerchantOrders 分页查询商家订单
+func GetMerchantOrders(merchantID uint64, page, pageSize int, filter *data.OrderFilter) ([]mdb.Orders, int64, error) {
+	return data.GetMerchantOrders(merchantID, page, pageSize, filter)
+}
+
+// GetMerchantOrderDetail 商家订单详情，订单不属于该商家时视为不存在
+func GetMerchantOrderDetail(merchantID uint64, tradeId string) (*response.OrderDetailResponse, error) {
+	order, err := data.GetMerchantOrderByTradeId(merchantID, tradeId)
+	if err != nil {
+		return nil, err
+	}
+	if order.ID <= 0 {
+		return nil, constant.OrderNotExists
+	}
+	return GetOrderDetailByTradeId(tradeId)
+}
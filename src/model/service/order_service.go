@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
-	"sync"
 	"time"
 
 	"github.com/assimon/luuu/config"
@@ -15,33 +14,43 @@ import (
 	"github.com/assimon/luuu/model/response"
 	"github.com/assimon/luuu/mq"
 	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/telegram"
 	"github.com/assimon/luuu/util/constant"
 	"github.com/assimon/luuu/util/chain"
-	"github.com/assimon/luuu/util/math"
+	"github.com/assimon/luuu/util/hdwallet"
+	"github.com/assimon/luuu/util/log"
 	"github.com/dromara/carbon/v2"
 	"github.com/hibiken/asynq"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 const (
-	CnyMinimumPaymentAmount  = 0.01 // cny最低支付金额
+	CnyMinimumPaymentAmount  = 0.01 // 法币最低支付金额
 	UsdtMinimumPaymentAmount = 0.01 // usdt最低支付金额
 	UsdtAmountPerIncrement   = 0.01 // usdt每次递增金额
 	IncrementalMaximumNumber = 100  // 最大递增次数
 )
 
-var gCreateTransactionLock sync.Mutex
+// orderIdCreatingLockTime 商户订单号创建锁时长
+const orderIdCreatingLockTime = 30 * time.Second
 
 // CreateTransaction 创建订单
+// 钱包金额通过 Redis SETNX 原子预占，可多实例并发下单
 func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateTransactionResponse, error) {
-	gCreateTransactionLock.Lock()
-	defer gCreateTransactionLock.Unlock()
-	payAmount := math.MustParsePrecFloat64(req.Amount, 2)
+	currency := config.NormalizeFiatCurrency(req.Currency)
+	if !config.IsSupportedFiatCurrency(currency) {
+		return nil, constant.CurrencyNotSupportErr
+	}
+	usdtRate := config.GetUsdtRateByCurrency(currency)
+	if usdtRate <= 0 {
+		return nil, constant.RateAmountErr
+	}
+	decimalPayAmount := req.Amount.Round(2)
 	// 按照汇率转化USDT
-	decimalPayAmount := decimal.NewFromFloat(payAmount)
-	decimalRate := decimal.NewFromFloat(config.GetUsdtRate())
+	decimalRate := decimal.NewFromFloat(usdtRate)
 	decimalUsdt := decimalPayAmount.Div(decimalRate)
-	// cny 是否可以满足最低支付金额
+	// 法币是否可以满足最低支付金额
 	if decimalPayAmount.Cmp(decimal.NewFromFloat(CnyMinimumPaymentAmount)) == -1 {
 		return nil, constant.PayAmountErr
 	}
@@ -49,6 +58,15 @@ func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateT
 	if decimalUsdt.Cmp(decimal.NewFromFloat(UsdtMinimumPaymentAmount)) == -1 {
 		return nil, constant.PayAmountErr
 	}
+	// 同一商户订单号并发创建时只放行一个请求
+	locked, err := data.TryLockOrderId(req.OrderId, orderIdCreatingLockTime)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, constant.OrderAlreadyExists
+	}
+	defer data.UnLockOrderId(req.OrderId)
 	// 已经存在了的交易
 	exist, err := data.GetOrderInfoByOrderId(req.OrderId)
 	if err != nil {
@@ -61,49 +79,72 @@ func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateT
 	if chainName == "" {
 		chainName = chain.ChainTron
 	}
-	if !chain.IsSupported(chainName) {
-		return nil, errors.New("不支持的链")
-	}
-	// 有无可用钱包
-	walletAddress, err := data.GetAvailableWalletAddressByChain(chainName)
-	if err != nil {
-		return nil, err
+	tokenSymbol := chain.NormalizeTokenSymbol(req.TokenSymbol)
+	if chainName == chain.ChainAny {
+		// 不指定链：至少要有一条链可供付款人选择
+		if len(GetAvailableCheckoutChains(req.MerchantID, tokenSymbol)) == 0 {
+			return nil, constant.NotAvailableWalletAddress
+		}
+	} else {
+		if !chain.IsSupported(chainName) {
+			return nil, errors.New("不支持的链")
+		}
+		if !chain.IsTokenEnabled(chainName, tokenSymbol) {
+			return nil, errors.New("该链未启用此代币")
+		}
 	}
-	if len(walletAddress) <= 0 {
-		return nil, constant.NotAvailableWalletAddress
+	amount := decimalUsdt.Round(2)
+	// 平台手续费按下单时的方案计算，订单足额支付后从商家余额扣除
+	fee := decimal.Zero
+	if req.MerchantID > 0 {
+		if fee, err = CalculateFee(req.MerchantID, mdb.FeeBizOrder, amount); err != nil {
+			return nil, err
+		}
 	}
-	amount := math.MustParsePrecFloat64(decimalUsdt.InexactFloat64(), 2)
-	availableToken, availableAmount, err := CalculateAvailableWalletAndAmount(amount, walletAddress)
-	if err != nil {
-		return nil, err
+	tradeId := GenerateCode()
+	tx := dao.Mdb.Begin()
+	availableToken, availableAmount, derivationPath := "", amount, ""
+	if chainName != chain.ChainAny {
+		availableToken, availableAmount, derivationPath, err = allocateOrderWallet(tx, req.MerchantID, chainName, tokenSymbol, tradeId, amount, config.GetOrderExpirationTimeDuration())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
-	if availableToken == "" {
-		return nil, constant.NotAvailableAmountErr
+	// 下单失败时释放已预占的钱包金额
+	releaseLock := func() {
+		if availableToken != "" {
+			_ = data.UnLockTransaction(availableToken, tokenSymbol, tradeId, availableAmount)
+		}
 	}
-	tx := dao.Mdb.Begin()
 	order := &mdb.Orders{
-		TradeId:      GenerateCode(),
-		OrderId:      req.OrderId,
-		Amount:       req.Amount,
-		ActualAmount: availableAmount,
-		Token:        availableToken,
-		Chain:        chainName,
-		Status:       mdb.StatusWaitPay,
-		NotifyUrl:    req.NotifyUrl,
-		RedirectUrl:  req.RedirectUrl,
+		TradeId:        tradeId,
+		OrderId:        req.OrderId,
+		Amount:         req.Amount,
+		Currency:       currency,
+		UsdtRate:       decimalRate,
+		ActualAmount:   availableAmount,
+		FeeAmount:      fee,
+		Token:          availableToken,
+		Chain:          chainName,
+		TokenSymbol:    tokenSymbol,
+		Status:         mdb.StatusWaitPay,
+		DerivationPath: derivationPath,
+		PaymentLinkId:  req.PaymentLinkId,
+		MerchantID:     req.MerchantID,
+		NotifyUrl:      req.NotifyUrl,
+		RedirectUrl:    req.RedirectUrl,
 	}
 	err = data.CreateOrderWithTransaction(tx, order)
 	if err != nil {
 		tx.Rollback()
+		releaseLock()
 		return nil, err
 	}
-	// 锁定支付池
-	err = data.LockTransaction(availableToken, order.TradeId, availableAmount, config.GetOrderExpirationTimeDuration())
-	if err != nil {
-		tx.Rollback()
+	if err = tx.Commit().Error; err != nil {
+		releaseLock()
 		return nil, err
 	}
-	tx.Commit()
 	// 超时过期消息队列
 	orderExpirationQueue, _ := handle.NewOrderExpirationQueue(order.TradeId)
 	mq.MClient.Enqueue(orderExpirationQueue, asynq.ProcessIn(config.GetOrderExpirationTimeDuration()),
@@ -111,84 +152,599 @@ func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateT
 	)
 	
 	// 启动即时监控
-	instantMonitor := GetInstantMonitor()
-	instantMonitor.StartMonitoringForOrder(order.TradeId, availableToken)
+	if availableToken != "" {
+		instantMonitor := GetInstantMonitor()
+		instantMonitor.StartMonitoringForOrder(order.TradeId, availableToken)
+	}
 	ExpirationTime := carbon.Now().AddMinutes(config.GetOrderExpirationTime()).Timestamp()
 	resp := &response.CreateTransactionResponse{
 		TradeId:        order.TradeId,
 		OrderId:        order.OrderId,
 		Amount:         order.Amount,
+		Currency:       order.Currency,
+		UsdtRate:       order.UsdtRate,
 		ActualAmount:   order.ActualAmount,
 		Token:          order.Token,
 		Chain:          order.Chain,
+		TokenSymbol:    order.TokenSymbol,
 		ExpirationTime: ExpirationTime,
 		PaymentUrl:     fmt.Sprintf("%s/pay/checkout-counter/%s", config.GetAppUri(), order.TradeId),
 	}
 	return resp, nil
 }
 
-// OrderProcessing 成功处理订单
-func OrderProcessing(req *request.OrderProcessingRequest) error {
+// allocateOrderWallet 为订单分配收款钱包与实际支付金额，并在 Redis 中预占 expiration 时长
+func allocateOrderWallet(tx *gorm.DB, merchantID uint64, chainName, tokenSymbol, tradeId string, amount decimal.Decimal, expiration time.Duration) (string, decimal.Decimal, string, error) {
+	if config.GetWalletAllocationMode() == config.WalletAllocationHd {
+		// HD模式：每笔订单派生独立收款地址，按地址匹配入账，无需递增金额
+		depositAddress, err := AllocateHdDepositAddress(tx, chainName, tradeId)
+		if err != nil {
+			return "", decimal.Zero, "", err
+		}
+		locked, err := data.TryLockTransaction(depositAddress.Address, tokenSymbol, tradeId, amount, expiration)
+		if err != nil {
+			return "", decimal.Zero, "", err
+		}
+		if !locked {
+			return "", decimal.Zero, "", constant.NotAvailableAmountErr
+		}
+		return depositAddress.Address, amount, depositAddress.DerivationPath, nil
+	}
+	// 有无可用钱包
+	walletAddress, err := data.GetOrderWalletAddressByChain(merchantID, chainName)
+	if err != nil {
+		return "", decimal.Zero, "", err
+	}
+	walletAddress, strategy := orderWalletsByStrategy(walletPurposeOrder, merchantID, chainName, walletAddress)
+	if len(walletAddress) <= 0 {
+		return "", decimal.Zero, "", constant.NotAvailableWalletAddress
+	}
+	availableToken, availableAmount, err := ReserveAvailableWalletAndAmount(amount, tokenSymbol, tradeId, walletAddress, expiration)
+	if err != nil {
+		return "", decimal.Zero, "", err
+	}
+	if availableToken == "" {
+		return "", decimal.Zero, "", constant.NotAvailableAmountErr
+	}
+	recordWalletSelection(walletPurposeOrder, merchantID, chainName, strategy, tradeId, walletAddress, availableToken)
+	return availableToken, availableAmount, "", nil
+}
+
+// GetAvailableCheckoutChains 获取当前可供付款人选择的链
+func GetAvailableCheckoutChains(merchantID uint64, tokenSymbol string) []string {
+	var chains []string
+	for _, chainName := range chain.SupportedChains {
+		if !chain.IsTokenEnabled(chainName, tokenSymbol) {
+			continue
+		}
+		if config.GetWalletAllocationMode() == config.WalletAllocationHd {
+			if getHdXpub(chainName) == "" {
+				continue
+			}
+		} else {
+			wallets, err := data.GetOrderWalletAddressByChain(merchantID, chainName)
+			if err != nil || len(candidateWallets(walletPurposeOrder, merchantID, chainName, wallets)) == 0 {
+				continue
+			}
+		}
+		chains = append(chains, chainName)
+	}
+	return chains
+}
+
+// SelectOrderChain 付款人为不指定链的订单选择链，此时才分配钱包并锁定金额
+func SelectOrderChain(tradeId, chainName string) error {
+	order, err := data.GetOrderInfoByTradeId(tradeId)
+	if err != nil {
+		return err
+	}
+	if order.ID <= 0 || order.Status != mdb.StatusWaitPay {
+		return constant.OrderNotExists
+	}
+	if order.Chain != chain.ChainAny {
+		return constant.OrderChainAlreadySelected
+	}
+	remaining := time.Until(order.CreatedAt.AddMinutes(config.GetOrderExpirationTime()).StdTime())
+	if remaining <= 0 {
+		return constant.OrderNotExists
+	}
+	chainName = chain.NormalizeChain(chainName)
+	if !chain.IsSupported(chainName) {
+		return errors.New("不支持的链")
+	}
+	if !chain.IsTokenEnabled(chainName, order.TokenSymbol) {
+		return errors.New("该链未启用此代币")
+	}
 	tx := dao.Mdb.Begin()
-	exist, err := data.GetOrderByBlockIdWithTransaction(tx, req.BlockTransactionId)
+	token, actualAmount, derivationPath, err := allocateOrderWallet(tx, order.MerchantID, chainName, order.TokenSymbol, order.TradeId, order.ActualAmount, remaining)
 	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	releaseLock := func() {
+		_ = data.UnLockTransaction(token, order.TokenSymbol, order.TradeId, actualAmount)
+	}
+	// 以 chain = ANY 为条件更新，防止并发重复选链
+	result := tx.Model(&mdb.Orders{}).
+		Where("trade_id = ? AND chain = ?", order.TradeId, chain.ChainAny).
+		Updates(map[string]interface{}{
+			"chain":           chainName,
+			"token":           token,
+			"actual_amount":   actualAmount,
+			"derivation_path": derivationPath,
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		releaseLock()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		releaseLock()
+		return constant.OrderChainAlreadySelected
+	}
+	if err = tx.Commit().Error; err != nil {
+		releaseLock()
 		return err
 	}
+	GetInstantMonitor().StartMonitoringForOrder(order.TradeId, token)
+	return nil
+}
+
+// OrderProcessing 订单入账处理，支持多笔转账累计、容差与超额
+// 链配置了确认数时，转账先记为确认中，待 ConfirmOrderTransfer 复核后再入账
+func OrderProcessing(req *request.OrderProcessingRequest) (*mdb.Orders, error) {
+	tx := dao.Mdb.Begin()
+	exist, err := data.GetOrderTransferByTxHash(tx, req.BlockTransactionId, req.Token, req.LogIndex)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if exist.ID > 0 && exist.Status != mdb.TransferStatusReorged {
+		tx.Rollback()
+		return nil, constant.OrderBlockAlreadyProcess
+	}
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, req.TradeId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if order.ID <= 0 || !mdb.IsOrderPending(order.Status) {
+		tx.Rollback()
+		return nil, constant.OrderNotExists
+	}
+	transfer := &mdb.OrderTransfer{
+		TradeId:        order.TradeId,
+		Chain:          order.Chain,
+		TokenSymbol:    order.TokenSymbol,
+		TxHash:         req.BlockTransactionId,
+		ToAddress:      req.Token,
+		LogIndex:       req.LogIndex,
+		FromAddress:    req.FromAddress,
+		Amount:         req.Amount,
+		BlockTimestamp: req.BlockTimestamp,
+		BlockNumber:    req.BlockNumber,
+		BlockHash:      req.BlockHash,
+		Status:         mdb.TransferStatusConfirmed,
+	}
+	if info := chain.GetChainInfo(order.Chain); info != nil && info.Confirmations > 0 {
+		transfer.Status = mdb.TransferStatusConfirming
+	}
 	if exist.ID > 0 {
+		// 重组回滚后交易再次上链，复用原记录
+		transfer.ID = exist.ID
+		err = data.UpdateOrderTransferWithTransaction(tx, exist.ID, map[string]interface{}{
+			"trade_id":        transfer.TradeId,
+			"from_address":    transfer.FromAddress,
+			"amount":          transfer.Amount,
+			"block_timestamp": transfer.BlockTimestamp,
+			"block_number":    transfer.BlockNumber,
+			"block_hash":      transfer.BlockHash,
+			"status":          transfer.Status,
+			"missing_count":   0,
+			"missing_since":   0,
+		})
+	} else {
+		err = data.CreateOrderTransferWithTransaction(tx, transfer)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if transfer.Status == mdb.TransferStatusConfirming {
+		order.Status = mdb.StatusConfirming
+		if order.FromAddress == "" {
+			order.FromAddress = req.FromAddress
+		}
+		err = data.UpdateOrderPaymentWithTransaction(tx, order.TradeId, map[string]interface{}{
+			"status":       order.Status,
+			"from_address": order.FromAddress,
+		})
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		tx.Commit()
+		return order, nil
+	}
+	if err = creditOrderTransfer(tx, order, transfer); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+	return order, nil
+}
+
+// creditOrderTransfer 将已确认的转账计入订单实收金额，order 须在同一事务内加锁读取
+func creditOrderTransfer(tx *gorm.DB, order *mdb.Orders, transfer *mdb.OrderTransfer) error {
+	order.ReceivedAmount = order.ReceivedAmount.Add(transfer.Amount)
+	order.Status = resolvePaidStatus(order.ReceivedAmount, order.ActualAmount)
+	if order.Status == mdb.StatusPartiallyPaid {
+		// 还有其它转账在确认中时保持确认中
+		confirming, err := data.CountConfirmingTransfersByTradeId(tx, order.TradeId, transfer.ID)
+		if err != nil {
+			return err
+		}
+		if confirming > 0 {
+			order.Status = mdb.StatusConfirming
+		}
+	}
+	order.BlockTransactionId = transfer.TxHash
+	if order.FromAddress == "" {
+		order.FromAddress = transfer.FromAddress
+	}
+	order.CallBackConfirm = mdb.CallBackConfirmNo
+	err := data.UpdateOrderPaymentWithTransaction(tx, order.TradeId, map[string]interface{}{
+		"received_amount":      order.ReceivedAmount,
+		"status":               order.Status,
+		"block_transaction_id": order.BlockTransactionId,
+		"from_address":         order.FromAddress,
+		"callback_confirm":     order.CallBackConfirm,
+	})
+	if err != nil {
+		return err
+	}
+	// 足额支付后解锁交易，锁已过期或被其它订单占用时不处理
+	if mdb.IsOrderPaid(order.Status) {
+		// 实收金额计入商家余额，记账失败时整笔入账回滚，等待下次监听重试
+		if err := postOrderPaymentLedger(tx, order); err != nil && !errors.Is(err, ErrAlreadyPosted) {
+			return err
+		}
+		// 手续费记账失败只回滚凭证，不影响订单入账
+		if err := postFeeLedger(tx, order.MerchantID, order.TradeId, order.FeeAmount, "订单手续费 "+order.TradeId); err != nil && !errors.Is(err, ErrAlreadyPosted) {
+			log.Sugar.Errorf("[order] 手续费记账失败, tradeId=%s, err=%v", order.TradeId, err)
+		}
+		return data.UnLockTransaction(order.Token, order.TokenSymbol, order.TradeId, order.ActualAmount)
+	}
+	return nil
+}
+
+// ConfirmOrderTransfer 转账达到确认数且仍在主链上，正式入账
+func ConfirmOrderTransfer(transferId uint64, blockNumber uint64, blockHash string) error {
+	tx := dao.Mdb.Begin()
+	transfer, err := data.GetOrderTransferByIdWithTransaction(tx, transferId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if transfer.ID <= 0 || transfer.Status != mdb.TransferStatusConfirming {
 		tx.Rollback()
-		return constant.OrderBlockAlreadyProcess
+		return nil
 	}
-	// 标记订单成功
-	err = data.OrderSuccessWithTransaction(tx, req)
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, transfer.TradeId)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	// 解锁交易
-	err = data.UnLockTransaction(req.Token, req.Amount)
+	if order.ID <= 0 {
+		tx.Rollback()
+		return constant.OrderNotExists
+	}
+	err = data.UpdateOrderTransferWithTransaction(tx, transfer.ID, map[string]interface{}{
+		"status":       mdb.TransferStatusConfirmed,
+		"block_number": blockNumber,
+		"block_hash":   blockHash,
+	})
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	tx.Commit()
+	if err = creditOrderTransfer(tx, order, transfer); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	notifyOrderPayment(order)
+	// 订单已过期且仍未足额，按部分支付通知商户
+	if order.Status == mdb.StatusPartiallyPaid && isOrderExpired(order) {
+		return handle.EnqueueOrderCallback(order)
+	}
 	return nil
 }
 
-// CalculateAvailableWalletAndAmount 计算可用钱包地址和金额
-func CalculateAvailableWalletAndAmount(amount float64, walletAddress []mdb.WalletAddress) (string, float64, error) {
-	availableToken := ""
-	availableAmount := amount
-	calculateAvailableWalletFunc := func(amount float64) (string, error) {
-		availableWallet := ""
-		for _, address := range walletAddress {
-			token := address.Token
-			result, err := data.GetTradeIdByWalletAddressAndAmount(token, amount)
-			if err != nil {
-				return "", err
+// RollbackOrderTransfer 转账因区块重组已不在主链，撤销确认中的入账
+func RollbackOrderTransfer(transferId uint64) error {
+	tx := dao.Mdb.Begin()
+	transfer, err := data.GetOrderTransferByIdWithTransaction(tx, transferId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if transfer.ID <= 0 || transfer.Status != mdb.TransferStatusConfirming {
+		tx.Rollback()
+		return nil
+	}
+	err = data.UpdateOrderTransferWithTransaction(tx, transfer.ID, map[string]interface{}{
+		"status": mdb.TransferStatusReorged,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = data.UpdateIncomingTransferMatch(tx, transfer.TxHash, transfer.ToAddress, transfer.LogIndex, mdb.IncomingTransferReorged, transfer.TradeId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, transfer.TradeId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	confirming, err := data.CountConfirmingTransfersByTradeId(tx, transfer.TradeId, transfer.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if order.ID > 0 && order.Status == mdb.StatusConfirming && confirming == 0 {
+		switch {
+		case order.ReceivedAmount.IsPositive():
+			order.Status = mdb.StatusPartiallyPaid
+		case isOrderExpired(order):
+			order.Status = mdb.StatusExpired
+		default:
+			order.Status = mdb.StatusWaitPay
+		}
+		err = data.UpdateOrderPaymentWithTransaction(tx, order.TradeId, map[string]interface{}{
+			"status": order.Status,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	msgTpl := `
+<b>⚠️⚠️入账转账已被区块重组回滚</b>
+<pre>链: %s</pre>
+<pre>交易号：%s</pre>
+<pre>交易哈希：%s</pre>
+<pre>金额：%s %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, transfer.Chain, transfer.TradeId, transfer.TxHash, transfer.Amount, transfer.TokenSymbol))
+	if order.ID > 0 && order.Status == mdb.StatusPartiallyPaid && isOrderExpired(order) {
+		return handle.EnqueueOrderCallback(order)
+	}
+	return nil
+}
+
+// isOrderExpired 订单是否已超过有效期
+func isOrderExpired(order *mdb.Orders) bool {
+	return carbon.Now().Gt(order.CreatedAt.AddMinutes(config.GetOrderExpirationTime()))
+}
+
+// resolvePaidStatus 根据实收金额与应付金额计算订单状态
+func resolvePaidStatus(received, actual decimal.Decimal) int {
+	tolerance := decimal.NewFromFloat(config.GetOrderAmountTolerance())
+	if received.LessThan(actual.Sub(tolerance)) {
+		return mdb.StatusPartiallyPaid
+	}
+	if received.GreaterThan(actual.Add(tolerance)) {
+		return mdb.StatusOverpaid
+	}
+	return mdb.StatusPaySuccess
+}
+
+// MatchTransferOrder 为一笔入账转账匹配订单，未匹配时返回 nil
+// 依次尝试：精确金额锁定 -> HD派生地址 -> 容差范围内的剩余应付金额 -> 钱包下唯一的部分支付订单（分笔累计）
+func MatchTransferOrder(token, chainName, tokenSymbol string, amount decimal.Decimal, blockTimestamp int64) (*mdb.Orders, error) {
+	tradeId, err := data.GetTradeIdByWalletAddressAndAmount(token, tokenSymbol, amount)
+	if err != nil {
+		return nil, err
+	}
+	if tradeId != "" {
+		order, err := data.GetOrderInfoByTradeId(tradeId)
+		if err != nil {
+			return nil, err
+		}
+		if order.ID > 0 && mdb.IsOrderPending(order.Status) && isTransferInOrderWindow(order, blockTimestamp) {
+			return order, nil
+		}
+	}
+	orders, err := data.GetPendingOrdersByToken(token, chainName, tokenSymbol)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []mdb.Orders
+	for _, order := range orders {
+		if isTransferInOrderWindow(&order, blockTimestamp) {
+			candidates = append(candidates, order)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	// HD派生地址由单笔订单独占，按地址直接匹配
+	if candidates[0].DerivationPath != "" {
+		return &candidates[0], nil
+	}
+	tolerance := decimal.NewFromFloat(config.GetOrderAmountTolerance())
+	if tolerance.IsPositive() {
+		var matched *mdb.Orders
+		var matchedDiff decimal.Decimal
+		for i := range candidates {
+			remaining := candidates[i].ActualAmount.Sub(candidates[i].ReceivedAmount)
+			diff := remaining.Sub(amount).Abs()
+			if diff.GreaterThan(tolerance) {
+				continue
 			}
-			if result == "" {
-				availableWallet = token
-				break
+			if matched == nil || diff.LessThan(matchedDiff) {
+				matched = &candidates[i]
+				matchedDiff = diff
 			}
 		}
-		return availableWallet, nil
+		if matched != nil {
+			return matched, nil
+		}
+	}
+	// 金额对不上的转账只累计到唯一的部分支付订单上，其余留在入账记录中等待人工关联
+	var partial []mdb.Orders
+	for _, order := range candidates {
+		if order.Status == mdb.StatusPartiallyPaid {
+			partial = append(partial, order)
+		}
+	}
+	if len(partial) == 1 {
+		return &partial[0], nil
+	}
+	return nil, nil
+}
+
+// isTransferInOrderWindow 区块时间必须位于订单创建与过期时间之间
+func isTransferInOrderWindow(order *mdb.Orders, blockTimestamp int64) bool {
+	if blockTimestamp <= 0 {
+		return true
+	}
+	createTime := order.CreatedAt.TimestampMilli()
+	expireTime := order.CreatedAt.AddMinutes(config.GetOrderExpirationTime()).TimestampMilli()
+	return blockTimestamp >= createTime && blockTimestamp <= expireTime
+}
+
+// ProcessIncomingTransfer 处理监听到的入账转账：匹配订单、入账，并在订单完结时回调
+func ProcessIncomingTransfer(req *request.OrderProcessingRequest) error {
+	exist, err := data.GetOrderTransferByTxHash(dao.Mdb, req.BlockTransactionId, req.Token, req.LogIndex)
+	if err != nil {
+		return err
+	}
+	if exist.ID > 0 && exist.Status != mdb.TransferStatusReorged {
+		return nil
+	}
+	incoming, err := recordIncomingTransfer(req)
+	if err != nil {
+		return err
+	}
+	if incoming.MatchStatus == mdb.IncomingTransferAttached {
+		return nil
+	}
+	order, err := MatchTransferOrder(req.Token, req.Chain, req.TokenSymbol, req.Amount, req.BlockTimestamp)
+	if err != nil || order == nil {
+		return err
+	}
+	req.TradeId = order.TradeId
+	order, err = OrderProcessing(req)
+	if err != nil {
+		return err
+	}
+	if err = data.UpdateIncomingTransferMatch(dao.Mdb, req.BlockTransactionId, req.Token, req.LogIndex, mdb.IncomingTransferMatched, order.TradeId); err != nil {
+		log.Sugar.Errorf("[order] 更新入账转账匹配状态失败, hash=%s, err=%v", req.BlockTransactionId, err)
+	}
+	if order.Status == mdb.StatusConfirming {
+		return nil
 	}
+	notifyOrderPayment(order)
+	return nil
+}
+
+// notifyOrderPayment 入账后的通知：足额时投递回调，并发送机器人消息
+func notifyOrderPayment(order *mdb.Orders) {
+	if !mdb.IsOrderPaid(order.Status) {
+		msgTpl := `
+<b>📢📢订单收到部分付款</b>
+<pre>链: %s</pre>
+<pre>交易号：%s</pre>
+<pre>订单号：%s</pre>
+<pre>应付金额：%s %s</pre>
+<pre>已收金额：%s %s</pre>
+<pre>钱包地址：%s</pre>
+`
+		msg := fmt.Sprintf(msgTpl, order.Chain, order.TradeId, order.OrderId, order.ActualAmount, order.TokenSymbol, order.ReceivedAmount, order.TokenSymbol, order.Token)
+		telegram.SendToBot(msg)
+		return
+	}
+	// 回调队列
+	if err := handle.EnqueueOrderCallback(order); err != nil {
+		log.Sugar.Errorf("[order] 投递回调失败, tradeId=%s, err=%v", order.TradeId, err)
+	}
+	handle.PublishOrderEvent(order, mdb.WebhookEventOrderPaid)
+	// 发送机器人消息
+	msgTpl := `
+<b>📢📢有新的交易支付成功！</b>
+<pre>链: %s</pre>
+<pre>交易号：%s</pre>
+<pre>订单号：%s</pre>
+<pre>请求支付金额：%s %s</pre>
+<pre>应付金额：%s %s</pre>
+<pre>实际支付金额：%s %s</pre>
+<pre>钱包地址：%s</pre>
+<pre>订单创建时间：%s</pre>
+<pre>支付成功时间：%s</pre>
+`
+	msg := fmt.Sprintf(msgTpl, order.Chain, order.TradeId, order.OrderId, order.Amount, order.Currency, order.ActualAmount, order.TokenSymbol, order.ReceivedAmount, order.TokenSymbol, order.Token, order.CreatedAt.ToDateTimeString(), carbon.Now().ToDateTimeString())
+	telegram.SendToBot(msg)
+}
+
+// ReserveAvailableWalletAndAmount 原子预占可用钱包地址和金额
+// 依次尝试各钱包，金额被占用时按 UsdtAmountPerIncrement 递增，SETNX 成功即为预占成功
+func ReserveAvailableWalletAndAmount(amount decimal.Decimal, tokenSymbol, tradeId string, walletAddress []mdb.WalletAddress, expiration time.Duration) (string, decimal.Decimal, error) {
+	availableAmount := amount
+	increment := decimal.NewFromFloat(UsdtAmountPerIncrement)
 	for i := 0; i < IncrementalMaximumNumber; i++ {
-		token, err := calculateAvailableWalletFunc(availableAmount)
-		if err != nil {
-			return "", 0, err
+		for _, address := range walletAddress {
+			locked, err := data.TryLockTransaction(address.Token, tokenSymbol, tradeId, availableAmount, expiration)
+			if err != nil {
+				return "", decimal.Zero, err
+			}
+			if locked {
+				return address.Token, availableAmount, nil
+			}
 		}
 		// 拿不到可用钱包就累加金额
-		if token == "" {
-			decimalOldAmount := decimal.NewFromFloat(availableAmount)
-			decimalIncr := decimal.NewFromFloat(UsdtAmountPerIncrement)
-			availableAmount = decimalOldAmount.Add(decimalIncr).InexactFloat64()
-			continue
-		}
-		availableToken = token
-		break
+		availableAmount = availableAmount.Add(increment)
 	}
-	return availableToken, availableAmount, nil
+	return "", availableAmount, nil
+}
+
+// AllocateHdDepositAddress 为订单派生并登记一个新的收款地址
+func AllocateHdDepositAddress(tx *gorm.DB, chainName, tradeId string) (*mdb.HdDepositAddress, error) {
+	xpub := getHdXpub(chainName)
+	if xpub == "" {
+		return nil, constant.NotAvailableWalletAddress
+	}
+	network := hdwallet.NetworkOf(chainName)
+	index, err := data.AllocateHdDerivationIndex(tx, network)
+	if err != nil {
+		return nil, err
+	}
+	address, err := hdwallet.DeriveAddress(xpub, chainName, index)
+	if err != nil {
+		return nil, err
+	}
+	depositAddress := &mdb.HdDepositAddress{
+		Network:         network,
+		DerivationIndex: index,
+		DerivationPath:  hdwallet.DerivationPath(chainName, index),
+		Address:         address,
+		Chain:           chainName,
+		TradeId:         tradeId,
+	}
+	if err = data.CreateHdDepositAddressWithTransaction(tx, depositAddress); err != nil {
+		return nil, err
+	}
+	return depositAddress, nil
 }
 
 // GenerateCode 订单号生成
@@ -210,3 +766,11 @@ func GetOrderInfoByTradeId(tradeId string) (*mdb.Orders, error) {
 	}
 	return order, nil
 }
+
+// getHdXpub 获取链对应的账户级扩展公钥
+func getHdXpub(chainName string) string {
+	if chain.IsTronChain(chainName) {
+		return config.GetHdTronXpub()
+	}
+	return config.GetHdEvmXpub()
+}
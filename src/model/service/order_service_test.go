@@ -0,0 +1,304 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testOrderWallet = "0xorderwallet"
+
+// createTestOrder 创建收款地址为 testOrderWallet 的订单，创建时间为当前时间
+func createTestOrder(t *testing.T, db *gorm.DB, order mdb.Orders) *mdb.Orders {
+	order.Token = testOrderWallet
+	order.Chain = "BSC"
+	order.TokenSymbol = "USDT"
+	if order.OrderId == "" {
+		order.OrderId = "O-" + order.TradeId
+	}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Model(&order).UpdateColumn("created_at", time.Now()).Error)
+	require.NoError(t, db.First(&order, order.ID).Error)
+	return &order
+}
+
+// TestResolvePaidStatus 测试按实收金额与容差判断少付、足额与超付
+func TestResolvePaidStatus(t *testing.T) {
+	d := decimal.RequireFromString
+	testCases := []struct {
+		name      string
+		tolerance float64
+		received  string
+		expected  int
+	}{
+		{"少付", 0.01, "9.5", mdb.StatusPartiallyPaid},
+		{"容差内少付视为足额", 0.01, "9.99", mdb.StatusPaySuccess},
+		{"金额一致", 0.01, "10", mdb.StatusPaySuccess},
+		{"容差内多付视为足额", 0.01, "10.01", mdb.StatusPaySuccess},
+		{"超付", 0.01, "10.5", mdb.StatusOverpaid},
+		{"无容差少付", 0, "9.99", mdb.StatusPartiallyPaid},
+		{"无容差多付", 0, "10.01", mdb.StatusOverpaid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfigForTest(t, "order_amount_tolerance", tc.tolerance)
+			assert.Equal(t, tc.expected, resolvePaidStatus(d(tc.received), d("10")))
+		})
+	}
+}
+
+// TestMatchTransferOrder 测试入账转账匹配订单的各个分支
+func TestMatchTransferOrder(t *testing.T) {
+	d := decimal.RequireFromString
+	testCases := []struct {
+		name           string
+		tolerance      float64
+		orders         []mdb.Orders
+		lockTradeId    string // 按转账金额预占钱包的订单
+		amount         string
+		blockTimestamp int64
+		expected       string
+	}{
+		{
+			name: "精确金额锁定",
+			orders: []mdb.Orders{
+				{TradeId: "A", ActualAmount: d("10.01"), Status: mdb.StatusWaitPay},
+				{TradeId: "B", ActualAmount: d("10.02"), Status: mdb.StatusWaitPay},
+			},
+			lockTradeId: "B",
+			amount:      "10.02",
+			expected:    "B",
+		},
+		{
+			name:           "区块时间早于订单创建",
+			orders:         []mdb.Orders{{TradeId: "A", ActualAmount: d("10"), Status: mdb.StatusWaitPay}},
+			lockTradeId:    "A",
+			amount:         "10",
+			blockTimestamp: time.Now().Add(-time.Hour).UnixMilli(),
+			expected:       "",
+		},
+		{
+			name:     "HD派生地址按地址匹配",
+			orders:   []mdb.Orders{{TradeId: "A", ActualAmount: d("10"), Status: mdb.StatusWaitPay, DerivationPath: "m/44'/60'/0'/0/1"}},
+			amount:   "3",
+			expected: "A",
+		},
+		{
+			name:      "容差内匹配剩余应付最接近的订单",
+			tolerance: 0.01,
+			orders: []mdb.Orders{
+				{TradeId: "A", ActualAmount: d("10"), Status: mdb.StatusWaitPay},
+				{TradeId: "B", ActualAmount: d("20"), Status: mdb.StatusWaitPay},
+			},
+			amount:   "19.995",
+			expected: "B",
+		},
+		{
+			name:      "超出容差且无部分支付订单",
+			tolerance: 0.01,
+			orders:    []mdb.Orders{{TradeId: "A", ActualAmount: d("10"), Status: mdb.StatusWaitPay}},
+			amount:    "9",
+			expected:  "",
+		},
+		{
+			name: "累计到唯一的部分支付订单",
+			orders: []mdb.Orders{
+				{TradeId: "A", ActualAmount: d("10"), ReceivedAmount: d("4"), Status: mdb.StatusPartiallyPaid},
+				{TradeId: "B", ActualAmount: d("20"), Status: mdb.StatusWaitPay},
+			},
+			amount:   "3",
+			expected: "A",
+		},
+		{
+			name: "多个部分支付订单不自动匹配",
+			orders: []mdb.Orders{
+				{TradeId: "A", ActualAmount: d("10"), ReceivedAmount: d("4"), Status: mdb.StatusPartiallyPaid},
+				{TradeId: "B", ActualAmount: d("20"), ReceivedAmount: d("5"), Status: mdb.StatusPartiallyPaid},
+			},
+			amount:   "3",
+			expected: "",
+		},
+		{
+			name:     "已支付订单不参与匹配",
+			orders:   []mdb.Orders{{TradeId: "A", ActualAmount: d("10"), ReceivedAmount: d("10"), Status: mdb.StatusPaySuccess}},
+			amount:   "10",
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t, &mdb.Orders{})
+			newTestRedis(t)
+			setConfigForTest(t, "order_amount_tolerance", tc.tolerance)
+			for _, order := range tc.orders {
+				createTestOrder(t, db, order)
+			}
+			if tc.lockTradeId != "" {
+				locked, err := data.TryLockTransaction(testOrderWallet, "USDT", tc.lockTradeId, d(tc.amount), time.Hour)
+				require.NoError(t, err)
+				require.True(t, locked)
+			}
+
+			order, err := MatchTransferOrder(testOrderWallet, "BSC", "USDT", d(tc.amount), tc.blockTimestamp)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, order)
+				return
+			}
+			require.NotNil(t, order)
+			assert.Equal(t, tc.expected, order.TradeId)
+		})
+	}
+}
+
+// TestOrderProcessing 测试转账入账：少付、超付、容差、多笔累计及同一交易的批量转账
+func TestOrderProcessing(t *testing.T) {
+	d := decimal.RequireFromString
+	type transfer struct {
+		txHash   string
+		logIndex uint
+		amount   string
+	}
+	testCases := []struct {
+		name         string
+		transfers    []transfer
+		wantErr      error // 最后一笔转账的返回
+		wantStatus   int
+		wantReceived string
+		wantUnlocked bool
+	}{
+		{"少付", []transfer{{"0x1", 0, "9"}}, nil, mdb.StatusPartiallyPaid, "9", false},
+		{"超付", []transfer{{"0x1", 0, "10.5"}}, nil, mdb.StatusOverpaid, "10.5", true},
+		{"容差内视为足额", []transfer{{"0x1", 0, "9.995"}}, nil, mdb.StatusPaySuccess, "9.995", true},
+		{"多笔累计足额", []transfer{{"0x1", 0, "4"}, {"0x2", 0, "6"}}, nil, mdb.StatusPaySuccess, "10", true},
+		{"多笔累计超付", []transfer{{"0x1", 0, "4"}, {"0x2", 0, "7"}}, nil, mdb.StatusOverpaid, "11", true},
+		{"同一交易的批量转账分别入账", []transfer{{"0x1", 3, "4"}, {"0x1", 7, "6"}}, nil, mdb.StatusPaySuccess, "10", true},
+		{"重复转账不重复入账", []transfer{{"0x1", 3, "4"}, {"0x1", 3, "4"}}, constant.OrderBlockAlreadyProcess, mdb.StatusPartiallyPaid, "4", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t, &mdb.Orders{}, &mdb.OrderTransfer{}, &mdb.Merchant{},
+				&mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{})
+			newTestRedis(t)
+			setConfigForTest(t, "order_amount_tolerance", 0.01)
+			// 入账不等待区块确认
+			setConfigForTest(t, "bsc_confirmations", 0)
+			chain.InitRegistry()
+			merchant := &mdb.Merchant{Username: "order_test", ApiToken: "order_test_token"}
+			require.NoError(t, db.Create(merchant).Error)
+			order := createTestOrder(t, db, mdb.Orders{TradeId: "T1", ActualAmount: d("10"), Status: mdb.StatusWaitPay,
+				MerchantID: merchant.ID, FeeAmount: d("0.1")})
+			_, err := data.TryLockTransaction(testOrderWallet, "USDT", order.TradeId, order.ActualAmount, time.Hour)
+			require.NoError(t, err)
+
+			for i, tr := range tc.transfers {
+				_, err = OrderProcessing(&request.OrderProcessingRequest{
+					Token:              testOrderWallet,
+					Chain:              "BSC",
+					TokenSymbol:        "USDT",
+					Amount:             d(tr.amount),
+					TradeId:            order.TradeId,
+					BlockTransactionId: tr.txHash,
+					LogIndex:           tr.logIndex,
+					FromAddress:        "0xpayer",
+				})
+				if i < len(tc.transfers)-1 {
+					require.NoError(t, err)
+				}
+			}
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var got mdb.Orders
+			require.NoError(t, db.First(&got, order.ID).Error)
+			assert.Equal(t, tc.wantStatus, got.Status)
+			assert.True(t, got.ReceivedAmount.Equal(d(tc.wantReceived)), "received=%s", got.ReceivedAmount)
+			assert.Equal(t, "0xpayer", got.FromAddress)
+			// 足额后释放钱包金额锁
+			lockedBy, err := data.GetTradeIdByWalletAddressAndAmount(testOrderWallet, "USDT", order.ActualAmount)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantUnlocked, lockedBy == "")
+			// 支付完成后实收金额扣除手续费计入商家余额，未完成不记账
+			wantBalance := decimal.Zero
+			if tc.wantUnlocked {
+				wantBalance = d(tc.wantReceived).Sub(order.FeeAmount)
+			}
+			var gotMerchant mdb.Merchant
+			require.NoError(t, db.First(&gotMerchant, merchant.ID).Error)
+			assert.True(t, gotMerchant.Balance.Equal(wantBalance), "balance=%s", gotMerchant.Balance)
+		})
+	}
+}
+
+// TestRollbackOrderTransfer 测试重组回滚确认中的转账后恢复订单状态，不影响商家余额
+func TestRollbackOrderTransfer(t *testing.T) {
+	d := decimal.RequireFromString
+	testCases := []struct {
+		name           string
+		received       string
+		age            time.Duration // 订单创建距今的时间
+		transferStatus int
+		otherConfirm   bool // 订单还有其它确认中的转账
+		wantOrder      int
+		wantTransfer   int
+	}{
+		{"无其它入账回到待支付", "0", time.Minute, mdb.TransferStatusConfirming, false, mdb.StatusWaitPay, mdb.TransferStatusReorged},
+		{"已有部分入账回到部分支付", "4", time.Minute, mdb.TransferStatusConfirming, false, mdb.StatusPartiallyPaid, mdb.TransferStatusReorged},
+		{"订单已过期回到过期", "0", 2 * time.Hour, mdb.TransferStatusConfirming, false, mdb.StatusExpired, mdb.TransferStatusReorged},
+		{"仍有其它确认中的转账", "0", time.Minute, mdb.TransferStatusConfirming, true, mdb.StatusConfirming, mdb.TransferStatusReorged},
+		{"已确认的转账不回滚", "0", time.Minute, mdb.TransferStatusConfirmed, false, mdb.StatusConfirming, mdb.TransferStatusConfirmed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t, &mdb.Orders{}, &mdb.OrderTransfer{}, &mdb.IncomingTransfer{},
+				&mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{})
+			setConfigForTest(t, "order_expiration_time", 10)
+			order := createTestOrder(t, db, mdb.Orders{TradeId: "T1", ActualAmount: d("10"), ReceivedAmount: d(tc.received),
+				Status: mdb.StatusConfirming, MerchantID: 1})
+			require.NoError(t, db.Model(order).UpdateColumn("created_at", time.Now().Add(-tc.age)).Error)
+
+			transfer := &mdb.OrderTransfer{TradeId: order.TradeId, Chain: "BSC", TokenSymbol: "USDT", TxHash: "0x1",
+				ToAddress: testOrderWallet, Amount: d("6"), Status: tc.transferStatus}
+			require.NoError(t, db.Create(transfer).Error)
+			require.NoError(t, db.Create(&mdb.IncomingTransfer{Chain: "BSC", TokenSymbol: "USDT", TxHash: "0x1", ToAddress: testOrderWallet,
+				Amount: d("6"), MatchStatus: mdb.IncomingTransferMatched, TradeId: order.TradeId}).Error)
+			if tc.otherConfirm {
+				require.NoError(t, db.Create(&mdb.OrderTransfer{TradeId: order.TradeId, Chain: "BSC", TokenSymbol: "USDT", TxHash: "0x2",
+					ToAddress: testOrderWallet, Amount: d("4"), Status: mdb.TransferStatusConfirming}).Error)
+			}
+
+			require.NoError(t, RollbackOrderTransfer(transfer.ID))
+
+			var gotOrder mdb.Orders
+			require.NoError(t, db.First(&gotOrder, order.ID).Error)
+			assert.Equal(t, tc.wantOrder, gotOrder.Status)
+			assert.True(t, gotOrder.ReceivedAmount.Equal(d(tc.received)), "received=%s", gotOrder.ReceivedAmount)
+			var gotTransfer mdb.OrderTransfer
+			require.NoError(t, db.First(&gotTransfer, transfer.ID).Error)
+			assert.Equal(t, tc.wantTransfer, gotTransfer.Status)
+			incoming, err := data.GetIncomingTransferByTxHash(db, "0x1", testOrderWallet, 0)
+			require.NoError(t, err)
+			if tc.wantTransfer == mdb.TransferStatusReorged {
+				assert.Equal(t, mdb.IncomingTransferReorged, incoming.MatchStatus)
+			} else {
+				assert.Equal(t, mdb.IncomingTransferMatched, incoming.MatchStatus)
+			}
+			// 确认中的转账尚未入账，回滚不产生账本凭证
+			var journals int64
+			require.NoError(t, db.Model(&mdb.LedgerJournal{}).Count(&journals).Error)
+			assert.Equal(t, int64(0), journals)
+		})
+	}
+}
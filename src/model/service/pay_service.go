@@ -2,11 +2,15 @@ package service
 
 import (
 	"errors"
+	"regexp"
+	"strings"
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/chain"
 )
 
 // GetCheckoutCounterByTradeId 获取收银台详情，通过订单
@@ -15,15 +19,101 @@ func GetCheckoutCounterByTradeId(tradeId string) (*response.CheckoutCounterRespo
 	if err != nil {
 		return nil, err
 	}
-	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
+	if orderInfo.ID <= 0 || !mdb.IsOrderPending(orderInfo.Status) {
 		return nil, errors.New("不存在待支付订单或已过期！")
 	}
+	payableAmount := orderInfo.ActualAmount
+	if orderInfo.ReceivedAmount.IsPositive() && orderInfo.ReceivedAmount.LessThan(orderInfo.ActualAmount) {
+		payableAmount = orderInfo.ActualAmount.Sub(orderInfo.ReceivedAmount)
+	}
+	chainName := getChainDisplayName(orderInfo.Chain)
 	resp := &response.CheckoutCounterResponse{
 		TradeId:        orderInfo.TradeId,
+		OrderId:        orderInfo.OrderId,
+		Amount:         orderInfo.Amount,
+		Currency:       orderInfo.Currency,
 		ActualAmount:   orderInfo.ActualAmount,
+		ReceivedAmount: orderInfo.ReceivedAmount,
+		PayableAmount:  payableAmount,
 		Token:          orderInfo.Token,
+		Chain:          orderInfo.Chain,
+		ChainName:      chainName,
+		TokenSymbol:    orderInfo.TokenSymbol,
+		Status:         orderInfo.Status,
 		ExpirationTime: orderInfo.CreatedAt.AddMinutes(config.GetOrderExpirationTime()).TimestampMilli(),
 		RedirectUrl:    orderInfo.RedirectUrl,
+		MerchantID:     orderInfo.MerchantID,
+	}
+	if orderInfo.Chain == chain.ChainAny {
+		for _, chainName := range GetAvailableCheckoutChains(orderInfo.MerchantID, orderInfo.TokenSymbol) {
+			resp.Chains = append(resp.Chains, response.CheckoutChainOption{
+				Chain:     chainName,
+				ChainName: getChainDisplayName(chainName),
+			})
+		}
 	}
 	return resp, nil
 }
+
+// getChainDisplayName 链显示名称
+func getChainDisplayName(chainName string) string {
+	if info := chain.GetChainInfo(chainName); info != nil {
+		return info.DisplayName
+	}
+	return chainName
+}
+
+// themeColorPattern 主题色只允许十六进制颜色
+var themeColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// GetCheckoutBranding 收银台品牌，商家未设置的项使用全局配置
+func GetCheckoutBranding(merchantID uint64) *response.CheckoutBranding {
+	branding := &response.CheckoutBranding{
+		BrandName:  config.GetCheckoutBrandName(),
+		LogoUrl:    config.GetCheckoutLogoUrl(),
+		ThemeColor: config.GetCheckoutThemeColor(),
+	}
+	if merchantID <= 0 {
+		return branding
+	}
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil || merchant.ID <= 0 {
+		return branding
+	}
+	if merchant.CheckoutBrandName != "" {
+		branding.BrandName = merchant.CheckoutBrandName
+	}
+	if merchant.CheckoutLogoUrl != "" {
+		branding.LogoUrl = merchant.CheckoutLogoUrl
+	}
+	if merchant.CheckoutThemeColor != "" {
+		branding.ThemeColor = merchant.CheckoutThemeColor
+	}
+	return branding
+}
+
+// GetMerchantCheckoutBranding 商家自己设置的收银台品牌，未设置的项为空
+func GetMerchantCheckoutBranding(merchantID uint64) (*response.CheckoutBranding, error) {
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return &response.CheckoutBranding{
+		BrandName:  merchant.CheckoutBrandName,
+		LogoUrl:    merchant.CheckoutLogoUrl,
+		ThemeColor: merchant.CheckoutThemeColor,
+	}, nil
+}
+
+// UpdateMerchantCheckoutBranding 设置商家的收银台品牌
+func UpdateMerchantCheckoutBranding(merchantID uint64, req *request.CheckoutBrandingRequest) error {
+	themeColor := strings.TrimSpace(req.ThemeColor)
+	if themeColor != "" && !themeColorPattern.MatchString(themeColor) {
+		return errors.New("主题色格式有误，例如 #1677ff")
+	}
+	return data.UpdateMerchantCheckoutBranding(merchantID, map[string]interface{}{
+		"checkout_brand_name":  strings.TrimSpace(req.BrandName),
+		"checkout_logo_url":    strings.TrimSpace(req.LogoUrl),
+		"checkout_theme_color": themeColor,
+	})
+}
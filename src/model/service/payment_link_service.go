@@ -0,0 +1,234 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/shopspring/decimal"
+)
+
+var paymentLinkSlugRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{4,64}$`)
+
+// normalizePaymentLink 校验并规范化收款链接参数
+func normalizePaymentLink(req *request.PaymentLinkRequest) error {
+	req.Currency = config.NormalizeFiatCurrency(req.Currency)
+	if !config.IsSupportedFiatCurrency(req.Currency) {
+		return errors.New("不支持的法币币种")
+	}
+	req.Chain = chain.NormalizeChain(req.Chain)
+	if req.Chain == "" {
+		req.Chain = chain.ChainAny
+	}
+	req.TokenSymbol = chain.NormalizeTokenSymbol(req.TokenSymbol)
+	if req.Chain != chain.ChainAny {
+		if !chain.IsSupported(req.Chain) {
+			return errors.New("不支持的链")
+		}
+		if !chain.IsTokenEnabled(req.Chain, req.TokenSymbol) {
+			return errors.New("该链未启用此代币")
+		}
+	}
+	for _, amount := range []decimal.Decimal{req.Amount, req.MinAmount, req.MaxAmount} {
+		if amount.IsNegative() {
+			return errors.New("金额不能为负数")
+		}
+		if err := (request.CreateTransactionRequest{Amount: amount}).ValidateAmount(); err != nil {
+			return err
+		}
+	}
+	if req.MaxAmount.IsPositive() && req.MinAmount.GreaterThan(req.MaxAmount) {
+		return errors.New("金额下限不能大于上限")
+	}
+	if req.MaxUses < 0 {
+		return errors.New("使用次数上限不能为负数")
+	}
+	if req.ExpiresAt < 0 {
+		return errors.New("过期时间有误")
+	}
+	return nil
+}
+
+// generatePaymentLinkSlug 生成随机链接标识
+func generatePaymentLinkSlug() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// CreatePaymentLink 商家创建收款链接
+func CreatePaymentLink(merchantID uint64, req *request.PaymentLinkRequest) (*mdb.PaymentLink, error) {
+	if err := normalizePaymentLink(req); err != nil {
+		return nil, err
+	}
+	slug := strings.TrimSpace(req.Slug)
+	if slug == "" {
+		slug = generatePaymentLinkSlug()
+	}
+	if !paymentLinkSlugRe.MatchString(slug) {
+		return nil, errors.New("链接标识只能包含字母、数字、下划线和中划线，长度4~64")
+	}
+	exist, err := data.GetPaymentLinkBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if exist.ID > 0 {
+		return nil, errors.New("链接标识已存在")
+	}
+	link := &mdb.PaymentLink{
+		MerchantID:  merchantID,
+		Slug:        slug,
+		Title:       req.Title,
+		Description: req.Description,
+		Amount:      req.Amount,
+		MinAmount:   req.MinAmount,
+		MaxAmount:   req.MaxAmount,
+		Currency:    req.Currency,
+		Chain:       req.Chain,
+		TokenSymbol: req.TokenSymbol,
+		NotifyUrl:   req.NotifyUrl,
+		RedirectUrl: req.RedirectUrl,
+		MaxUses:     req.MaxUses,
+		ExpiresAt:   req.ExpiresAt,
+		Status:      mdb.PaymentLinkStatusEnable,
+	}
+	if err = data.CreatePaymentLink(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// UpdatePaymentLink 商家更新收款链接，标识不可修改
+func UpdatePaymentLink(merchantID, id uint64, req *request.PaymentLinkRequest) (*mdb.PaymentLink, error) {
+	link, err := data.GetMerchantPaymentLink(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if link.ID <= 0 {
+		return nil, errors.New("收款链接不存在")
+	}
+	if err = normalizePaymentLink(req); err != nil {
+		return nil, err
+	}
+	status := req.Status
+	if status != mdb.PaymentLinkStatusEnable && status != mdb.PaymentLinkStatusDisable {
+		status = link.Status
+	}
+	err = data.UpdateMerchantPaymentLink(merchantID, id, map[string]interface{}{
+		"title":        req.Title,
+		"description":  req.Description,
+		"amount":       req.Amount,
+		"min_amount":   req.MinAmount,
+		"max_amount":   req.MaxAmount,
+		"currency":     req.Currency,
+		"chain":        req.Chain,
+		"token_symbol": req.TokenSymbol,
+		"notify_url":   req.NotifyUrl,
+		"redirect_url": req.RedirectUrl,
+		"max_uses":     req.MaxUses,
+		"expires_at":   req.ExpiresAt,
+		"status":       status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.GetMerchantPaymentLink(merchantID, id)
+}
+
+// GetMerchantPaymentLinks 商家收款链接列表
+func GetMerchantPaymentLinks(merchantID uint64, page, pageSize int) ([]mdb.PaymentLink, int64, error) {
+	return data.GetMerchantPaymentLinks(merchantID, page, pageSize)
+}
+
+// DeleteMerchantPaymentLink 删除商家收款链接
+func DeleteMerchantPaymentLink(merchantID, id uint64) error {
+	return data.DeleteMerchantPaymentLink(merchantID, id)
+}
+
+// GetAvailablePaymentLink 获取可用的收款链接
+func GetAvailablePaymentLink(slug string) (*mdb.PaymentLink, error) {
+	link, err := data.GetPaymentLinkBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if link.ID <= 0 || link.Status != mdb.PaymentLinkStatusEnable {
+		return nil, errors.New("收款链接不存在或已停用")
+	}
+	if link.ExpiresAt > 0 && time.Now().Unix() > link.ExpiresAt {
+		return nil, errors.New("收款链接已过期")
+	}
+	if link.MaxUses > 0 && link.UsedCount >= link.MaxUses {
+		return nil, errors.New("收款链接已达到使用次数上限")
+	}
+	return link, nil
+}
+
+// PaymentLinkAmountRange 付款人可填写的金额范围，链接未设置上限时使用系统默认上限
+func PaymentLinkAmountRange(link *mdb.PaymentLink) (decimal.Decimal, decimal.Decimal) {
+	min := decimal.NewFromFloat(0.01)
+	if link.MinAmount.GreaterThan(min) {
+		min = link.MinAmount
+	}
+	max := decimal.NewFromFloat(config.GetPaymentLinkMaxAmount())
+	if link.MaxAmount.IsPositive() {
+		max = link.MaxAmount
+	}
+	return min, max
+}
+
+// CreateOrderFromPaymentLink 付款人在收款链接页提交后生成一笔新订单
+// 固定金额的链接忽略 amount，否则使用付款人填写的金额，金额须在链接允许的范围内
+func CreateOrderFromPaymentLink(slug string, amount decimal.Decimal) (*response.CreateTransactionResponse, error) {
+	link, err := GetAvailablePaymentLink(slug)
+	if err != nil {
+		return nil, err
+	}
+	if link.Amount.IsPositive() {
+		amount = link.Amount
+	} else if amount.IsPositive() {
+		min, max := PaymentLinkAmountRange(link)
+		if amount.LessThan(min) || amount.GreaterThan(max) {
+			return nil, fmt.Errorf("支付金额须在 %s ~ %s %s 之间", min.StringFixed(2), max.StringFixed(2), link.Currency)
+		}
+	}
+	req := &request.CreateTransactionRequest{
+		OrderId:       "PL" + GenerateCode(),
+		Amount:        amount,
+		Currency:      link.Currency,
+		NotifyUrl:     link.NotifyUrl,
+		RedirectUrl:   link.RedirectUrl,
+		Chain:         link.Chain,
+		TokenSymbol:   link.TokenSymbol,
+		PaymentLinkId: link.ID,
+		MerchantID:    link.MerchantID,
+	}
+	if !amount.IsPositive() {
+		return nil, errors.New("请输入支付金额")
+	}
+	if err = req.ValidateAmount(); err != nil {
+		return nil, err
+	}
+	ok, err := data.IncrPaymentLinkUsage(link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("收款链接已达到使用次数上限")
+	}
+	resp, err := CreateTransaction(req)
+	if err != nil {
+		_ = data.DecrPaymentLinkUsage(link.ID)
+		return nil, err
+	}
+	return resp, nil
+}
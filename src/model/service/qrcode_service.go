@@ -3,7 +3,6 @@ package service
 import (
 	"errors"
 	"fmt"
-	"math/big"
 	"math/rand"
 	"time"
 
@@ -11,6 +10,8 @@ import (
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/shopspring/decimal"
 )
 
 // QRCodeFormat 二维码格式类型
@@ -38,7 +39,7 @@ func GenerateAuthorizationQRCode(
 	authNo string,
 	chainName string,
 	merchantWallet string,
-	amountUsdt float64,
+	amountUsdt decimal.Decimal,
 ) (*AuthorizationQRCode, error) {
 
 	chainName = chain.NormalizeChain(chainName)
@@ -61,7 +62,7 @@ func generateEIP681QRCode(
 	authNo string,
 	chainName string,
 	merchantWallet string,
-	amountUsdt float64,
+	amountUsdt decimal.Decimal,
 ) (*AuthorizationQRCode, error) {
 
 	// 获取链配置
@@ -91,11 +92,7 @@ func generateEIP681QRCode(
 	}
 
 	// 转换金额为最小单位
-	amountWei := new(big.Float).Mul(
-		big.NewFloat(amountUsdt),
-		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)),
-	)
-	amountInt, _ := amountWei.Int(nil)
+	amountInt := evm.ToBaseUnits(amountUsdt, decimals)
 
 	// EIP-681格式
 	// ethereum:<contract>@<chainId>/approve?address=<spender>&uint256=<amount>
@@ -131,7 +128,7 @@ func generateEIP681QRCode(
 func generateTronWebQRCode(
 	authNo string,
 	merchantWallet string,
-	amountUsdt float64,
+	amountUsdt decimal.Decimal,
 ) (*AuthorizationQRCode, error) {
 
 	// 生成授权页面URL
@@ -154,7 +151,7 @@ func generateTronWebQRCode(
 
 // CreateAuthorizationWithQRCode 创建授权并生成二维码
 func CreateAuthorizationWithQRCode(
-	amountUsdt float64,
+	amountUsdt decimal.Decimal,
 	tableNo string,
 	customerName string,
 	remark string,
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/page"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// refundTransitions 退款状态机：当前状态 => 允许流转到的状态
+var refundTransitions = map[int][]int{
+	mdb.RefundStatusPending:    {mdb.RefundStatusProcessing},
+	mdb.RefundStatusProcessing: {mdb.RefundStatusSuccess, mdb.RefundStatusFailed},
+	mdb.RefundStatusFailed:     {mdb.RefundStatusPending},
+}
+
+// sendRefundTransfer 退款链上转账
+var sendRefundTransfer = refundTransfer
+
+// canTransitRefund 校验退款状态流转是否合法
+func canTransitRefund(from, to int) bool {
+	for _, status := range refundTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitRefund 按状态机流转退款状态
+func transitRefund(tx *gorm.DB, refundNo string, from, to int, updates map[string]interface{}) error {
+	if !canTransitRefund(from, to) {
+		return fmt.Errorf("退款状态不允许从 %d 变更为 %d", from, to)
+	}
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	ok, err := data.TransitRefundStatus(tx, refundNo, from, updates)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("退款状态已变更，请刷新后重试")
+	}
+	return nil
+}
+
+// CreateOrderRefund 发起订单退款，amount<=0 时退还全部剩余金额
+// merchantID>0 时校验订单归属该商家
+func CreateOrderRefund(tradeId string, amount decimal.Decimal, reason, operator string, merchantID uint64) (*mdb.OrderRefund, error) {
+	refund, err := reserveOrderRefund(tradeId, amount, reason, operator, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTpl := `
+<b>↩️ 新退款申请!</b>
+<pre>退款单号: %s</pre>
+<pre>交易号: %s</pre>
+<pre>金额: %s USDT</pre>
+<pre>退款地址: %s</pre>
+<pre>链: %s</pre>
+`
+	msg := fmt.Sprintf(msgTpl, refund.RefundNo, refund.TradeId, refund.Amount.StringFixed(4), refund.ToWallet, refund.Chain)
+	telegram.SendToBot(msg)
+
+	// 异步执行链上退款
+	go executeRefundTransfer(refund)
+
+	return refund, nil
+}
+
+// reserveOrderRefund 锁定订单行后校验可退金额并创建退款，退款金额从商家可用余额冻结
+// 同一订单的退款在订单行锁上串行，多实例并发发起也不会超退
+func reserveOrderRefund(tradeId string, amount decimal.Decimal, reason, operator string, merchantID uint64) (*mdb.OrderRefund, error) {
+	tx := dao.Mdb.Begin()
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, tradeId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if order.ID <= 0 {
+		tx.Rollback()
+		return nil, constant.OrderNotExists
+	}
+	// 订单记录了所属商家时以订单为准，早期订单按收款钱包反查
+	orderMerchantID := order.MerchantID
+	if orderMerchantID == 0 {
+		orderMerchantID, _ = data.GetMerchantIDByWallet(order.Token)
+	}
+	if merchantID > 0 && orderMerchantID != merchantID {
+		tx.Rollback()
+		return nil, constant.OrderNotExists
+	}
+	if !mdb.IsOrderPaid(order.Status) && order.Status != mdb.StatusPartiallyPaid && order.Status != mdb.StatusPartialRefunded {
+		tx.Rollback()
+		return nil, errors.New("订单状态不允许退款")
+	}
+	if order.FromAddress == "" {
+		tx.Rollback()
+		return nil, errors.New("订单缺少付款地址，无法原路退款")
+	}
+
+	refundable, err := getRefundableAmount(tx, order)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		amount = refundable
+	}
+	amount = amount.Round(4)
+	if !amount.IsPositive() {
+		tx.Rollback()
+		return nil, errors.New("订单已无可退金额")
+	}
+	if amount.GreaterThan(refundable) {
+		tx.Rollback()
+		return nil, fmt.Errorf("退款金额超出可退金额 %s USDT", refundable.String())
+	}
+
+	refund := &mdb.OrderRefund{
+		RefundNo:    generateRefundNo(),
+		TradeId:     order.TradeId,
+		OrderId:     order.OrderId,
+		MerchantID:  orderMerchantID,
+		Chain:       order.Chain,
+		TokenSymbol: order.TokenSymbol,
+		ToWallet:    order.FromAddress,
+		Amount:      amount,
+		Status:      mdb.RefundStatusPending,
+		Reason:      reason,
+		Operator:    operator,
+	}
+	if orderMerchantID > 0 {
+		refund.HoldAttempt = 1
+	}
+	if err := data.CreateRefundWithTransaction(tx, refund); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := holdRefundBalance(tx, refund); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// holdRefundBalance 公司钱包垫付前先冻结商家可用余额，余额不足时不允许退款
+func holdRefundBalance(tx *gorm.DB, refund *mdb.OrderRefund) error {
+	if refund.HoldAttempt == 0 {
+		return nil
+	}
+	err := postRefundHold(tx, refund)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrLedgerInsufficient) {
+		return fmt.Errorf("商家可用余额不足，无法退款 %s USDT", refund.Amount.String())
+	}
+	log.Sugar.Errorf("[refund] 冻结余额失败, refundNo=%s, err=%v", refund.RefundNo, err)
+	return errors.New("冻结商家余额失败")
+}
+
+// RetryOrderRefund 重试失败的退款，重新校验可退金额并冻结商家余额
+func RetryOrderRefund(refundNo string) error {
+	refund, err := data.GetRefundByNo(refundNo)
+	if err != nil {
+		return err
+	}
+	if refund.ID <= 0 {
+		return errors.New("退款记录不存在")
+	}
+	if refund.Status != mdb.RefundStatusFailed {
+		return errors.New("只能重试失败的退款")
+	}
+	if refund.LedgerPending == 1 {
+		return errors.New("上次失败的退款尚未退回商家余额，请稍后重试")
+	}
+
+	tx := dao.Mdb.Begin()
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, refund.TradeId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// 失败的退款不占用可退金额，重试前需要重新校验
+	refundable, err := getRefundableAmount(tx, order)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if refund.Amount.GreaterThan(refundable) {
+		tx.Rollback()
+		return fmt.Errorf("退款金额超出可退金额 %s USDT", refundable.String())
+	}
+	if refund.MerchantID > 0 {
+		refund.HoldAttempt++
+	}
+	err = transitRefund(tx, refund.RefundNo, mdb.RefundStatusFailed, mdb.RefundStatusPending, map[string]interface{}{
+		"hold_attempt": refund.HoldAttempt,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := holdRefundBalance(tx, refund); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	refund.Status = mdb.RefundStatusPending
+
+	go executeRefundTransfer(refund)
+	return nil
+}
+
+// getRefundableAmount 计算订单剩余可退金额（扣除处理中与已成功的退款）
+func getRefundableAmount(tx *gorm.DB, order *mdb.Orders) (decimal.Decimal, error) {
+	used, err := data.SumRefundAmountByStatus(tx, order.TradeId,
+		mdb.RefundStatusPending, mdb.RefundStatusProcessing, mdb.RefundStatusSuccess)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	refundable := getOrderPaidAmount(order).Sub(used)
+	if refundable.IsNegative() {
+		return decimal.Zero, nil
+	}
+	return refundable, nil
+}
+
+// getOrderPaidAmount 订单实收金额，早期订单未记录实收时按应付金额计算
+func getOrderPaidAmount(order *mdb.Orders) decimal.Decimal {
+	if order.ReceivedAmount.IsPositive() {
+		return order.ReceivedAmount
+	}
+	return order.ActualAmount
+}
+
+// executeRefundTransfer 执行待处理退款的链上转账（用公司钱包私钥）
+func executeRefundTransfer(refund *mdb.OrderRefund) {
+	if err := transitRefund(dao.Mdb, refund.RefundNo, mdb.RefundStatusPending, mdb.RefundStatusProcessing, map[string]interface{}{
+		"fail_reason": "",
+	}); err != nil {
+		log.Sugar.Errorf("[refund] 状态流转失败, refundNo=%s, err=%v", refund.RefundNo, err)
+		return
+	}
+
+	txHash, err := sendRefundTransfer(refund)
+	if err != nil {
+		log.Sugar.Errorf("[refund] 转账失败, refundNo=%s, err=%v", refund.RefundNo, err)
+		failRefund(refund, fmt.Sprintf("转账失败: %s", err.Error()))
+		msgTpl := `
+<b>❌ 退款转账失败!</b>
+<pre>退款单号: %s</pre>
+<pre>金额: %s USDT</pre>
+<pre>原因: %s</pre>
+`
+		msg := fmt.Sprintf(msgTpl, refund.RefundNo, refund.Amount.StringFixed(4), err.Error())
+		telegram.SendToBot(msg)
+		return
+	}
+
+	// 转账成功：先单独保存交易哈希，链上资金已转出，后续步骤失败也不能丢失记录
+	ok, err := data.TransitRefundStatus(dao.Mdb, refund.RefundNo, mdb.RefundStatusProcessing, map[string]interface{}{
+		"status":      mdb.RefundStatusSuccess,
+		"tx_hash":     txHash,
+		"refund_time": time.Now().Unix(),
+	})
+	if err != nil || !ok {
+		if err == nil {
+			err = errors.New("退款状态已变更")
+		}
+		log.Sugar.Errorf("[refund] 更新退款状态失败, refundNo=%s, txHash=%s, err=%v", refund.RefundNo, txHash, err)
+		notifyRefundReconcile(refund, txHash, "更新退款状态失败: "+err.Error())
+		return
+	}
+	refund.Status = mdb.RefundStatusSuccess
+	refund.TxHash = txHash
+
+	// 记账独立于订单状态，失败时由补记任务重试
+	settleRefundLedgerOrAlert(refund)
+	order, err := syncOrderRefunded(refund)
+	if err != nil {
+		log.Sugar.Errorf("[refund] 更新订单退款状态失败, refundNo=%s, txHash=%s, err=%v", refund.RefundNo, txHash, err)
+		notifyRefundReconcile(refund, txHash, "更新订单退款状态失败: "+err.Error())
+		return
+	}
+
+	// 退款回调
+	if order.NotifyUrl != "" {
+		if err := handle.EnqueueRefundCallback(refund.RefundNo); err != nil {
+			log.Sugar.Errorf("[refund] 投递回调失败, refundNo=%s, err=%v", refund.RefundNo, err)
+		}
+	}
+
+	msgTpl := `
+<b>✅ 退款成功!</b>
+<pre>退款单号: %s</pre>
+<pre>交易号: %s</pre>
+<pre>金额: %s USDT</pre>
+<pre>退款地址: %s</pre>
+<pre>TxHash: %s</pre>
+`
+	msg := fmt.Sprintf(msgTpl, refund.RefundNo, refund.TradeId, refund.Amount.StringFixed(4), refund.ToWallet, txHash)
+	telegram.SendToBot(msg)
+}
+
+// syncOrderRefunded 按成功的退款汇总更新订单退款金额与状态
+func syncOrderRefunded(refund *mdb.OrderRefund) (*mdb.Orders, error) {
+	tx := dao.Mdb.Begin()
+	order, err := data.GetOrderInfoByTradeIdWithTransaction(tx, refund.TradeId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	refunded, err := data.SumRefundAmountByStatus(tx, refund.TradeId, mdb.RefundStatusSuccess)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	status := mdb.StatusPartialRefunded
+	if refunded.GreaterThanOrEqual(getOrderPaidAmount(order)) {
+		status = mdb.StatusRefunded
+	}
+	if err := data.UpdateOrderRefundedWithTransaction(tx, refund.TradeId, refunded, status); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// failRefund 转账未发出，标记失败后单独记账退回冻结的余额
+func failRefund(refund *mdb.OrderRefund, reason string) {
+	err := transitRefund(dao.Mdb, refund.RefundNo, mdb.RefundStatusProcessing, mdb.RefundStatusFailed, map[string]interface{}{
+		"fail_reason": reason,
+	})
+	if err != nil {
+		log.Sugar.Errorf("[refund] 更新退款状态失败, refundNo=%s, err=%v", refund.RefundNo, err)
+		return
+	}
+	refund.Status = mdb.RefundStatusFailed
+	refund.FailReason = reason
+	settleRefundLedgerOrAlert(refund)
+}
+
+// settleRefundLedger 按退款结果记账：成功时从在途转出，失败时退回冻结的余额；记账失败时标记待补记，成功后清除标记
+func settleRefundLedger(refund *mdb.OrderRefund) error {
+	if refund.MerchantID == 0 {
+		return nil
+	}
+	var post func(tx *gorm.DB) error
+	switch refund.Status {
+	case mdb.RefundStatusSuccess:
+		post = func(tx *gorm.DB) error { return postRefundLedger(tx, refund) }
+	case mdb.RefundStatusFailed:
+		if refund.HoldAttempt == 0 {
+			return nil
+		}
+		post = func(tx *gorm.DB) error { return postRefundRelease(tx, refund) }
+	default:
+		return fmt.Errorf("退款状态 %d 无需记账", refund.Status)
+	}
+	err := commitLedger(post)
+	if pending := err != nil; pending != (refund.LedgerPending == 1) {
+		if markErr := data.UpdateRefundLedgerPending(refund.ID, pending); markErr != nil {
+			log.Sugar.Errorf("[refund] 更新待补记状态失败, refundNo=%s, err=%v", refund.RefundNo, markErr)
+		}
+	}
+	return err
+}
+
+// settleRefundLedgerOrAlert 记账失败时告警
+func settleRefundLedgerOrAlert(refund *mdb.OrderRefund) {
+	if err := settleRefundLedger(refund); err != nil {
+		log.Sugar.Errorf("[refund] 记账失败, refundNo=%s, txHash=%s, err=%v", refund.RefundNo, refund.TxHash, err)
+		notifyLedgerPending("退款", refund.RefundNo, refund.MerchantID, refund.Amount, err)
+	}
+}
+
+// notifyRefundReconcile 退款已在链上转出但本地状态未能完整更新，通知人工对账
+func notifyRefundReconcile(refund *mdb.OrderRefund, txHash, reason string) {
+	msgTpl := `
+<b>⚠️ 退款已转账但本地更新失败，请人工对账!</b>
+<pre>退款单号: %s</pre>
+<pre>交易号: %s</pre>
+<pre>金额: %s USDT</pre>
+<pre>TxHash: %s</pre>
+<pre>原因: %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, refund.RefundNo, refund.TradeId, refund.Amount.StringFixed(4), txHash, reason))
+}
+
+// refundTransfer 按链原路退回
+func refundTransfer(refund *mdb.OrderRefund) (string, error) {
+	companyPrivateKey := config.GetCompanyPrivateKey()
+	if companyPrivateKey == "" {
+		return "", errors.New("公司钱包私钥未配置")
+	}
+	if chain.IsTronChain(refund.Chain) {
+		return tronTransfer(refund.TokenSymbol, companyPrivateKey, refund.ToWallet, refund.Amount)
+	}
+	if chain.IsEvmChain(refund.Chain) {
+		return evm.Transfer(refund.Chain, refund.TokenSymbol, companyPrivateKey, refund.ToWallet, refund.Amount)
+	}
+	return "", errors.New("不支持的链")
+}
+
+// GetMerchantRefunds 获取商家退款记录
+func GetMerchantRefunds(merchantID uint64, page, pageSize int) ([]mdb.OrderRefund, int64, error) {
+	return data.GetRefundsByMerchantID(merchantID, page, pageSize)
+}
+
+// ListRefunds 获取退款记录（管理员）
+func ListRefunds(filter *data.ListFilter, q *page.Query) (*data.ListResult[mdb.OrderRefund], error) {
+	return data.ListRefunds(filter, q)
+}
+
+// generateRefundNo 退款单号，使用 UUID 避免多实例同一秒内发起退款时冲突
+func generateRefundNo() string {
+	return "R" + strings.ReplaceAll(uuid.NewV4().String(), "-", "")
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupRefundTest 建立退款相关表，创建有余额的商家及其已支付订单
+func setupRefundTest(t *testing.T, balance, paid int64) (*gorm.DB, *mdb.Merchant, *mdb.Orders) {
+	db := newTestDB(t, &mdb.Orders{}, &mdb.OrderRefund{}, &mdb.Merchant{},
+		&mdb.LedgerAccount{}, &mdb.LedgerJournal{}, &mdb.LedgerEntry{})
+	merchant := &mdb.Merchant{Username: "refund_test", ApiToken: "refund_test_token"}
+	require.NoError(t, db.Create(merchant).Error)
+	if balance > 0 {
+		_, err := AdjustMerchantBalance(merchant.ID, decimal.NewFromInt(balance), "期初", "test")
+		require.NoError(t, err)
+	}
+	order := &mdb.Orders{
+		TradeId:        "T1",
+		OrderId:        "O1",
+		ActualAmount:   decimal.NewFromInt(paid),
+		ReceivedAmount: decimal.NewFromInt(paid),
+		Token:          "0xwallet",
+		Chain:          "BSC",
+		TokenSymbol:    "USDT",
+		Status:         mdb.StatusPaySuccess,
+		FromAddress:    "0xpayer",
+		MerchantID:     merchant.ID,
+	}
+	require.NoError(t, db.Create(order).Error)
+	return db, merchant, order
+}
+
+// merchantLedgerBalance 商家科目余额
+func merchantLedgerBalance(t *testing.T, merchantID uint64, kind string) decimal.Decimal {
+	balance, err := data.GetLedgerBalanceByCode(mdb.MerchantLedgerCode(merchantID, kind))
+	require.NoError(t, err)
+	return balance
+}
+
+// TestCanTransitRefund 测试退款状态机允许的流转
+func TestCanTransitRefund(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from, to int
+		expected bool
+	}{
+		{"待处理转为转账中", mdb.RefundStatusPending, mdb.RefundStatusProcessing, true},
+		{"转账中成功", mdb.RefundStatusProcessing, mdb.RefundStatusSuccess, true},
+		{"转账中失败", mdb.RefundStatusProcessing, mdb.RefundStatusFailed, true},
+		{"失败后重试回到待处理", mdb.RefundStatusFailed, mdb.RefundStatusPending, true},
+		{"失败不能直接转账", mdb.RefundStatusFailed, mdb.RefundStatusProcessing, false},
+		{"待处理不能直接成功", mdb.RefundStatusPending, mdb.RefundStatusSuccess, false},
+		{"成功为终态", mdb.RefundStatusSuccess, mdb.RefundStatusFailed, false},
+		{"成功不能重试", mdb.RefundStatusSuccess, mdb.RefundStatusPending, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, canTransitRefund(tc.from, tc.to))
+		})
+	}
+}
+
+// TestReserveOrderRefund 测试发起退款：可退金额校验、冻结商家余额、余额不足拒绝
+func TestReserveOrderRefund(t *testing.T) {
+	_, merchant, order := setupRefundTest(t, 100, 80)
+	d := decimal.NewFromInt
+
+	// 按顺序执行，后面的用例依赖前面已发起的退款
+	testCases := []struct {
+		name          string
+		amount        int64
+		merchantID    uint64
+		wantErr       error
+		wantAnyErr    bool
+		wantAmount    int64
+		wantAvailable int64
+		wantRefunding int64
+	}{
+		{"其他商家的订单", 10, merchant.ID + 1, constant.OrderNotExists, false, 0, 100, 0},
+		{"超出可退金额", 90, merchant.ID, nil, true, 0, 100, 0},
+		{"部分退款", 30, merchant.ID, nil, false, 30, 70, 30},
+		{"退还全部剩余", 0, 0, nil, false, 50, 20, 80},
+		{"已无可退金额", 0, merchant.ID, nil, true, 0, 20, 80},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			refund, err := reserveOrderRefund(order.TradeId, d(tc.amount), "test", "test", tc.merchantID)
+			switch {
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			case tc.wantAnyErr:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.True(t, refund.Amount.Equal(d(tc.wantAmount)), "amount=%s", refund.Amount)
+				assert.Equal(t, merchant.ID, refund.MerchantID)
+				assert.Equal(t, 1, refund.HoldAttempt)
+				assert.Equal(t, mdb.RefundStatusPending, refund.Status)
+			}
+			assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantAvailable).Equal(d(tc.wantAvailable)))
+			assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantRefunding).Equal(d(tc.wantRefunding)))
+		})
+	}
+}
+
+// TestReserveOrderRefundInsufficientBalance 测试商家余额不足时不创建退款
+func TestReserveOrderRefundInsufficientBalance(t *testing.T) {
+	db, merchant, order := setupRefundTest(t, 10, 80)
+
+	_, err := reserveOrderRefund(order.TradeId, decimal.NewFromInt(50), "test", "test", merchant.ID)
+	assert.Error(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&mdb.OrderRefund{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+	assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantAvailable).Equal(decimal.NewFromInt(10)))
+}
+
+// TestExecuteRefundTransfer 测试退款转账结果：成功时从在途转出并更新订单，失败时退回余额，重试重新冻结
+func TestExecuteRefundTransfer(t *testing.T) {
+	d := decimal.NewFromInt
+	testCases := []struct {
+		name          string
+		amounts       []int64 // 依次发起并执行的退款
+		transferErr   error
+		wantRefund    int
+		wantOrder     int
+		wantRefunded  int64
+		wantAvailable int64
+		wantRefunding int64
+	}{
+		{"部分退款成功", []int64{30}, nil, mdb.RefundStatusSuccess, mdb.StatusPartialRefunded, 30, 70, 0},
+		{"分两次退完", []int64{30, 50}, nil, mdb.RefundStatusSuccess, mdb.StatusRefunded, 80, 20, 0},
+		{"转账失败退回余额", []int64{30}, errors.New("insufficient funds"), mdb.RefundStatusFailed, mdb.StatusPaySuccess, 0, 100, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, merchant, order := setupRefundTest(t, 100, 80)
+			setForTest(t, &sendRefundTransfer, func(refund *mdb.OrderRefund) (string, error) {
+				return "0xrefund" + refund.RefundNo, tc.transferErr
+			})
+
+			var refund *mdb.OrderRefund
+			for _, amount := range tc.amounts {
+				var err error
+				refund, err = reserveOrderRefund(order.TradeId, d(amount), "test", "test", merchant.ID)
+				require.NoError(t, err)
+				executeRefundTransfer(refund)
+			}
+
+			got, err := data.GetRefundByNo(refund.RefundNo)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantRefund, got.Status)
+			assert.Equal(t, 0, got.LedgerPending)
+			var gotOrder mdb.Orders
+			require.NoError(t, db.First(&gotOrder, order.ID).Error)
+			assert.Equal(t, tc.wantOrder, gotOrder.Status)
+			assert.True(t, gotOrder.RefundedAmount.Equal(d(tc.wantRefunded)), "refunded=%s", gotOrder.RefundedAmount)
+			assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantAvailable).Equal(d(tc.wantAvailable)))
+			assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantRefunding).Equal(d(tc.wantRefunding)))
+		})
+	}
+}
+
+// TestRetryOrderRefund 测试失败的退款重试时重新冻结余额，成功后从在途转出
+func TestRetryOrderRefund(t *testing.T) {
+	db, merchant, order := setupRefundTest(t, 100, 80)
+	setForTest(t, &sendRefundTransfer, func(refund *mdb.OrderRefund) (string, error) {
+		return "", errors.New("rpc unavailable")
+	})
+	refund, err := reserveOrderRefund(order.TradeId, decimal.NewFromInt(30), "test", "test", merchant.ID)
+	require.NoError(t, err)
+	executeRefundTransfer(refund)
+	assert.Error(t, RetryOrderRefund("R-not-exists"))
+
+	setForTest(t, &sendRefundTransfer, func(refund *mdb.OrderRefund) (string, error) {
+		return "0xretry", nil
+	})
+	require.NoError(t, RetryOrderRefund(refund.RefundNo))
+	// 重试的转账异步执行，订单退款金额最后更新
+	assert.Eventually(t, func() bool {
+		var gotOrder mdb.Orders
+		return db.First(&gotOrder, order.ID).Error == nil && gotOrder.Status == mdb.StatusPartialRefunded
+	}, 2*time.Second, 10*time.Millisecond)
+
+	got, err := data.GetRefundByNo(refund.RefundNo)
+	require.NoError(t, err)
+	assert.Equal(t, mdb.RefundStatusSuccess, got.Status)
+	assert.Equal(t, 2, got.HoldAttempt)
+	assert.Equal(t, "0xretry", got.TxHash)
+	assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantAvailable).Equal(decimal.NewFromInt(70)))
+	assert.True(t, merchantLedgerBalance(t, merchant.ID, mdb.LedgerMerchantRefunding).IsZero())
+	assert.EqualError(t, RetryOrderRefund(refund.RefundNo), "只能重试失败的退款")
+}
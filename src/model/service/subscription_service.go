@@ -0,0 +1,398 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/log"
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+// 每轮最多投递的到期订阅数
+const subscriptionDispatchBatch = 100
+
+// subscriptionBillingStaleMinutes 扣款中超过该时间仍未结束的订阅，按扣款单结果收尾
+const subscriptionBillingStaleMinutes = 30
+
+// CreateSubscription 商家基于有效授权创建订阅
+func CreateSubscription(merchantID uint64, req *request.SubscriptionRequest) (*mdb.Subscription, error) {
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil {
+		return nil, errors.New("商家不存在")
+	}
+	auth, err := data.GetAuthorizeByNo(req.AuthNo)
+	if err != nil {
+		return nil, errors.New("授权不存在")
+	}
+	if auth.MerchantWallet != merchant.WalletToken {
+		return nil, errors.New("无权使用该授权")
+	}
+	if auth.Status != mdb.AuthorizeStatusActive {
+		return nil, errors.New("授权未生效或已失效")
+	}
+	if req.IntervalCount <= 0 {
+		req.IntervalCount = 1
+	}
+	maxRetries := config.GetSubscriptionMaxRetries()
+	if req.MaxRetries != nil && *req.MaxRetries >= 0 {
+		maxRetries = *req.MaxRetries
+	}
+	retryInterval := req.RetryIntervalMinutes
+	if retryInterval <= 0 {
+		retryInterval = config.GetSubscriptionRetryInterval()
+	}
+	startTime := req.StartTime
+	if startTime < time.Now().Unix() {
+		startTime = time.Now().Unix()
+	}
+	sub := &mdb.Subscription{
+		SubscriptionNo:       generateSubscriptionNo(),
+		MerchantID:           merchantID,
+		AuthID:               auth.ID,
+		AuthNo:               auth.AuthNo,
+		PlanName:             req.PlanName,
+		AmountUsdt:           req.AmountUsdt.Round(4),
+		IntervalUnit:         req.IntervalUnit,
+		IntervalCount:        req.IntervalCount,
+		MaxRetries:           maxRetries,
+		RetryIntervalMinutes: retryInterval,
+		PeriodEnd:            startTime,
+		NextBillingTime:      startTime,
+		NotifyUrl:            req.NotifyUrl,
+		Status:               mdb.SubscriptionStatusActive,
+	}
+	if err = data.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// GetMerchantSubscriptions 获取商家订阅列表
+func GetMerchantSubscriptions(merchantID uint64, page, pageSize int, status int) ([]mdb.Subscription, int64, error) {
+	return data.GetMerchantSubscriptions(merchantID, page, pageSize, status)
+}
+
+// GetMerchantSubscription 获取商家订阅详情
+func GetMerchantSubscription(merchantID, id uint64) (*mdb.Subscription, error) {
+	sub, err := data.GetMerchantSubscription(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.ID <= 0 {
+		return nil, errors.New("订阅不存在")
+	}
+	return sub, nil
+}
+
+// CancelMerchantSubscription 商家取消订阅，已发起的扣款不受影响
+func CancelMerchantSubscription(merchantID, id uint64) (*mdb.Subscription, error) {
+	sub, err := GetMerchantSubscription(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == mdb.SubscriptionStatusCanceled {
+		return nil, errors.New("订阅已取消")
+	}
+	sub.Status = mdb.SubscriptionStatusCanceled
+	sub.CanceledAt = time.Now().Unix()
+	err = data.UpdateSubscriptionById(sub.ID, map[string]interface{}{
+		"status":      sub.Status,
+		"canceled_at": sub.CanceledAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifySubscriptionEvent(sub, mdb.WebhookEventSubscriptionCanceled, nil)
+	return sub, nil
+}
+
+// DispatchDueSubscriptions 为到期订阅投递扣款任务
+func DispatchDueSubscriptions() {
+	subs, err := data.GetDueSubscriptions(time.Now().Unix(), subscriptionDispatchBatch)
+	if err != nil {
+		log.Sugar.Errorf("[subscription] get due subscriptions err=%s", err)
+		return
+	}
+	for _, sub := range subs {
+		startedAt := time.Now()
+		ok, err := data.MarkSubscriptionBilling(sub.ID, startedAt.Unix())
+		if err != nil || !ok {
+			continue
+		}
+		deadline := startedAt.Add(subscriptionBillingStaleMinutes * time.Minute)
+		if err = handle.EnqueueSubscriptionCharge(sub.ID, deadline); err != nil {
+			log.Sugar.Errorf("[subscription] enqueue charge %s err=%s", sub.SubscriptionNo, err)
+			_ = data.UpdateSubscriptionById(sub.ID, map[string]interface{}{"billing": 0})
+		}
+	}
+}
+
+// ChargeSubscription 执行一次订阅扣款并按结果推进周期或进入催收
+func ChargeSubscription(subscriptionID uint64) error {
+	sub, err := data.GetSubscriptionById(subscriptionID)
+	if err != nil {
+		return err
+	}
+	// 扣款中状态已被收尾或重置时任务作废
+	if sub.ID <= 0 || sub.Billing != 1 {
+		return nil
+	}
+	if sub.Status != mdb.SubscriptionStatusActive && sub.Status != mdb.SubscriptionStatusPastDue {
+		return data.UpdateSubscriptionById(sub.ID, map[string]interface{}{"billing": 0})
+	}
+	auth, deduct, err := createSubscriptionDeduction(sub)
+	if err != nil {
+		return failSubscriptionCharge(sub.ID, nil, err.Error())
+	}
+	executeTransferFrom(auth, deduct)
+	deduct, err = data.GetDeductionByNo(deduct.DeductNo)
+	if err != nil {
+		return err
+	}
+	switch deduct.Status {
+	case mdb.DeductionStatusSuccess:
+		return settleSubscriptionCharge(sub.ID, deduct)
+	case mdb.DeductionStatusFailed:
+		return failSubscriptionCharge(sub.ID, deduct, deduct.FailReason)
+	}
+	// 链上结果未知时保持扣款中状态，避免重复扣款，需人工核对
+	msg := fmt.Sprintf("<b>⚠️ 订阅扣款状态未知，请人工核对</b>\n<pre>订阅: %s</pre>\n<pre>扣款单: %s</pre>", sub.SubscriptionNo, deduct.DeductNo)
+	telegram.SendToBot(msg)
+	return nil
+}
+
+// createSubscriptionDeduction 校验授权额度并创建本期扣款记录
+func createSubscriptionDeduction(sub *mdb.Subscription) (*mdb.KtvAuthorize, *mdb.KtvDeduction, error) {
+	authLock.Lock()
+	defer authLock.Unlock()
+
+	auth, err := data.GetAuthorizeByNo(sub.AuthNo)
+	if err != nil {
+		return nil, nil, errors.New("授权不存在")
+	}
+	if auth.Status != mdb.AuthorizeStatusActive {
+		return nil, nil, errors.New("授权未生效或已失效")
+	}
+	if auth.RemainingUsdt.LessThan(sub.AmountUsdt) {
+		return nil, nil, fmt.Errorf("授权余额不足，剩余 %s USDT，需要 %s USDT", auth.RemainingUsdt.StringFixed(2), sub.AmountUsdt.StringFixed(4))
+	}
+	amountCny := sub.AmountUsdt.Mul(decimal.NewFromFloat(config.GetUsdtRate())).Round(2)
+	fee, err := deductionFee(auth.MerchantWallet, sub.AmountUsdt)
+	if err != nil {
+		return nil, nil, err
+	}
+	deduct := &mdb.KtvDeduction{
+		DeductNo:    generateDeductNo(),
+		AuthID:      auth.ID,
+		AuthNo:      auth.AuthNo,
+		Password:    auth.Password,
+		AmountUsdt:  sub.AmountUsdt,
+		AmountCny:   amountCny,
+		FeeAmount:   fee,
+		Status:      mdb.DeductionStatusProcessing,
+		ProductInfo: sub.PlanName,
+		OperatorID:  fmt.Sprintf("subscription_%s", sub.SubscriptionNo),
+		DeductTime:  time.Now().Unix(),
+	}
+	if err = data.CreateDeduction(deduct); err != nil {
+		return nil, nil, err
+	}
+	// 先记下本期扣款单，扣款任务中断时据此收尾
+	if err = data.UpdateSubscriptionById(sub.ID, map[string]interface{}{"last_deduct_no": deduct.DeductNo}); err != nil {
+		log.Sugar.Errorf("[subscription] save last deduct %s err=%s", sub.SubscriptionNo, err)
+	}
+	return auth, deduct, nil
+}
+
+// ReclaimStaleSubscriptionBilling 收尾长时间处于扣款中的订阅：任务丢失或进程中断时扣款中标记不会被清除，
+// 按本次扣款单的结果推进周期或进入催收，未创建扣款单的直接解除扣款中状态等待重新投递
+func ReclaimStaleSubscriptionBilling() {
+	before := time.Now().Add(-subscriptionBillingStaleMinutes * time.Minute).Unix()
+	subs, err := data.GetStaleBillingSubscriptions(before, subscriptionDispatchBatch)
+	if err != nil {
+		log.Sugar.Errorf("[subscription] get stale billing subscriptions err=%s", err)
+		return
+	}
+	for i := range subs {
+		sub := &subs[i]
+		// 未记录开始时间的旧数据无法判断扣款单归属，需管理员核对后重置
+		if sub.BillingStartedAt == 0 {
+			log.Sugar.Warnf("[subscription] %s billing without start time, reset it manually", sub.SubscriptionNo)
+			continue
+		}
+		done, err := reconcileSubscriptionBilling(sub, false)
+		if err != nil {
+			log.Sugar.Errorf("[subscription] reclaim billing %s err=%s", sub.SubscriptionNo, err)
+			continue
+		}
+		if !done {
+			log.Sugar.Warnf("[subscription] %s deduction %s still processing", sub.SubscriptionNo, sub.LastDeductNo)
+		}
+	}
+}
+
+// ResetSubscriptionBilling 管理员重置卡在扣款中的订阅：扣款单已有结果时按结果收尾，
+// 否则直接解除扣款中状态，调用前需确认该扣款未在链上成功
+func ResetSubscriptionBilling(subscriptionNo string) (*mdb.Subscription, error) {
+	sub, err := data.GetSubscriptionByNo(subscriptionNo)
+	if err != nil {
+		return nil, err
+	}
+	if sub.ID <= 0 {
+		return nil, errors.New("订阅不存在")
+	}
+	if sub.Billing != 1 {
+		return nil, errors.New("订阅不在扣款中")
+	}
+	if _, err = reconcileSubscriptionBilling(sub, true); err != nil {
+		return nil, err
+	}
+	return data.GetSubscriptionById(sub.ID)
+}
+
+// reconcileSubscriptionBilling 按本次扣款单结果结束扣款中状态，返回是否已结束
+// 扣款单仍在处理中时，force 为 true 才解除扣款中状态
+func reconcileSubscriptionBilling(sub *mdb.Subscription, force bool) (bool, error) {
+	var deduct *mdb.KtvDeduction
+	if sub.LastDeductNo != "" {
+		last, err := data.GetDeductionByNo(sub.LastDeductNo)
+		if err != nil {
+			return false, err
+		}
+		// 早于本次扣款开始的扣款单已在上一期收尾
+		if last.ID > 0 && last.DeductTime >= sub.BillingStartedAt {
+			deduct = last
+		}
+	}
+	if deduct != nil {
+		switch deduct.Status {
+		case mdb.DeductionStatusSuccess:
+			return true, settleSubscriptionCharge(sub.ID, deduct)
+		case mdb.DeductionStatusFailed:
+			return true, failSubscriptionCharge(sub.ID, deduct, deduct.FailReason)
+		}
+		if !force {
+			return false, nil
+		}
+	}
+	return true, data.UpdateSubscriptionById(sub.ID, map[string]interface{}{"billing": 0})
+}
+
+// settleSubscriptionCharge 扣款成功，推进一个计费周期
+func settleSubscriptionCharge(subscriptionID uint64, deduct *mdb.KtvDeduction) error {
+	// 重新读取，扣款期间订阅可能已被取消
+	sub, err := data.GetSubscriptionById(subscriptionID)
+	if err != nil {
+		return err
+	}
+	sub.PeriodEnd = advanceSubscriptionPeriod(sub.PeriodEnd, sub.IntervalUnit, sub.IntervalCount)
+	sub.NextBillingTime = sub.PeriodEnd
+	sub.RetryCount = 0
+	sub.LastDeductNo = deduct.DeductNo
+	sub.LastFailReason = ""
+	if sub.Status != mdb.SubscriptionStatusCanceled {
+		sub.Status = mdb.SubscriptionStatusActive
+	}
+	err = data.UpdateSubscriptionById(sub.ID, map[string]interface{}{
+		"period_end":        sub.PeriodEnd,
+		"next_billing_time": sub.NextBillingTime,
+		"retry_count":       sub.RetryCount,
+		"last_deduct_no":    sub.LastDeductNo,
+		"last_fail_reason":  sub.LastFailReason,
+		"status":            sub.Status,
+		"billing":           0,
+	})
+	if err != nil {
+		return err
+	}
+	notifySubscriptionEvent(sub, mdb.WebhookEventSubscriptionCharged, deduct)
+	return nil
+}
+
+// failSubscriptionCharge 扣款失败，按重试策略进入逾期或停止扣款
+func failSubscriptionCharge(subscriptionID uint64, deduct *mdb.KtvDeduction, reason string) error {
+	sub, err := data.GetSubscriptionById(subscriptionID)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{
+		"last_fail_reason": reason,
+		"billing":          0,
+	}
+	if deduct != nil {
+		updates["last_deduct_no"] = deduct.DeductNo
+	}
+	if sub.Status == mdb.SubscriptionStatusCanceled {
+		return data.UpdateSubscriptionById(sub.ID, updates)
+	}
+	sub.LastFailReason = reason
+	sub.RetryCount++
+	event := mdb.WebhookEventSubscriptionPastDue
+	if sub.RetryCount > sub.MaxRetries {
+		sub.Status = mdb.SubscriptionStatusUnpaid
+		event = mdb.WebhookEventSubscriptionUnpaid
+	} else {
+		sub.Status = mdb.SubscriptionStatusPastDue
+		sub.NextBillingTime = time.Now().Add(time.Duration(sub.RetryIntervalMinutes) * time.Minute).Unix()
+	}
+	updates["retry_count"] = sub.RetryCount
+	updates["status"] = sub.Status
+	updates["next_billing_time"] = sub.NextBillingTime
+	if err = data.UpdateSubscriptionById(sub.ID, updates); err != nil {
+		return err
+	}
+	notifySubscriptionEvent(sub, event, deduct)
+	return nil
+}
+
+// notifySubscriptionEvent 向订阅通知地址及商户事件接收地址推送订阅事件
+func notifySubscriptionEvent(sub *mdb.Subscription, event string, deduct *mdb.KtvDeduction) {
+	notify := &response.SubscriptionNotifyResponse{
+		Event:           event,
+		SubscriptionNo:  sub.SubscriptionNo,
+		AuthNo:          sub.AuthNo,
+		PlanName:        sub.PlanName,
+		AmountUsdt:      sub.AmountUsdt,
+		FailReason:      sub.LastFailReason,
+		RetryCount:      sub.RetryCount,
+		PeriodEnd:       sub.PeriodEnd,
+		NextBillingTime: sub.NextBillingTime,
+		Status:          sub.Status,
+	}
+	if deduct != nil {
+		notify.DeductNo = deduct.DeductNo
+		notify.TxHash = deduct.TxHash
+	}
+	if err := handle.EnqueueSubscriptionNotify(sub.NotifyUrl, sub.MerchantID, notify); err != nil {
+		log.Sugar.Errorf("[subscription] enqueue notify %s err=%s", sub.SubscriptionNo, err)
+	}
+	publishWebhookEvent(sub.MerchantID, event, sub)
+}
+
+// advanceSubscriptionPeriod 计算下一个计费时间，按月计费时月末不溢出到下月
+func advanceSubscriptionPeriod(from int64, unit string, count int) int64 {
+	t := carbon.CreateFromTimestamp(from)
+	switch unit {
+	case mdb.SubscriptionIntervalDay:
+		t = t.AddDays(count)
+	case mdb.SubscriptionIntervalWeek:
+		t = t.AddWeeks(count)
+	default:
+		t = t.AddMonthsNoOverflow(count)
+	}
+	return t.Timestamp()
+}
+
+func generateSubscriptionNo() string {
+	return fmt.Sprintf("S%s%03d", time.Now().Format("20060102150405"), rand.Intn(1000))
+}
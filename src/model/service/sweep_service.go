@@ -0,0 +1,321 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/log"
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+// sweepGasTimeoutMinutes 补充 gas 后超过该时间仍未到账则放弃本次归集
+const sweepGasTimeoutMinutes = 30
+
+// sweepTxTimeoutMinutes 归集交易广播后超过该时间仍未出块则标记失败，交易可能已被节点丢弃
+const sweepTxTimeoutMinutes = 60
+
+// sweepWalletClient 归集使用的链上客户端
+var sweepWalletClient = getWalletClient
+
+// RunWalletSweep 收款钱包归集：推进进行中的归集，再为余额超过阈值的钱包发起新的归集
+func RunWalletSweep() {
+	if !config.IsSweepEnabled() {
+		return
+	}
+
+	openSweeps, err := data.GetOpenWalletSweeps()
+	if err != nil {
+		log.Sugar.Errorf("[sweep] 查询进行中的归集失败, err=%v", err)
+		return
+	}
+	busy := make(map[string]bool, len(openSweeps))
+	for i := range openSweeps {
+		sweep := &openSweeps[i]
+		progressWalletSweep(sweep)
+		if mdb.IsSweepOpen(sweep.Status) {
+			busy[sweepBusyKey(sweep.Chain, sweep.FromAddress)] = true
+		}
+	}
+
+	for _, info := range chain.GetAllChains() {
+		coldAddress := config.GetSweepColdAddress(chain.ConfigPrefix(info.Name))
+		if coldAddress == "" {
+			continue
+		}
+		if err := chain.ValidateAddress(info.Name, coldAddress); err != nil {
+			log.Sugar.Errorf("[sweep] %s 冷钱包地址无效, address=%s, err=%v", info.Name, coldAddress, err)
+			continue
+		}
+		client, err := sweepWalletClient(info.Name)
+		if err != nil {
+			continue
+		}
+		wallets, err := data.GetAvailableWalletAddressByChain(info.Name)
+		if err != nil {
+			log.Sugar.Errorf("[sweep] %s 查询收款钱包失败, err=%v", info.Name, err)
+			continue
+		}
+		for _, wallet := range wallets {
+			if strings.EqualFold(wallet.Token, coldAddress) || busy[sweepBusyKey(info.Name, wallet.Token)] {
+				continue
+			}
+			for _, token := range chain.GetEnabledTokens(info.Name) {
+				if startWalletSweep(client, info.Name, token.Symbol, wallet.Token, coldAddress) {
+					// 每个钱包同一时间只进行一笔归集，避免 nonce 冲突与重复补 gas
+					busy[sweepBusyKey(info.Name, wallet.Token)] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// startWalletSweep 检查钱包余额并发起归集，创建了归集记录时返回 true
+func startWalletSweep(client walletClient, chainName, tokenSymbol, wallet, coldAddress string) bool {
+	prefix := chain.ConfigPrefix(chainName)
+	privateKey, err := sweepPrivateKey(client, wallet)
+	if err != nil {
+		log.Sugar.Debugf("[sweep] %s 跳过钱包 %s: %v", chainName, wallet, err)
+		return false
+	}
+	balance, err := client.tokenBalance(tokenSymbol, wallet)
+	if err != nil {
+		log.Sugar.Errorf("[sweep] %s 查询 %s 余额失败, wallet=%s, err=%v", chainName, tokenSymbol, wallet, err)
+		return false
+	}
+	if balance.LessThan(decimal.NewFromFloat(config.GetSweepThreshold(prefix))) {
+		return false
+	}
+
+	requiredGas := decimal.NewFromFloat(config.GetSweepGasMin(prefix))
+	if !requiredGas.IsPositive() {
+		requiredGas, err = client.transferFee(tokenSymbol, wallet, coldAddress, balance)
+		if err != nil {
+			log.Sugar.Errorf("[sweep] %s 估算手续费失败, wallet=%s, err=%v", chainName, wallet, err)
+			return false
+		}
+	}
+	nativeBalance, err := client.nativeBalance(wallet)
+	if err != nil {
+		log.Sugar.Errorf("[sweep] %s 查询原生币余额失败, wallet=%s, err=%v", chainName, wallet, err)
+		return false
+	}
+
+	sweep := &mdb.WalletSweep{
+		SweepNo:     generateSweepNo(),
+		Chain:       chainName,
+		TokenSymbol: tokenSymbol,
+		FromAddress: wallet,
+		ToAddress:   coldAddress,
+		Amount:      balance,
+	}
+
+	if nativeBalance.LessThan(requiredGas) {
+		companyPrivateKey := config.GetCompanyPrivateKey()
+		if companyPrivateKey == "" {
+			log.Sugar.Warnf("[sweep] %s 钱包 %s gas 不足且公司钱包私钥未配置, 跳过归集", chainName, wallet)
+			return false
+		}
+		// 补足到所需数量的两倍，留出 gas 价格波动的余量
+		gasAmount := requiredGas.Mul(decimal.NewFromInt(2)).Sub(nativeBalance)
+		// 先保存归集记录再转出 gas，保证每笔公司钱包转账都有记录可查
+		sweep.Status = mdb.SweepStatusGasPending
+		sweep.GasAmount = gasAmount
+		if err := data.CreateWalletSweep(sweep); err != nil {
+			log.Sugar.Errorf("[sweep] 保存归集记录失败, sweepNo=%s, err=%v", sweep.SweepNo, err)
+			return false
+		}
+		gasTxHash, err := client.transferNative(companyPrivateKey, wallet, gasAmount)
+		if err != nil {
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, "补充 gas 失败: "+err.Error())
+			return true
+		}
+		sweep.GasTxHash = gasTxHash
+		if err := data.UpdateWalletSweep(sweep.ID, map[string]interface{}{"gas_tx_hash": gasTxHash}); err != nil {
+			log.Sugar.Errorf("[sweep] 更新归集记录失败, sweepNo=%s, gasTxHash=%s, err=%v", sweep.SweepNo, gasTxHash, err)
+		}
+		return true
+	}
+
+	txHash, err := client.transferToken(tokenSymbol, privateKey, coldAddress, balance)
+	if err != nil {
+		sweep.Status = mdb.SweepStatusFailed
+		sweep.FailReason = truncateFailReason(err.Error())
+		log.Sugar.Errorf("[sweep] %s 归集转账失败, wallet=%s, err=%v", chainName, wallet, err)
+	} else {
+		sweep.Status = mdb.SweepStatusSubmitted
+		sweep.TxHash = txHash
+	}
+	if err := data.CreateWalletSweep(sweep); err != nil {
+		log.Sugar.Errorf("[sweep] 保存归集记录失败, sweepNo=%s, txHash=%s, err=%v", sweep.SweepNo, txHash, err)
+	}
+	if sweep.Status == mdb.SweepStatusFailed {
+		notifyWalletSweep(sweep)
+	}
+	return true
+}
+
+// progressWalletSweep 推进进行中的归集
+func progressWalletSweep(sweep *mdb.WalletSweep) {
+	client, err := sweepWalletClient(sweep.Chain)
+	if err != nil {
+		finishWalletSweep(sweep, mdb.SweepStatusFailed, err.Error())
+		return
+	}
+
+	switch sweep.Status {
+	case mdb.SweepStatusGasPending:
+		// gas 转账结果未保存（转出后更新记录失败），超时后放弃，需人工核实公司钱包转账
+		if sweep.GasTxHash == "" {
+			if carbon.Now().Gt(sweep.CreatedAt.AddMinutes(sweepGasTimeoutMinutes)) {
+				finishWalletSweep(sweep, mdb.SweepStatusFailed, "补充 gas 交易哈希未记录，请核实公司钱包转账")
+			}
+			return
+		}
+		status, err := client.txStatus(sweep.GasTxHash)
+		if err != nil {
+			log.Sugar.Warnf("[sweep] 查询 gas 交易失败, sweepNo=%s, err=%v", sweep.SweepNo, err)
+			return
+		}
+		switch status {
+		case evm.TxStatusFailed:
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, "补充 gas 交易失败")
+			return
+		case evm.TxStatusPending:
+			if carbon.Now().Gt(sweep.CreatedAt.AddMinutes(sweepGasTimeoutMinutes)) {
+				finishWalletSweep(sweep, mdb.SweepStatusFailed, "补充 gas 超时未到账")
+			}
+			return
+		}
+
+		privateKey, err := sweepPrivateKey(client, sweep.FromAddress)
+		if err != nil {
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, err.Error())
+			return
+		}
+		// 以转账时的实际余额为准
+		balance, err := client.tokenBalance(sweep.TokenSymbol, sweep.FromAddress)
+		if err != nil {
+			log.Sugar.Warnf("[sweep] 查询余额失败, sweepNo=%s, err=%v", sweep.SweepNo, err)
+			return
+		}
+		if !balance.IsPositive() {
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, "钱包余额为0")
+			return
+		}
+		txHash, err := client.transferToken(sweep.TokenSymbol, privateKey, sweep.ToAddress, balance)
+		if err != nil {
+			sweep.Amount = balance
+			_ = data.UpdateWalletSweep(sweep.ID, map[string]interface{}{"amount": balance})
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, err.Error())
+			return
+		}
+		sweep.Amount = balance
+		sweep.TxHash = txHash
+		sweep.Status = mdb.SweepStatusSubmitted
+		if err := data.UpdateWalletSweep(sweep.ID, map[string]interface{}{
+			"amount":  balance,
+			"tx_hash": txHash,
+			"status":  mdb.SweepStatusSubmitted,
+		}); err != nil {
+			log.Sugar.Errorf("[sweep] 更新归集记录失败, sweepNo=%s, txHash=%s, err=%v", sweep.SweepNo, txHash, err)
+		}
+
+	case mdb.SweepStatusSubmitted:
+		status, err := client.txStatus(sweep.TxHash)
+		if err != nil {
+			log.Sugar.Warnf("[sweep] 查询归集交易失败, sweepNo=%s, err=%v", sweep.SweepNo, err)
+			return
+		}
+		switch status {
+		case evm.TxStatusSuccess:
+			finishWalletSweep(sweep, mdb.SweepStatusSuccess, "")
+		case evm.TxStatusFailed:
+			finishWalletSweep(sweep, mdb.SweepStatusFailed, "归集交易执行失败")
+		case evm.TxStatusPending:
+			// 广播时间以最后一次更新为准（先补 gas 的归集在 gas 到账后才广播）
+			if carbon.Now().Gt(sweep.UpdatedAt.AddMinutes(sweepTxTimeoutMinutes)) {
+				finishWalletSweep(sweep, mdb.SweepStatusFailed, "归集交易超时未确认，请在区块浏览器核实")
+			}
+		}
+	}
+}
+
+// finishWalletSweep 归集结束，更新状态并通知
+func finishWalletSweep(sweep *mdb.WalletSweep, status int, reason string) {
+	sweep.Status = status
+	sweep.FailReason = truncateFailReason(reason)
+	if err := data.UpdateWalletSweep(sweep.ID, map[string]interface{}{
+		"status":      status,
+		"fail_reason": sweep.FailReason,
+	}); err != nil {
+		log.Sugar.Errorf("[sweep] 更新归集记录失败, sweepNo=%s, err=%v", sweep.SweepNo, err)
+	}
+	notifyWalletSweep(sweep)
+}
+
+func notifyWalletSweep(sweep *mdb.WalletSweep) {
+	if sweep.Status == mdb.SweepStatusSuccess {
+		msgTpl := `
+<b>✅ 收款钱包归集成功!</b>
+<pre>归集单号: %s</pre>
+<pre>链: %s</pre>
+<pre>金额: %s %s</pre>
+<pre>来源: %s</pre>
+<pre>TxHash: %s</pre>
+`
+		telegram.SendToBot(fmt.Sprintf(msgTpl, sweep.SweepNo, sweep.Chain, sweep.Amount.StringFixed(4), sweep.TokenSymbol, sweep.FromAddress, sweep.TxHash))
+		return
+	}
+	msgTpl := `
+<b>❌ 收款钱包归集失败!</b>
+<pre>归集单号: %s</pre>
+<pre>链: %s</pre>
+<pre>金额: %s %s</pre>
+<pre>来源: %s</pre>
+<pre>原因: %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, sweep.SweepNo, sweep.Chain, sweep.Amount.StringFixed(4), sweep.TokenSymbol, sweep.FromAddress, sweep.FailReason))
+}
+
+// sweepPrivateKey 获取收款钱包私钥，并校验私钥与钱包地址一致
+func sweepPrivateKey(client walletClient, wallet string) (string, error) {
+	privateKey := config.GetMerchantPrivateKeyForWallet(wallet)
+	if privateKey == "" {
+		return "", errors.New("未配置钱包私钥")
+	}
+	address, err := client.address(privateKey)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(address, wallet) {
+		return "", errors.New("私钥与钱包地址不匹配")
+	}
+	return privateKey, nil
+}
+
+func sweepBusyKey(chainName, wallet string) string {
+	return chainName + ":" + strings.ToLower(wallet)
+}
+
+// truncateFailReason 失败原因截断到字段长度内
+func truncateFailReason(reason string) string {
+	if runes := []rune(reason); len(runes) > 200 {
+		return string(runes[:200])
+	}
+	return reason
+}
+
+func generateSweepNo() string {
+	return fmt.Sprintf("S%s%03d", time.Now().Format("20060102150405"), rand.Intn(1000))
+}
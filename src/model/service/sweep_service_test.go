@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	testSweepWallet = "0xsweepwallet"
+	testSweepCold   = "0xcoldwallet"
+)
+
+// fakeWalletClient 模拟链上操作
+type fakeWalletClient struct {
+	tokenBal     decimal.Decimal
+	nativeBal    decimal.Decimal
+	fee          decimal.Decimal
+	transferErr  error
+	gasErr       error
+	statuses     map[string]int
+	gasTransfers int
+}
+
+func (f *fakeWalletClient) address(privateKey string) (string, error) {
+	return testSweepWallet, nil
+}
+
+func (f *fakeWalletClient) tokenBalance(tokenSymbol, owner string) (decimal.Decimal, error) {
+	return f.tokenBal, nil
+}
+
+func (f *fakeWalletClient) nativeBalance(owner string) (decimal.Decimal, error) {
+	return f.nativeBal, nil
+}
+
+func (f *fakeWalletClient) transferFee(tokenSymbol, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return f.fee, nil
+}
+
+func (f *fakeWalletClient) transferToken(tokenSymbol, privateKey, to string, amount decimal.Decimal) (string, error) {
+	if f.transferErr != nil {
+		return "", f.transferErr
+	}
+	return "0xsweeptx", nil
+}
+
+func (f *fakeWalletClient) transferNative(privateKey, to string, amount decimal.Decimal) (string, error) {
+	f.gasTransfers++
+	if f.gasErr != nil {
+		return "", f.gasErr
+	}
+	return "0xgastx", nil
+}
+
+func (f *fakeWalletClient) txStatus(txHash string) (int, error) {
+	return f.statuses[txHash], nil
+}
+
+// setupSweepTest 内存数据库与钱包私钥配置，结束后还原
+func setupSweepTest(t *testing.T) *gorm.DB {
+	db := newTestDB(t, &mdb.WalletSweep{})
+	setForTest(t, &config.MerchantPrivateKeyMap, map[string]string{testSweepWallet: "wallet_key"})
+	setForTest(t, &config.CompanyPrivateKey, "company_key")
+	return db
+}
+
+// TestStartWalletSweep 测试发起归集：余额不足跳过，gas 不足先保存记录再补 gas
+func TestStartWalletSweep(t *testing.T) {
+	d := decimal.RequireFromString
+	testCases := []struct {
+		name         string
+		client       *fakeWalletClient
+		started      bool
+		status       int
+		gasTransfers int
+		gasTxHash    string
+		txHash       string
+	}{
+		{"余额未达阈值", &fakeWalletClient{tokenBal: d("50"), nativeBal: d("1"), fee: d("0.01")}, false, 0, 0, "", ""},
+		{"gas 充足直接归集", &fakeWalletClient{tokenBal: d("150"), nativeBal: d("1"), fee: d("0.01")}, true, mdb.SweepStatusSubmitted, 0, "", "0xsweeptx"},
+		{"归集转账失败", &fakeWalletClient{tokenBal: d("150"), nativeBal: d("1"), fee: d("0.01"), transferErr: errors.New("nonce too low")}, true, mdb.SweepStatusFailed, 0, "", ""},
+		{"gas 不足先补 gas", &fakeWalletClient{tokenBal: d("150"), nativeBal: d("0"), fee: d("0.01")}, true, mdb.SweepStatusGasPending, 1, "0xgastx", ""},
+		{"补 gas 失败保留记录", &fakeWalletClient{tokenBal: d("150"), nativeBal: d("0"), fee: d("0.01"), gasErr: errors.New("insufficient funds")}, true, mdb.SweepStatusFailed, 1, "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupSweepTest(t)
+			started := startWalletSweep(tc.client, "BSC", "USDT", testSweepWallet, testSweepCold)
+			assert.Equal(t, tc.started, started)
+			assert.Equal(t, tc.gasTransfers, tc.client.gasTransfers)
+
+			var sweeps []mdb.WalletSweep
+			require.NoError(t, db.Find(&sweeps).Error)
+			if !tc.started {
+				assert.Empty(t, sweeps)
+				return
+			}
+			require.Len(t, sweeps, 1)
+			assert.Equal(t, tc.status, sweeps[0].Status)
+			assert.Equal(t, tc.gasTxHash, sweeps[0].GasTxHash)
+			assert.Equal(t, tc.txHash, sweeps[0].TxHash)
+			if tc.gasTransfers > 0 {
+				// 补足到手续费的两倍
+				assert.True(t, sweeps[0].GasAmount.Equal(d("0.02")), "gas=%s", sweeps[0].GasAmount)
+			}
+		})
+	}
+}
+
+// TestProgressWalletSweep 测试推进归集：gas 到账后转账，交易确认、失败及超时
+func TestProgressWalletSweep(t *testing.T) {
+	d := decimal.RequireFromString
+	testCases := []struct {
+		name       string
+		status     int
+		gasTxHash  string
+		txHash     string
+		age        time.Duration // 记录创建与最后更新距今的时间
+		statuses   map[string]int
+		wantStatus int
+		wantTxHash string
+	}{
+		{"gas 已到账发起归集", mdb.SweepStatusGasPending, "0xgastx", "", time.Minute, map[string]int{"0xgastx": evm.TxStatusSuccess}, mdb.SweepStatusSubmitted, "0xsweeptx"},
+		{"gas 交易失败", mdb.SweepStatusGasPending, "0xgastx", "", time.Minute, map[string]int{"0xgastx": evm.TxStatusFailed}, mdb.SweepStatusFailed, ""},
+		{"gas 未到账继续等待", mdb.SweepStatusGasPending, "0xgastx", "", time.Minute, map[string]int{"0xgastx": evm.TxStatusPending}, mdb.SweepStatusGasPending, ""},
+		{"gas 超时未到账", mdb.SweepStatusGasPending, "0xgastx", "", 31 * time.Minute, map[string]int{"0xgastx": evm.TxStatusPending}, mdb.SweepStatusFailed, ""},
+		{"gas 哈希未记录等待超时", mdb.SweepStatusGasPending, "", "", time.Minute, nil, mdb.SweepStatusGasPending, ""},
+		{"gas 哈希未记录已超时", mdb.SweepStatusGasPending, "", "", 31 * time.Minute, nil, mdb.SweepStatusFailed, ""},
+		{"归集交易成功", mdb.SweepStatusSubmitted, "", "0xsweeptx", time.Minute, map[string]int{"0xsweeptx": evm.TxStatusSuccess}, mdb.SweepStatusSuccess, "0xsweeptx"},
+		{"归集交易执行失败", mdb.SweepStatusSubmitted, "", "0xsweeptx", time.Minute, map[string]int{"0xsweeptx": evm.TxStatusFailed}, mdb.SweepStatusFailed, "0xsweeptx"},
+		{"归集交易未确认继续等待", mdb.SweepStatusSubmitted, "", "0xsweeptx", 59 * time.Minute, map[string]int{"0xsweeptx": evm.TxStatusPending}, mdb.SweepStatusSubmitted, "0xsweeptx"},
+		{"归集交易超时未确认", mdb.SweepStatusSubmitted, "", "0xsweeptx", 61 * time.Minute, map[string]int{"0xsweeptx": evm.TxStatusPending}, mdb.SweepStatusFailed, "0xsweeptx"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupSweepTest(t)
+			client := &fakeWalletClient{tokenBal: d("150"), statuses: tc.statuses}
+			setForTest(t, &sweepWalletClient, func(chainName string) (walletClient, error) { return client, nil })
+
+			sweep := &mdb.WalletSweep{SweepNo: "S1", Chain: "BSC", TokenSymbol: "USDT", FromAddress: testSweepWallet,
+				ToAddress: testSweepCold, Amount: d("100"), GasTxHash: tc.gasTxHash, TxHash: tc.txHash, Status: tc.status}
+			require.NoError(t, db.Create(sweep).Error)
+			past := time.Now().Add(-tc.age)
+			require.NoError(t, db.Model(sweep).UpdateColumns(map[string]interface{}{"created_at": past, "updated_at": past}).Error)
+			require.NoError(t, db.First(sweep, sweep.ID).Error)
+
+			progressWalletSweep(sweep)
+
+			var got mdb.WalletSweep
+			require.NoError(t, db.First(&got, sweep.ID).Error)
+			assert.Equal(t, tc.wantStatus, got.Status)
+			assert.Equal(t, tc.wantTxHash, got.TxHash)
+			assert.Equal(t, got.Status == mdb.SweepStatusFailed, got.FailReason != "", "fail_reason=%s", got.FailReason)
+		})
+	}
+}
@@ -5,20 +5,13 @@ import (
 	"net/http"
 	"sync"
 
-	"github.com/assimon/luuu/config"
-	"github.com/spf13/viper"
-
-	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/request"
-	"github.com/assimon/luuu/mq"
-	"github.com/assimon/luuu/mq/handle"
-	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/json"
 	"github.com/assimon/luuu/util/log"
 	"github.com/dromara/carbon/v2"
 	"github.com/gookit/goutil/stdutil"
-	"github.com/hibiken/asynq"
 	"github.com/shopspring/decimal"
 )
 
@@ -72,6 +65,15 @@ func Trc20CallBack(token string, wg *sync.WaitGroup) {
 			log.Sugar.Error(err)
 		}
 	}()
+	for _, tokenInfo := range chain.GetEnabledTokens(chain.ChainTron) {
+		if err := trc20TokenCallBack(token, tokenInfo); err != nil {
+			log.Sugar.Errorf("[trc20] %s 入账查询失败, address=%s, err=%v", tokenInfo.Symbol, token, err)
+		}
+	}
+}
+
+// trc20TokenCallBack 查询钱包某个trc20代币的入账并处理
+func trc20TokenCallBack(token string, tokenInfo *chain.TokenInfo) error {
 	client := http_client.GetHttpClient()
 	startTime := carbon.Now().AddHours(-24).TimestampMilli()
 	endTime := carbon.Now().TimestampMilli()
@@ -81,81 +83,52 @@ func Trc20CallBack(token string, wg *sync.WaitGroup) {
 		"start":           "0",
 		"direction":       "2",
 		"db_version":      "1",
-		"trc20Id":         "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		"trc20Id":         tokenInfo.Contract,
 		"address":         token,
 		"start_timestamp": stdutil.ToString(startTime),
 		"end_timestamp":   stdutil.ToString(endTime),
 	}).Get(UsdtTrc20ApiUri)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	if resp.StatusCode() != http.StatusOK {
-		panic(err)
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode())
 	}
 	var trc20Resp UsdtTrc20Resp
 	err = json.Cjson.Unmarshal(resp.Body(), &trc20Resp)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	if trc20Resp.PageSize <= 0 {
-		return
+		return nil
 	}
+	// 同一交易内转入该地址的多笔转账按出现顺序编号
+	logIndexes := make(map[string]uint)
 	for _, transfer := range trc20Resp.Data {
 		if transfer.To != token || transfer.ContractRet != "SUCCESS" {
 			continue
 		}
+		logIndex := logIndexes[transfer.Hash]
+		logIndexes[transfer.Hash]++
 		decimalQuant, err := decimal.NewFromString(transfer.Amount)
 		if err != nil {
-			panic(err)
-		}
-		decimalDivisor := decimal.NewFromFloat(1000000)
-		amount := decimalQuant.Div(decimalDivisor).InexactFloat64()
-		tradeId, err := data.GetTradeIdByWalletAddressAndAmount(token, amount)
-		if err != nil {
-			panic(err)
+			return err
 		}
-		if tradeId == "" {
-			continue
-		}
-		order, err := data.GetOrderInfoByTradeId(tradeId)
-		if err != nil {
-			panic(err)
-		}
-		// 区块的确认时间必须在订单创建时间之后
-		createTime := order.CreatedAt.TimestampMilli()
-		if transfer.BlockTimestamp < createTime {
-			panic("Orders cannot actually be matched")
-		}
-		// 到这一步就完全算是支付成功了
+		amount := decimalQuant.Shift(-int32(tokenInfo.Decimals))
 		req := &request.OrderProcessingRequest{
 			Token:              token,
-			TradeId:            tradeId,
+			Chain:              chain.ChainTron,
+			TokenSymbol:        tokenInfo.Symbol,
 			Amount:             amount,
 			BlockTransactionId: transfer.Hash,
+			LogIndex:           logIndex,
+			FromAddress:        transfer.From,
+			BlockTimestamp:     transfer.BlockTimestamp,
+			BlockNumber:        uint64(transfer.Block),
 		}
-		err = OrderProcessing(req)
-		if err != nil {
-			panic(err)
+		if err = ProcessIncomingTransfer(req); err != nil {
+			log.Sugar.Errorf("[trc20] 入账处理失败, hash=%s, err=%v", transfer.Hash, err)
 		}
-		// 回调队列
-		orderCallbackQueue, _ := handle.NewOrderCallbackQueue(order)
-		orderNoticeMaxRetry := viper.GetInt("order_notice_max_retry")
-		mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(orderNoticeMaxRetry),
-			asynq.Retention(config.GetOrderExpirationTimeDuration()),
-		)
-		// mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(5))
-		// 发送机器人消息
-		msgTpl := `
-<b>📢📢有新的交易支付成功！</b>
-<pre>交易号：%s</pre>
-<pre>订单号：%s</pre>
-<pre>请求支付金额：%f cny</pre>
-<pre>实际支付金额：%f usdt</pre>
-<pre>钱包地址：%s</pre>
-<pre>订单创建时间：%s</pre>
-<pre>支付成功时间：%s</pre>
-`
-		msg := fmt.Sprintf(msgTpl, order.TradeId, order.OrderId, order.Amount, order.ActualAmount, order.Token, order.CreatedAt.ToDateTimeString(), carbon.Now().ToDateTimeString())
-		telegram.SendToBot(msg)
 	}
+	return nil
 }
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/tron"
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+)
+
+// trxDecimals TRX 精度（1 TRX = 1e6 sun）
+const trxDecimals = 6
+
+const (
+	tronGetAccountUri         = "https://api.trongrid.io/wallet/getaccount"
+	tronConstantContractUri   = "https://api.trongrid.io/wallet/triggerconstantcontract"
+	tronCreateTransactionUri  = "https://api.trongrid.io/wallet/createtransaction"
+	tronBroadcastUri          = "https://api.trongrid.io/wallet/broadcasttransaction"
+	tronGetTransactionInfoUri = "https://api.trongrid.io/wallet/gettransactioninfobyid"
+)
+
+// tronClient 带 API Key 的 TronGrid 请求
+func tronClient() *resty.Request {
+	req := http_client.GetHttpClient().R()
+	if apiKey := config.GetTrongridApiKey(); apiKey != "" {
+		req.SetHeader("TRON-PRO-API-KEY", apiKey)
+	}
+	return req
+}
+
+// tronTokenBalance 查询 TRC20 代币余额
+func tronTokenBalance(tokenSymbol, owner string) (decimal.Decimal, error) {
+	token, err := getTronToken(tokenSymbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	ownerHex, err := tron.AddressToHex(owner)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	var resp struct {
+		ConstantResult []string `json:"constant_result"`
+	}
+	_, err = tronClient().
+		SetBody(map[string]interface{}{
+			"owner_address":     owner,
+			"contract_address":  token.Contract,
+			"function_selector": "balanceOf(address)",
+			"parameter":         ownerHex,
+			"visible":           true,
+		}).
+		SetResult(&resp).
+		Post(tronConstantContractUri)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("查询余额失败: %v", err)
+	}
+	if len(resp.ConstantResult) == 0 || resp.ConstantResult[0] == "" {
+		return decimal.Zero, errors.New("查询余额失败: 无结果")
+	}
+	val, ok := new(big.Int).SetString(resp.ConstantResult[0], 16)
+	if !ok {
+		return decimal.Zero, errors.New("查询余额失败: 结果格式错误")
+	}
+	return evm.ToDecimalAmount(val, token.Decimals), nil
+}
+
+// tronNativeBalance 查询 TRX 余额，未激活的账户为 0
+func tronNativeBalance(owner string) (decimal.Decimal, error) {
+	var resp struct {
+		Balance int64 `json:"balance"`
+	}
+	_, err := tronClient().
+		SetBody(map[string]interface{}{"address": owner, "visible": true}).
+		SetResult(&resp).
+		Post(tronGetAccountUri)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("查询TRX余额失败: %v", err)
+	}
+	return decimal.New(resp.Balance, -trxDecimals), nil
+}
+
+// tronNativeTransfer TRX 转账（用于补充能量/带宽消耗）
+func tronNativeTransfer(privateKeyHex, to string, amount decimal.Decimal) (string, error) {
+	owner, err := tron.PrivateKeyToAddress(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+	var transaction map[string]interface{}
+	_, err = tronClient().
+		SetBody(map[string]interface{}{
+			"owner_address": owner,
+			"to_address":    to,
+			"amount":        evm.ToBaseUnits(amount, trxDecimals).Int64(),
+			"visible":       true,
+		}).
+		SetResult(&transaction).
+		Post(tronCreateTransactionUri)
+	if err != nil {
+		return "", fmt.Errorf("构建交易失败: %v", err)
+	}
+	if msg, ok := transaction["Error"].(string); ok {
+		return "", fmt.Errorf("构建交易失败: %s", msg)
+	}
+	txID, signature, err := tronLocalSign(transaction, privateKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("本地签名失败: %v", err)
+	}
+	transaction["signature"] = []string{signature}
+
+	var broadcastResp map[string]interface{}
+	_, err = tronClient().
+		SetBody(transaction).
+		SetResult(&broadcastResp).
+		Post(tronBroadcastUri)
+	if err != nil {
+		return "", fmt.Errorf("广播交易失败: %v", err)
+	}
+	if result, ok := broadcastResp["result"].(bool); !ok || !result {
+		if msg, ok := broadcastResp["message"].(string); ok {
+			if decoded, err := hex.DecodeString(msg); err == nil {
+				msg = string(decoded)
+			}
+			return "", fmt.Errorf("广播失败: %s", msg)
+		}
+		return "", errors.New("广播交易失败")
+	}
+	return txID, nil
+}
+
+// tronTransactionStatus 查询交易执行结果，未出块时返回 evm.TxStatusPending
+func tronTransactionStatus(txHash string) (int, error) {
+	var info struct {
+		Id      string `json:"id"`
+		Result  string `json:"result"`
+		Receipt struct {
+			Result string `json:"result"`
+		} `json:"receipt"`
+	}
+	_, err := tronClient().
+		SetBody(map[string]interface{}{"value": txHash}).
+		SetResult(&info).
+		Post(tronGetTransactionInfoUri)
+	if err != nil {
+		return evm.TxStatusPending, err
+	}
+	if info.Id == "" {
+		return evm.TxStatusPending, nil
+	}
+	if info.Result == "FAILED" || (info.Receipt.Result != "" && info.Receipt.Result != "SUCCESS") {
+		return evm.TxStatusFailed, nil
+	}
+	return evm.TxStatusSuccess, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/tron"
+	"github.com/shopspring/decimal"
+)
+
+// tronTransferFeeDefault TRON 转账预留的 TRX 数量（无能量时 TRC20 转账约消耗 14~28 TRX）
+var tronTransferFeeDefault = decimal.NewFromInt(30)
+
+// walletClient 钱包归集与余额监控所需的链上操作
+type walletClient interface {
+	address(privateKey string) (string, error)
+	tokenBalance(tokenSymbol, owner string) (decimal.Decimal, error)
+	nativeBalance(owner string) (decimal.Decimal, error)
+	transferFee(tokenSymbol, from, to string, amount decimal.Decimal) (decimal.Decimal, error)
+	transferToken(tokenSymbol, privateKey, to string, amount decimal.Decimal) (string, error)
+	transferNative(privateKey, to string, amount decimal.Decimal) (string, error)
+	txStatus(txHash string) (int, error)
+}
+
+type evmWalletClient struct {
+	chainName string
+}
+
+func (c evmWalletClient) address(privateKey string) (string, error) {
+	return evm.AddressFromPrivateKey(privateKey)
+}
+
+func (c evmWalletClient) tokenBalance(tokenSymbol, owner string) (decimal.Decimal, error) {
+	return evm.GetTokenBalance(c.chainName, tokenSymbol, owner)
+}
+
+func (c evmWalletClient) nativeBalance(owner string) (decimal.Decimal, error) {
+	return evm.GetNativeBalance(c.chainName, owner)
+}
+
+func (c evmWalletClient) transferFee(tokenSymbol, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return evm.EstimateTransferFee(c.chainName, tokenSymbol, from, to, amount)
+}
+
+func (c evmWalletClient) transferToken(tokenSymbol, privateKey, to string, amount decimal.Decimal) (string, error) {
+	return evm.Transfer(c.chainName, tokenSymbol, privateKey, to, amount)
+}
+
+func (c evmWalletClient) transferNative(privateKey, to string, amount decimal.Decimal) (string, error) {
+	return evm.TransferNative(c.chainName, privateKey, to, amount)
+}
+
+func (c evmWalletClient) txStatus(txHash string) (int, error) {
+	return evm.GetTransactionStatus(c.chainName, txHash)
+}
+
+type tronWalletClient struct{}
+
+func (tronWalletClient) address(privateKey string) (string, error) {
+	return tron.PrivateKeyToAddress(privateKey)
+}
+
+func (tronWalletClient) tokenBalance(tokenSymbol, owner string) (decimal.Decimal, error) {
+	return tronTokenBalance(tokenSymbol, owner)
+}
+
+func (tronWalletClient) nativeBalance(owner string) (decimal.Decimal, error) {
+	return tronNativeBalance(owner)
+}
+
+func (tronWalletClient) transferFee(tokenSymbol, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return tronTransferFeeDefault, nil
+}
+
+func (tronWalletClient) transferToken(tokenSymbol, privateKey, to string, amount decimal.Decimal) (string, error) {
+	return tronTransfer(tokenSymbol, privateKey, to, amount)
+}
+
+func (tronWalletClient) transferNative(privateKey, to string, amount decimal.Decimal) (string, error) {
+	return tronNativeTransfer(privateKey, to, amount)
+}
+
+func (tronWalletClient) txStatus(txHash string) (int, error) {
+	return tronTransactionStatus(txHash)
+}
+
+// getWalletClient 按链获取钱包客户端
+func getWalletClient(chainName string) (walletClient, error) {
+	switch {
+	case chain.IsTronChain(chainName):
+		return tronWalletClient{}, nil
+	case chain.IsEvmChain(chainName):
+		return evmWalletClient{chainName: chainName}, nil
+	}
+	return nil, errors.New("不支持的链")
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/log"
+	"github.com/shopspring/decimal"
+)
+
+// 内置的收款钱包选择策略
+const (
+	WalletStrategyRoundRobin   = "round_robin"   // 轮询
+	WalletStrategyLeastRecent  = "lru"           // 最久未使用优先
+	WalletStrategyLeastLocked  = "least_locked"  // 占用金额最少优先
+	WalletStrategyWeighted     = "weighted"      // 按钱包权重随机
+	WalletStrategyMerchantOnly = "merchant_only" // 仅使用商家自有钱包
+)
+
+// DefaultWalletStrategy 商家与链均未配置时使用的策略
+const DefaultWalletStrategy = WalletStrategyRoundRobin
+
+// 选择钱包的业务
+const (
+	walletPurposeOrder     = "order"
+	walletPurposeAuthorize = "authorize"
+)
+
+// WalletSelection 一次收款钱包选择的上下文
+type WalletSelection struct {
+	Purpose    string              // order:订单收款 authorize:授权扣款
+	MerchantID uint64              // 发起方商家，0 为平台
+	Chain      string              // 链
+	Wallets    []mdb.WalletAddress // 候选钱包
+}
+
+// WalletStrategy 收款钱包选择策略，返回按优先级排序的候选钱包，调用方依次尝试
+type WalletStrategy interface {
+	Name() string
+	Order(sel *WalletSelection) ([]mdb.WalletAddress, error)
+}
+
+// WalletFilter 策略可选实现，限定候选范围，用于判断链上是否有可用钱包（不产生轮询等副作用）
+type WalletFilter interface {
+	Filter(sel *WalletSelection) []mdb.WalletAddress
+}
+
+var (
+	walletStrategiesMu sync.RWMutex
+	walletStrategies   = map[string]WalletStrategy{}
+)
+
+func init() {
+	RegisterWalletStrategy(roundRobinStrategy{})
+	RegisterWalletStrategy(leastRecentStrategy{})
+	RegisterWalletStrategy(leastLockedStrategy{})
+	RegisterWalletStrategy(weightedStrategy{})
+	RegisterWalletStrategy(merchantOnlyStrategy{})
+}
+
+// RegisterWalletStrategy 注册钱包选择策略，同名覆盖
+func RegisterWalletStrategy(strategy WalletStrategy) {
+	walletStrategiesMu.Lock()
+	defer walletStrategiesMu.Unlock()
+	walletStrategies[strings.ToLower(strategy.Name())] = strategy
+}
+
+// GetWalletStrategy 按名称获取钱包选择策略
+func GetWalletStrategy(name string) (WalletStrategy, bool) {
+	walletStrategiesMu.RLock()
+	defer walletStrategiesMu.RUnlock()
+	strategy, ok := walletStrategies[strings.ToLower(strings.TrimSpace(name))]
+	return strategy, ok
+}
+
+// GetWalletStrategyNames 已注册的策略名称
+func GetWalletStrategyNames() []string {
+	walletStrategiesMu.RLock()
+	defer walletStrategiesMu.RUnlock()
+	names := make([]string, 0, len(walletStrategies))
+	for name := range walletStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpdateMerchantWalletStrategy 设置商家的钱包选择策略，空字符串表示跟随链配置
+func UpdateMerchantWalletStrategy(merchantID uint64, name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name != "" {
+		if _, ok := GetWalletStrategy(name); !ok {
+			return errors.New("不支持的钱包选择策略，可选: " + strings.Join(GetWalletStrategyNames(), ", "))
+		}
+	}
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil {
+		return err
+	}
+	if merchant.ID == 0 {
+		return errors.New("商家不存在")
+	}
+	return data.UpdateMerchantWalletStrategy(merchantID, name)
+}
+
+// UpdateWalletWeight 设置钱包权重，merchantID 大于 0 时只能修改自己的钱包
+func UpdateWalletWeight(walletID, merchantID uint64, weight int) error {
+	wallet, err := data.GetWalletAddressById(walletID)
+	if err != nil {
+		return err
+	}
+	if wallet.ID == 0 || (merchantID > 0 && wallet.MerchantID != merchantID) {
+		return errors.New("钱包不存在")
+	}
+	return data.UpdateWalletWeight(walletID, merchantID, weight)
+}
+
+// resolveWalletStrategy 商家配置优先，其次链配置，均未配置或无效时使用默认策略
+func resolveWalletStrategy(merchantID uint64, chainName string) WalletStrategy {
+	if merchantID > 0 {
+		merchant, err := data.GetMerchantByID(merchantID)
+		if err == nil && merchant.WalletStrategy != "" {
+			if strategy, ok := GetWalletStrategy(merchant.WalletStrategy); ok {
+				return strategy
+			}
+			log.Sugar.Warnf("[wallet-select] 商家 %d 配置的策略 %s 不存在, 使用链配置", merchantID, merchant.WalletStrategy)
+		}
+	}
+	if name := config.GetWalletStrategy(chain.ConfigPrefix(chainName)); name != "" {
+		if strategy, ok := GetWalletStrategy(name); ok {
+			return strategy
+		}
+		log.Sugar.Warnf("[wallet-select] %s 配置的策略 %s 不存在, 使用默认策略", chainName, name)
+	}
+	strategy, _ := GetWalletStrategy(DefaultWalletStrategy)
+	return strategy
+}
+
+// candidateWallets 按策略限定候选钱包，不改变顺序
+func candidateWallets(purpose string, merchantID uint64, chainName string, wallets []mdb.WalletAddress) []mdb.WalletAddress {
+	strategy := resolveWalletStrategy(merchantID, chainName)
+	if filter, ok := strategy.(WalletFilter); ok {
+		return filter.Filter(&WalletSelection{Purpose: purpose, MerchantID: merchantID, Chain: chainName, Wallets: wallets})
+	}
+	return wallets
+}
+
+// orderWalletsByStrategy 按配置的策略排序候选钱包，策略出错时保持原顺序
+func orderWalletsByStrategy(purpose string, merchantID uint64, chainName string, wallets []mdb.WalletAddress) ([]mdb.WalletAddress, string) {
+	strategy := resolveWalletStrategy(merchantID, chainName)
+	sel := &WalletSelection{Purpose: purpose, MerchantID: merchantID, Chain: chainName, Wallets: wallets}
+	ordered, err := strategy.Order(sel)
+	if err != nil {
+		log.Sugar.Warnf("[wallet-select] purpose=%s merchant=%d chain=%s strategy=%s 排序失败, 使用默认顺序, err=%v",
+			purpose, merchantID, chainName, strategy.Name(), err)
+		return wallets, strategy.Name()
+	}
+	return ordered, strategy.Name()
+}
+
+// recordWalletSelection 记录选择结果：更新最近使用时间并输出决策日志
+func recordWalletSelection(purpose string, merchantID uint64, chainName, strategy, ref string, ordered []mdb.WalletAddress, selected string) {
+	if err := data.MarkWalletUsed(chainName, selected); err != nil {
+		log.Sugar.Warnf("[wallet-select] 记录钱包使用时间失败, wallet=%s, err=%v", selected, err)
+	}
+	candidates := make([]string, len(ordered))
+	for i, w := range ordered {
+		candidates[i] = w.Token
+	}
+	log.Sugar.Infof("[wallet-select] purpose=%s ref=%s merchant=%d chain=%s strategy=%s candidates=[%s] selected=%s",
+		purpose, ref, merchantID, chainName, strategy, strings.Join(candidates, ","), selected)
+}
+
+// walletRoundRobinCounter 轮询计数器，按链与商家分别递增
+var walletRoundRobinCounter = data.NextWalletRoundRobin
+
+// roundRobinStrategy 轮询：每次选择从上次的下一个钱包开始
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Name() string { return WalletStrategyRoundRobin }
+
+func (roundRobinStrategy) Order(sel *WalletSelection) ([]mdb.WalletAddress, error) {
+	n := len(sel.Wallets)
+	if n <= 1 {
+		return sel.Wallets, nil
+	}
+	counter, err := walletRoundRobinCounter(fmt.Sprintf("%s:%d", sel.Chain, sel.MerchantID))
+	if err != nil {
+		return nil, err
+	}
+	// 按 ID 固定顺序后轮转，与数据库返回顺序无关
+	sorted := append([]mdb.WalletAddress(nil), sel.Wallets...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	start := int((counter - 1) % int64(n))
+	ordered := make([]mdb.WalletAddress, 0, n)
+	ordered = append(ordered, sorted[start:]...)
+	return append(ordered, sorted[:start]...), nil
+}
+
+// leastRecentStrategy 最久未被选中的钱包优先，从未使用的最先
+type leastRecentStrategy struct{}
+
+func (leastRecentStrategy) Name() string { return WalletStrategyLeastRecent }
+
+func (leastRecentStrategy) Order(sel *WalletSelection) ([]mdb.WalletAddress, error) {
+	lastUsed, err := data.GetWalletLastUsed(sel.Chain, walletTokens(sel.Wallets))
+	if err != nil {
+		return nil, err
+	}
+	ordered := append([]mdb.WalletAddress(nil), sel.Wallets...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return lastUsed[ordered[i].Token] < lastUsed[ordered[j].Token]
+	})
+	return ordered, nil
+}
+
+// leastLockedStrategy 待入账订单与生效授权占用金额最少的钱包优先
+type leastLockedStrategy struct{}
+
+func (leastLockedStrategy) Name() string { return WalletStrategyLeastLocked }
+
+func (leastLockedStrategy) Order(sel *WalletSelection) ([]mdb.WalletAddress, error) {
+	locked, err := data.GetWalletLockedAmounts(sel.Chain, walletTokens(sel.Wallets))
+	if err != nil {
+		return nil, err
+	}
+	ordered := append([]mdb.WalletAddress(nil), sel.Wallets...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return lockedAmount(locked, ordered[i].Token).LessThan(lockedAmount(locked, ordered[j].Token))
+	})
+	return ordered, nil
+}
+
+func lockedAmount(locked map[string]decimal.Decimal, token string) decimal.Decimal {
+	if amount, ok := locked[token]; ok {
+		return amount
+	}
+	return decimal.Zero
+}
+
+// weightedStrategy 按钱包权重随机排序，权重越大越靠前的概率越高，未设置或小于 1 的按 1 计
+type weightedStrategy struct{}
+
+func (weightedStrategy) Name() string { return WalletStrategyWeighted }
+
+func (weightedStrategy) Order(sel *WalletSelection) ([]mdb.WalletAddress, error) {
+	// 加权随机排列：每个钱包取 -ln(u)/w 作为键升序排列
+	keys := make(map[string]float64, len(sel.Wallets))
+	for _, w := range sel.Wallets {
+		weight := w.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		keys[w.Token] = -math.Log(1-rand.Float64()) / float64(weight)
+	}
+	ordered := append([]mdb.WalletAddress(nil), sel.Wallets...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return keys[ordered[i].Token] < keys[ordered[j].Token]
+	})
+	return ordered, nil
+}
+
+// merchantOnlyStrategy 只使用发起方自有的钱包（平台发起时只用平台钱包），没有时不回退到其他钱包
+type merchantOnlyStrategy struct{}
+
+func (merchantOnlyStrategy) Name() string { return WalletStrategyMerchantOnly }
+
+func (s merchantOnlyStrategy) Order(sel *WalletSelection) ([]mdb.WalletAddress, error) {
+	return s.Filter(sel), nil
+}
+
+func (merchantOnlyStrategy) Filter(sel *WalletSelection) []mdb.WalletAddress {
+	out := make([]mdb.WalletAddress, 0, len(sel.Wallets))
+	for _, w := range sel.Wallets {
+		if w.MerchantID == sel.MerchantID {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func walletTokens(wallets []mdb.WalletAddress) []string {
+	tokens := make([]string, len(wallets))
+	for i, w := range wallets {
+		tokens[i] = w.Token
+	}
+	return tokens
+}
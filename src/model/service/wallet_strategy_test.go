@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWallet 构造带ID的钱包
+func testWallet(id, merchantID uint64, token string, weight int) mdb.WalletAddress {
+	w := mdb.WalletAddress{Token: token, MerchantID: merchantID, Weight: weight}
+	w.ID = id
+	return w
+}
+
+func walletTokenList(wallets []mdb.WalletAddress) []string {
+	tokens := make([]string, len(wallets))
+	for i, w := range wallets {
+		tokens[i] = w.Token
+	}
+	return tokens
+}
+
+// TestRoundRobinStrategyOrder 测试轮询按钱包ID固定顺序后依次轮转
+func TestRoundRobinStrategyOrder(t *testing.T) {
+	var counter int64
+	var scopes []string
+	setForTest(t, &walletRoundRobinCounter, func(scope string) (int64, error) {
+		scopes = append(scopes, scope)
+		counter++
+		return counter, nil
+	})
+
+	wallets := []mdb.WalletAddress{testWallet(3, 0, "C", 0), testWallet(1, 0, "A", 0), testWallet(2, 0, "B", 0)}
+	sel := &WalletSelection{Purpose: walletPurposeOrder, MerchantID: 7, Chain: "BSC", Wallets: wallets}
+
+	testCases := []struct {
+		name     string
+		expected []string
+	}{
+		{"第1次", []string{"A", "B", "C"}},
+		{"第2次", []string{"B", "C", "A"}},
+		{"第3次", []string{"C", "A", "B"}},
+		{"第4次回到开头", []string{"A", "B", "C"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ordered, err := roundRobinStrategy{}.Order(sel)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, walletTokenList(ordered))
+		})
+	}
+	assert.Equal(t, []string{"BSC:7", "BSC:7", "BSC:7", "BSC:7"}, scopes)
+	// 候选钱包原顺序不变
+	assert.Equal(t, []string{"C", "A", "B"}, walletTokenList(wallets))
+}
+
+// TestRoundRobinStrategyEdgeCases 测试单个钱包不计数、计数失败时返回错误
+func TestRoundRobinStrategyEdgeCases(t *testing.T) {
+	calls := 0
+	setForTest(t, &walletRoundRobinCounter, func(scope string) (int64, error) {
+		calls++
+		return 0, errors.New("redis unavailable")
+	})
+
+	single := &WalletSelection{Chain: "TRON", Wallets: []mdb.WalletAddress{testWallet(1, 0, "A", 0)}}
+	ordered, err := roundRobinStrategy{}.Order(single)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, walletTokenList(ordered))
+	assert.Equal(t, 0, calls)
+
+	multi := &WalletSelection{Chain: "TRON", Wallets: []mdb.WalletAddress{testWallet(1, 0, "A", 0), testWallet(2, 0, "B", 0)}}
+	_, err = roundRobinStrategy{}.Order(multi)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestWeightedStrategyOrder 测试按权重随机排序：结果是候选钱包的排列，权重大的大概率排在前面
+func TestWeightedStrategyOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		wallets  []mdb.WalletAddress
+		heavy    string
+		minFirst int // 1000 次中 heavy 排在第一的最少次数
+	}{
+		{"权重悬殊", []mdb.WalletAddress{testWallet(1, 0, "light", 1), testWallet(2, 0, "heavy", 1000)}, "heavy", 950},
+		{"未设置权重按1计", []mdb.WalletAddress{testWallet(1, 0, "unset", 0), testWallet(2, 0, "heavy", 1000), testWallet(3, 0, "negative", -5)}, "heavy", 950},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sel := &WalletSelection{Chain: "BSC", Wallets: tc.wallets}
+			first := 0
+			for i := 0; i < 1000; i++ {
+				ordered, err := weightedStrategy{}.Order(sel)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, walletTokenList(tc.wallets), walletTokenList(ordered))
+				if ordered[0].Token == tc.heavy {
+					first++
+				}
+			}
+			assert.GreaterOrEqual(t, first, tc.minFirst)
+		})
+	}
+}
+
+// TestMerchantOnlyStrategyFilter 测试只保留发起方自有的钱包，保持原顺序
+func TestMerchantOnlyStrategyFilter(t *testing.T) {
+	wallets := []mdb.WalletAddress{
+		testWallet(1, 0, "platform", 0),
+		testWallet(2, 5, "m5-a", 0),
+		testWallet(3, 7, "m7", 0),
+		testWallet(4, 5, "m5-b", 0),
+	}
+	testCases := []struct {
+		name       string
+		merchantID uint64
+		expected   []string
+	}{
+		{"商家自有钱包", 5, []string{"m5-a", "m5-b"}},
+		{"平台发起只用平台钱包", 0, []string{"platform"}},
+		{"没有自有钱包时不回退", 9, []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sel := &WalletSelection{MerchantID: tc.merchantID, Chain: "TRON", Wallets: wallets}
+			assert.Equal(t, tc.expected, walletTokenList(merchantOnlyStrategy{}.Filter(sel)))
+			ordered, err := merchantOnlyStrategy{}.Order(sel)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, walletTokenList(ordered))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/util/log"
+)
+
+// normalizeWebhookEvents 校验订阅的事件类型并拼接为逗号分隔
+func normalizeWebhookEvents(events []string) (string, error) {
+	var list []string
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if e == mdb.WebhookEventAll {
+			return mdb.WebhookEventAll, nil
+		}
+		if !mdb.IsWebhookEventType(e) {
+			return "", fmt.Errorf("不支持的事件类型: %s", e)
+		}
+		list = append(list, e)
+	}
+	if len(list) == 0 {
+		return "", errors.New("至少订阅一个事件")
+	}
+	return strings.Join(list, ","), nil
+}
+
+// lookupWebhookHost 解析接收地址的域名，测试时可替换
+var lookupWebhookHost = net.LookupIP
+
+// validateWebhookUrl 接收地址只能是 http(s)，且不能指向内网、回环或链路本地地址，防止借回调访问内部服务
+func validateWebhookUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Hostname() == "" {
+		return errors.New("接收地址格式不正确")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("接收地址只支持 http 或 https")
+	}
+	ips := []net.IP{net.ParseIP(u.Hostname())}
+	if ips[0] == nil {
+		if ips, err = lookupWebhookHost(u.Hostname()); err != nil || len(ips) == 0 {
+			return errors.New("接收地址域名无法解析")
+		}
+	}
+	for _, ip := range ips {
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return errors.New("接收地址不能指向内网地址")
+		}
+	}
+	return nil
+}
+
+// generateWebhookSecret 生成签名密钥
+func generateWebhookSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// CreateWebhookEndpoint 商家注册事件接收地址
+func CreateWebhookEndpoint(merchantID uint64, req *request.WebhookEndpointRequest) (*mdb.WebhookEndpoint, error) {
+	if err := validateWebhookUrl(req.Url); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &mdb.WebhookEndpoint{
+		MerchantID:  merchantID,
+		Url:         req.Url,
+		Secret:      generateWebhookSecret(),
+		Events:      events,
+		Description: req.Description,
+		Status:      mdb.WebhookEndpointStatusEnable,
+	}
+	if err = data.CreateWebhookEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// UpdateWebhookEndpoint 商家更新事件接收地址
+func UpdateWebhookEndpoint(merchantID, id uint64, req *request.WebhookEndpointRequest) (*mdb.WebhookEndpoint, error) {
+	endpoint, err := data.GetMerchantWebhookEndpoint(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.ID <= 0 {
+		return nil, errors.New("接收地址不存在")
+	}
+	if err = validateWebhookUrl(req.Url); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	status := req.Status
+	if status != mdb.WebhookEndpointStatusEnable && status != mdb.WebhookEndpointStatusDisable {
+		status = endpoint.Status
+	}
+	err = data.UpdateMerchantWebhookEndpoint(merchantID, id, map[string]interface{}{
+		"url":         req.Url,
+		"events":      events,
+		"description": req.Description,
+		"status":      status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.GetMerchantWebhookEndpoint(merchantID, id)
+}
+
+// RollWebhookEndpointSecret 重新生成签名密钥
+func RollWebhookEndpointSecret(merchantID, id uint64) (*mdb.WebhookEndpoint, error) {
+	endpoint, err := data.GetMerchantWebhookEndpoint(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.ID <= 0 {
+		return nil, errors.New("接收地址不存在")
+	}
+	endpoint.Secret = generateWebhookSecret()
+	if err = data.UpdateMerchantWebhookEndpoint(merchantID, id, map[string]interface{}{"secret": endpoint.Secret}); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// DeleteWebhookEndpoint 商家删除事件接收地址
+func DeleteWebhookEndpoint(merchantID, id uint64) error {
+	endpoint, err := data.GetMerchantWebhookEndpoint(merchantID, id)
+	if err != nil {
+		return err
+	}
+	if endpoint.ID <= 0 {
+		return errors.New("接收地址不存在")
+	}
+	return data.DeleteMerchantWebhookEndpoint(merchantID, id)
+}
+
+// GetMerchantWebhookEndpoints 商家事件接收地址列表
+func GetMerchantWebhookEndpoints(merchantID uint64) ([]mdb.WebhookEndpoint, error) {
+	return data.GetMerchantWebhookEndpoints(merchantID)
+}
+
+// GetMerchantWebhookDeliveries 商家事件投递记录
+func GetMerchantWebhookDeliveries(merchantID uint64, page, pageSize int, endpointID uint64, eventType, eventId string) ([]mdb.WebhookDelivery, int64, error) {
+	return data.GetMerchantWebhookDeliveries(merchantID, page, pageSize, endpointID, eventType, eventId)
+}
+
+// publishWebhookEvent 投递商户事件，失败只记录日志不影响业务流程
+func publishWebhookEvent(merchantID uint64, eventType string, eventData interface{}) {
+	if err := handle.PublishWebhookEvent(merchantID, eventType, eventData); err != nil {
+		log.Sugar.Errorf("[webhook] publish %s merchant=%d err=%s", eventType, merchantID, err)
+	}
+}
+
+// publishAuthorizeEvent 投递授权相关事件，按商家收款钱包归属商家
+func publishAuthorizeEvent(auth *mdb.KtvAuthorize, eventType string, eventData interface{}) {
+	merchantID, err := data.GetMerchantIDByWallet(auth.MerchantWallet)
+	if err != nil || merchantID <= 0 {
+		return
+	}
+	publishWebhookEvent(merchantID, eventType, eventData)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateWebhookUrl 测试接收地址的协议与内网地址校验
+func TestValidateWebhookUrl(t *testing.T) {
+	hosts := map[string][]net.IP{
+		"hooks.example.com":    {net.ParseIP("93.184.216.34")},
+		"internal.example.com": {net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.8")},
+		"localhost":            {net.ParseIP("127.0.0.1")},
+	}
+	setForTest(t, &lookupWebhookHost, func(host string) ([]net.IP, error) {
+		if ips, ok := hosts[host]; ok {
+			return ips, nil
+		}
+		return nil, errors.New("no such host")
+	})
+
+	testCases := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"公网域名", "https://hooks.example.com/webhooks", false},
+		{"公网 IP", "http://93.184.216.34:8080/hook", false},
+		{"非 http 协议", "ftp://hooks.example.com/hook", true},
+		{"file 协议", "file:///etc/passwd", true},
+		{"回环地址", "http://127.0.0.1/hook", true},
+		{"localhost", "http://localhost:8000/hook", true},
+		{"内网地址", "https://192.168.1.10/hook", true},
+		{"链路本地地址", "http://169.254.169.254/latest/meta-data", true},
+		{"IPv6 回环", "http://[::1]/hook", true},
+		{"IPv6 链路本地", "http://[fe80::1]/hook", true},
+		{"未指定地址", "http://0.0.0.0/hook", true},
+		{"域名解析到内网", "https://internal.example.com/hook", true},
+		{"域名无法解析", "https://missing.example.com/hook", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateWebhookUrl(tc.url)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
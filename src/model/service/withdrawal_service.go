@@ -11,40 +11,56 @@ import (
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/chain"
 	"github.com/assimon/luuu/util/evm"
 	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // CreateMerchantWithdrawal 商家申请提现
-func CreateMerchantWithdrawal(merchantID uint64, amount float64, toWallet, chain string) (*mdb.MerchantWithdrawal, error) {
-	if amount <= 0 {
+func CreateMerchantWithdrawal(merchantID uint64, amount decimal.Decimal, toWallet, chainName, tokenSymbol string) (*mdb.MerchantWithdrawal, error) {
+	if !amount.IsPositive() {
 		return nil, errors.New("提现金额必须大于0")
 	}
 	if toWallet == "" {
 		return nil, errors.New("提现钱包地址不能为空")
 	}
-	if chain == "" {
-		chain = "BSC"
+	if chainName == "" {
+		chainName = chain.ChainBsc
+	}
+	tokenSymbol = chain.NormalizeTokenSymbol(tokenSymbol)
+	if !chain.IsTokenEnabled(chainName, tokenSymbol) {
+		return nil, errors.New("该链未启用此代币")
+	}
+
+	fee, err := CalculateFee(merchantID, mdb.FeeBizWithdrawal, amount)
+	if err != nil {
+		return nil, err
 	}
 
-	// 校验余额
+	// 校验余额，手续费另从余额扣除
 	balance, err := data.GetMerchantBalance(merchantID)
 	if err != nil {
 		return nil, errors.New("获取余额失败")
 	}
-	if balance < amount {
-		return nil, fmt.Errorf("余额不足，当前余额 %.4f USDT", balance)
+	if balance.LessThan(amount.Add(fee)) {
+		return nil, fmt.Errorf("余额不足，当前余额 %s USDT，提现需 %s USDT（含手续费 %s USDT）",
+			balance.StringFixed(4), amount.Add(fee).StringFixed(4), fee.StringFixed(4))
 	}
 
 	withdrawNo := generateWithdrawNo()
 
 	withdrawal := &mdb.MerchantWithdrawal{
-		WithdrawNo: withdrawNo,
-		MerchantID: merchantID,
-		Amount:     amount,
-		ToWallet:   toWallet,
-		Chain:      chain,
-		Status:     mdb.WithdrawalStatusPending,
+		WithdrawNo:  withdrawNo,
+		MerchantID:  merchantID,
+		Amount:      amount,
+		FeeAmount:   fee,
+		ToWallet:    toWallet,
+		Chain:       chainName,
+		TokenSymbol: tokenSymbol,
+		Status:      mdb.WithdrawalStatusPending,
 	}
 
 	if err := data.CreateWithdrawal(withdrawal); err != nil {
@@ -56,11 +72,12 @@ func CreateMerchantWithdrawal(merchantID uint64, amount float64, toWallet, chain
 <b>📤 新提现申请!</b>
 <pre>提现单号: %s</pre>
 <pre>商家ID: %d</pre>
-<pre>金额: %.4f USDT</pre>
+<pre>金额: %s %s</pre>
+<pre>手续费: %s %s</pre>
 <pre>目标钱包: %s</pre>
 <pre>链: %s</pre>
 `
-	msg := fmt.Sprintf(msgTpl, withdrawNo, merchantID, amount, toWallet, chain)
+	msg := fmt.Sprintf(msgTpl, withdrawNo, merchantID, amount.StringFixed(4), tokenSymbol, fee.StringFixed(4), tokenSymbol, toWallet, chainName)
 	telegram.SendToBot(msg)
 
 	return withdrawal, nil
@@ -81,13 +98,13 @@ func ApproveWithdrawal(withdrawNo, reviewedBy string) error {
 	if err != nil {
 		return errors.New("获取余额失败")
 	}
-	if balance < withdrawal.Amount {
-		return fmt.Errorf("商家余额不足，当前余额 %.4f USDT", balance)
+	if balance.LessThan(withdrawal.DebitAmount()) {
+		return fmt.Errorf("商家余额不足，当前余额 %s USDT", balance.StringFixed(4))
 	}
 
-	// 更新状态为"转账中"
+	// 更新状态为"转账中"，仅待审核状态可流转，并发审批时只有一个成功
 	tx := dao.Mdb.Begin()
-	err = data.UpdateWithdrawalStatus(tx, withdrawNo, map[string]interface{}{
+	transited, err := data.TransitWithdrawalStatus(tx, withdrawNo, mdb.WithdrawalStatusPending, map[string]interface{}{
 		"status":      mdb.WithdrawalStatusApproved,
 		"reviewed_by": reviewedBy,
 		"reviewed_at": time.Now().Unix(),
@@ -96,14 +113,24 @@ func ApproveWithdrawal(withdrawNo, reviewedBy string) error {
 		tx.Rollback()
 		return err
 	}
+	if !transited {
+		tx.Rollback()
+		return errors.New("提现状态已变更，请刷新后重试")
+	}
 
-	// 扣减商家余额
-	err = data.SubMerchantBalance(tx, withdrawal.MerchantID, withdrawal.Amount)
+	// 扣减商家可用余额，转入提现在途；已记过账说明提现已被处理过，不再转账
+	err = postWithdrawalHold(tx, withdrawal, reviewedBy)
 	if err != nil {
 		tx.Rollback()
+		if errors.Is(err, ErrLedgerInsufficient) {
+			return err
+		}
+		log.Sugar.Errorf("[withdrawal] 记账失败, withdrawNo=%s, err=%v", withdrawNo, err)
 		return errors.New("扣减余额失败")
 	}
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
 
 	// 异步执行链上转账
 	go executeWithdrawalTransfer(withdrawal)
@@ -122,7 +149,7 @@ func RejectWithdrawal(withdrawNo, reason, reviewedBy string) error {
 	}
 
 	tx := dao.Mdb.Begin()
-	err = data.UpdateWithdrawalStatus(tx, withdrawNo, map[string]interface{}{
+	transited, err := data.TransitWithdrawalStatus(tx, withdrawNo, mdb.WithdrawalStatusPending, map[string]interface{}{
 		"status":        mdb.WithdrawalStatusRejected,
 		"reject_reason": reason,
 		"reviewed_by":   reviewedBy,
@@ -132,6 +159,10 @@ func RejectWithdrawal(withdrawNo, reason, reviewedBy string) error {
 		tx.Rollback()
 		return err
 	}
+	if !transited {
+		tx.Rollback()
+		return errors.New("提现状态已变更，请刷新后重试")
+	}
 	tx.Commit()
 
 	// Telegram 通知
@@ -151,9 +182,9 @@ func GetMerchantWithdrawals(merchantID uint64, page, pageSize int) ([]mdb.Mercha
 	return data.GetWithdrawalsByMerchantID(merchantID, page, pageSize)
 }
 
-// GetAllWithdrawals 获取所有提现记录（管理员）
-func GetAllWithdrawals(page, pageSize int) ([]mdb.MerchantWithdrawal, int64, error) {
-	return data.GetAllWithdrawals(page, pageSize)
+// ListWithdrawals 获取提现记录（管理员）
+func ListWithdrawals(filter *data.ListFilter, q *page.Query) (*data.ListResult[mdb.MerchantWithdrawal], error) {
+	return data.ListWithdrawals(filter, q)
 }
 
 // executeWithdrawalTransfer 执行提现链上转账（用公司钱包私钥）
@@ -162,59 +193,113 @@ func executeWithdrawalTransfer(withdrawal *mdb.MerchantWithdrawal) {
 	if companyPrivateKey == "" {
 		log.Sugar.Errorf("[withdrawal] 公司钱包私钥未配置, withdrawNo=%s", withdrawal.WithdrawNo)
 		// 标记失败，退还余额
-		tx := dao.Mdb.Begin()
-		_ = data.UpdateWithdrawalStatus(tx, withdrawal.WithdrawNo, map[string]interface{}{
-			"status":        mdb.WithdrawalStatusRejected,
-			"reject_reason": "公司钱包私钥未配置",
-		})
-		_ = data.AddMerchantBalance(tx, withdrawal.MerchantID, withdrawal.Amount)
-		tx.Commit()
+		failWithdrawal(withdrawal, "公司钱包私钥未配置")
+		publishWebhookEvent(withdrawal.MerchantID, mdb.WebhookEventWithdrawalFailed, withdrawal)
 		return
 	}
 
 	// 使用 EVM 转账（BSC/ETH/Polygon）
-	txHash, err := evm.Transfer(withdrawal.Chain, companyPrivateKey, withdrawal.ToWallet, withdrawal.Amount)
+	txHash, err := evm.Transfer(withdrawal.Chain, withdrawal.TokenSymbol, companyPrivateKey, withdrawal.ToWallet, withdrawal.Amount)
 	if err != nil {
 		log.Sugar.Errorf("[withdrawal] 转账失败, withdrawNo=%s, err=%v", withdrawal.WithdrawNo, err)
 		// 标记失败，退还余额
-		tx := dao.Mdb.Begin()
-		_ = data.UpdateWithdrawalStatus(tx, withdrawal.WithdrawNo, map[string]interface{}{
-			"status":        mdb.WithdrawalStatusRejected,
-			"reject_reason": fmt.Sprintf("转账失败: %s", err.Error()),
-		})
-		_ = data.AddMerchantBalance(tx, withdrawal.MerchantID, withdrawal.Amount)
-		tx.Commit()
+		failWithdrawal(withdrawal, fmt.Sprintf("转账失败: %s", err.Error()))
 
 		// 通知
 		msgTpl := `
 <b>❌ 提现转账失败!</b>
 <pre>提现单号: %s</pre>
-<pre>金额: %.4f USDT</pre>
+<pre>金额: %s USDT</pre>
 <pre>原因: %s</pre>
 `
-		msg := fmt.Sprintf(msgTpl, withdrawal.WithdrawNo, withdrawal.Amount, err.Error())
+		msg := fmt.Sprintf(msgTpl, withdrawal.WithdrawNo, withdrawal.Amount.StringFixed(4), err.Error())
 		telegram.SendToBot(msg)
+		publishWebhookEvent(withdrawal.MerchantID, mdb.WebhookEventWithdrawalFailed, withdrawal)
 		return
 	}
 
-	// 转账成功
-	tx := dao.Mdb.Begin()
-	_ = data.UpdateWithdrawalStatus(tx, withdrawal.WithdrawNo, map[string]interface{}{
+	// 转账成功：先提交提现状态，再单独记账，记账失败时由补记任务重试
+	withdrawal.Status = mdb.WithdrawalStatusCompleted
+	withdrawal.TxHash = txHash
+	err = data.UpdateWithdrawalStatus(dao.Mdb, withdrawal.WithdrawNo, map[string]interface{}{
 		"status":  mdb.WithdrawalStatusCompleted,
 		"tx_hash": txHash,
 	})
-	tx.Commit()
+	if err != nil {
+		log.Sugar.Errorf("[withdrawal] 更新提现状态失败, withdrawNo=%s, txHash=%s, err=%v", withdrawal.WithdrawNo, txHash, err)
+		notifyWithdrawalReconcile(withdrawal, "更新提现完成状态失败: "+err.Error())
+		return
+	}
+	settleWithdrawalLedgerOrAlert(withdrawal)
 
 	// 通知
 	msgTpl := `
 <b>✅ 提现转账成功!</b>
 <pre>提现单号: %s</pre>
-<pre>金额: %.4f USDT</pre>
+<pre>金额: %s USDT</pre>
 <pre>目标: %s</pre>
 <pre>TxHash: %s</pre>
 `
-	msg := fmt.Sprintf(msgTpl, withdrawal.WithdrawNo, withdrawal.Amount, withdrawal.ToWallet, txHash)
+	msg := fmt.Sprintf(msgTpl, withdrawal.WithdrawNo, withdrawal.Amount.StringFixed(4), withdrawal.ToWallet, txHash)
 	telegram.SendToBot(msg)
+	publishWebhookEvent(withdrawal.MerchantID, mdb.WebhookEventWithdrawalCompleted, withdrawal)
+}
+
+// failWithdrawal 转账未发出，标记失败后单独记账退回余额
+func failWithdrawal(withdrawal *mdb.MerchantWithdrawal, reason string) {
+	withdrawal.Status = mdb.WithdrawalStatusRejected
+	withdrawal.RejectReason = reason
+	err := data.UpdateWithdrawalStatus(dao.Mdb, withdrawal.WithdrawNo, map[string]interface{}{
+		"status":        mdb.WithdrawalStatusRejected,
+		"reject_reason": reason,
+	})
+	if err != nil {
+		log.Sugar.Errorf("[withdrawal] 更新提现状态失败, withdrawNo=%s, err=%v", withdrawal.WithdrawNo, err)
+		notifyWithdrawalReconcile(withdrawal, "更新提现失败状态失败: "+err.Error())
+		return
+	}
+	settleWithdrawalLedgerOrAlert(withdrawal)
+}
+
+// notifyWithdrawalReconcile 提现转账已结束但本地状态未能更新，通知人工对账
+func notifyWithdrawalReconcile(withdrawal *mdb.MerchantWithdrawal, reason string) {
+	msgTpl := `
+<b>⚠️ 提现状态更新失败，请人工对账!</b>
+<pre>提现单号: %s</pre>
+<pre>商家ID: %d</pre>
+<pre>金额: %s USDT</pre>
+<pre>TxHash: %s</pre>
+<pre>原因: %s</pre>
+`
+	telegram.SendToBot(fmt.Sprintf(msgTpl, withdrawal.WithdrawNo, withdrawal.MerchantID, withdrawal.Amount.StringFixed(4), withdrawal.TxHash, reason))
+}
+
+// settleWithdrawalLedger 按提现结果记账：完成时转出在途，失败时退回余额；记账失败时标记待补记，成功后清除标记
+func settleWithdrawalLedger(withdrawal *mdb.MerchantWithdrawal) error {
+	var post func(tx *gorm.DB) error
+	switch withdrawal.Status {
+	case mdb.WithdrawalStatusCompleted:
+		post = func(tx *gorm.DB) error { return postWithdrawalPaid(tx, withdrawal) }
+	case mdb.WithdrawalStatusRejected:
+		post = func(tx *gorm.DB) error { return postWithdrawalRelease(tx, withdrawal, withdrawal.RejectReason) }
+	default:
+		return fmt.Errorf("提现状态 %d 无需记账", withdrawal.Status)
+	}
+	err := commitLedger(post)
+	if pending := err != nil; pending != (withdrawal.LedgerPending == 1) {
+		if markErr := data.UpdateWithdrawalLedgerPending(withdrawal.ID, pending); markErr != nil {
+			log.Sugar.Errorf("[withdrawal] 更新待补记状态失败, withdrawNo=%s, err=%v", withdrawal.WithdrawNo, markErr)
+		}
+	}
+	return err
+}
+
+// settleWithdrawalLedgerOrAlert 记账失败时告警
+func settleWithdrawalLedgerOrAlert(withdrawal *mdb.MerchantWithdrawal) {
+	if err := settleWithdrawalLedger(withdrawal); err != nil {
+		log.Sugar.Errorf("[withdrawal] 记账失败, withdrawNo=%s, err=%v", withdrawal.WithdrawNo, err)
+		notifyLedgerPending("提现", withdrawal.WithdrawNo, withdrawal.MerchantID, withdrawal.DebitAmount(), err)
+	}
 }
 
 func generateWithdrawNo() string {
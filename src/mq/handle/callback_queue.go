@@ -17,6 +17,14 @@ import (
 
 const QueueOrderCallback = "order:callback"
 
+// client 任务投递客户端，由 mq 启动时注入
+var client *asynq.Client
+
+// SetClient 注入任务投递客户端
+func SetClient(c *asynq.Client) {
+	client = c
+}
+
 func NewOrderCallbackQueue(order *mdb.Orders) (*asynq.Task, error) {
 	payload, err := json.Cjson.Marshal(order)
 	if err != nil {
@@ -27,64 +35,90 @@ func NewOrderCallbackQueue(order *mdb.Orders) (*asynq.Task, error) {
 	), nil
 }
 
+// EnqueueOrderCallback 投递订单回调任务
+func EnqueueOrderCallback(order *mdb.Orders) error {
+	if order.NotifyUrl == "" {
+		return nil
+	}
+	orderCallbackQueue, err := NewOrderCallbackQueue(order)
+	if err != nil {
+		return err
+	}
+	_, err = client.Enqueue(orderCallbackQueue, callbackMaxRetry(),
+		asynq.Retention(config.GetOrderExpirationTimeDuration()),
+	)
+	return err
+}
+
 func OrderCallbackHandle(ctx context.Context, t *asynq.Task) error {
 	var order mdb.Orders
 	err := json.Cjson.Unmarshal(t.Payload(), &order)
 	if err != nil {
 		return err
 	}
+	return deliverOrderCallback(ctx, &order, 0)
+}
+
+// deliverOrderCallback 向订单 notify_url 发送回调，parentId 为手动重发时最初的回调日志
+func deliverOrderCallback(ctx context.Context, order *mdb.Orders, parentId uint64) error {
+	if order.NotifyUrl == "" {
+		return nil
+	}
 	defer func() {
 		if err := recover(); err != nil {
 			log.Sugar.Error(err)
 		}
 	}()
 	defer func() {
-		data.SaveCallBackOrdersResp(&order)
+		data.SaveCallBackOrdersResp(order)
 	}()
-	client := http_client.GetHttpClient()
+	httpClient := http_client.GetHttpClient()
 	orderResp := response.OrderNotifyResponse{
 		TradeId:            order.TradeId,
 		OrderId:            order.OrderId,
 		Amount:             order.Amount,
+		Currency:           order.Currency,
+		UsdtRate:           order.UsdtRate,
 		ActualAmount:       order.ActualAmount,
+		ReceivedAmount:     order.ReceivedAmount,
 		Token:              order.Token,
 		Chain:              order.Chain,
+		TokenSymbol:        order.TokenSymbol,
 		BlockTransactionId: order.BlockTransactionId,
-		Status:             mdb.StatusPaySuccess,
+		Status:             order.Status,
 	}
-	signature, err := sign.Get(orderResp, config.GetApiAuthToken())
+	merchantID, _ := data.GetOrderMerchantID(order)
+	merchant := callbackMerchant(merchantID)
+	signature, err := sign.Get(orderResp, merchantSignKey(merchant))
 	if err != nil {
 		return err
 	}
 	orderResp.Signature = signature
-	resp, err := client.R().SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").SetBody(orderResp).Post(order.NotifyUrl)
-	if err != nil {
-		_ = data.CreateCallbackLog(&mdb.CallbackLog{
-			TradeId:      order.TradeId,
-			OrderId:      order.OrderId,
-			NotifyUrl:    order.NotifyUrl,
-			RequestBody:  string(jsonBytes(orderResp)),
-			ResponseBody: "",
-			StatusCode:   0,
-			Success:      0,
-			ErrorMessage: err.Error(),
-		})
-		return err
-	}
-	body := string(resp.Body())
-	_ = data.CreateCallbackLog(&mdb.CallbackLog{
+	rule := merchantAckRule(merchant)
+	callbackLog := &mdb.CallbackLog{
 		TradeId:      order.TradeId,
 		OrderId:      order.OrderId,
+		CallbackType: mdb.CallbackTypeOrder,
+		BizNo:        order.TradeId,
+		MerchantID:   merchantID,
 		NotifyUrl:    order.NotifyUrl,
 		RequestBody:  string(jsonBytes(orderResp)),
-		ResponseBody: body,
-		StatusCode:   resp.StatusCode(),
-		Success:      boolToInt(body == "ok"),
-		ErrorMessage: "",
-	})
-	if body != "ok" {
+		AckRule:      rule.String(),
+	}
+	resp, err := httpClient.R().SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").SetBody(orderResp).Post(order.NotifyUrl)
+	if err != nil {
+		callbackLog.ErrorMessage = err.Error()
+		saveCallbackLog(ctx, callbackLog, parentId)
+		return err
+	}
+	body := string(resp.Body())
+	callbackLog.ResponseBody = body
+	callbackLog.StatusCode = resp.StatusCode()
+	callbackLog.Success = boolToInt(rule.Match(callbackLog.StatusCode, body))
+	saveCallbackLog(ctx, callbackLog, parentId)
+	if callbackLog.Success == 0 {
 		order.CallBackConfirm = mdb.CallBackConfirmNo
-		return errors.New("not ok")
+		return errors.New("not acknowledged")
 	}
 	order.CallBackConfirm = mdb.CallBackConfirmOk
 	return nil
@@ -0,0 +1,130 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/ack"
+	"github.com/assimon/luuu/util/json"
+	"github.com/hibiken/asynq"
+)
+
+const QueueCallbackRedeliver = "callback:redeliver"
+
+// CallbackRetryDelay 回调类任务按配置的退避时间表重试，其余任务沿用 asynq 默认策略
+func CallbackRetryDelay(n int, e error, t *asynq.Task) time.Duration {
+	switch t.Type() {
+	case QueueOrderCallback, QueueRefundCallback, QueueSubscriptionNotify, QueueWebhookDelivery, QueueCallbackRedeliver:
+		return config.GetCallbackRetryDelay(n)
+	}
+	return asynq.DefaultRetryDelayFunc(n, e, t)
+}
+
+// callbackMaxRetry 回调类任务的最大重试次数
+func callbackMaxRetry() asynq.Option {
+	return asynq.MaxRetry(len(config.GetCallbackRetrySchedule()))
+}
+
+// attemptInfo 当前是第几次尝试，以及失败后是否不再重试
+func attemptInfo(ctx context.Context) (attempt int, final bool) {
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	return retried + 1, retried >= maxRetry
+}
+
+// saveCallbackLog 记录一次回调尝试，最后一次重试仍失败时标记为死信
+func saveCallbackLog(ctx context.Context, callbackLog *mdb.CallbackLog, parentId uint64) {
+	attempt, final := attemptInfo(ctx)
+	callbackLog.Attempt = attempt
+	callbackLog.ParentId = parentId
+	if callbackLog.Success == 0 && final {
+		callbackLog.DeadLetter = 1
+	}
+	_ = data.CreateCallbackLog(callbackLog)
+}
+
+// EnqueueCallbackRedelivery 手动重发回调，重发记录关联到最初的回调日志
+func EnqueueCallbackRedelivery(callbackLog *mdb.CallbackLog) error {
+	rootId := callbackLog.ID
+	if callbackLog.ParentId > 0 {
+		rootId = callbackLog.ParentId
+	}
+	task := asynq.NewTask(QueueCallbackRedeliver, []byte(strconv.FormatUint(rootId, 10)))
+	_, err := client.Enqueue(task, callbackMaxRetry())
+	return err
+}
+
+// CallbackRedeliverHandle 按最初回调日志的类型重新投递最新的业务数据
+func CallbackRedeliverHandle(ctx context.Context, t *asynq.Task) error {
+	rootId, err := strconv.ParseUint(string(t.Payload()), 10, 64)
+	if err != nil {
+		return err
+	}
+	root, err := data.GetCallbackLogById(rootId)
+	if err != nil {
+		return err
+	}
+	if root.ID <= 0 {
+		return nil
+	}
+	switch root.CallbackType {
+	// 早期日志未记录回调类型，均为订单回调
+	case mdb.CallbackTypeOrder, "":
+		order, err := data.GetOrderInfoByTradeId(root.TradeId)
+		if err != nil {
+			return err
+		}
+		if order.ID <= 0 {
+			return nil
+		}
+		return deliverOrderCallback(ctx, order, root.ID)
+	case mdb.CallbackTypeRefund:
+		return deliverRefundCallback(ctx, root.BizNo, root.ID)
+	case mdb.CallbackTypeSubscription:
+		var notify response.SubscriptionNotifyResponse
+		if err = json.Cjson.Unmarshal([]byte(root.RequestBody), &notify); err != nil {
+			return err
+		}
+		return deliverSubscriptionNotify(ctx, root.NotifyUrl, &notify, root.MerchantID, root.ID)
+	}
+	return errors.New("unknown callback type")
+}
+
+// callbackMerchant 回调所属商家，未归属或查询失败时返回 nil
+func callbackMerchant(merchantID uint64) *mdb.Merchant {
+	if merchantID <= 0 {
+		return nil
+	}
+	merchant, err := data.GetMerchantByID(merchantID)
+	if err != nil {
+		return nil
+	}
+	return merchant
+}
+
+// merchantSignKey 回调签名密钥，商家回调使用商家自己的 API 密钥
+func merchantSignKey(merchant *mdb.Merchant) string {
+	if merchant == nil || merchant.ApiToken == "" {
+		return config.GetApiAuthToken()
+	}
+	return merchant.ApiToken
+}
+
+// merchantAckRule 商户配置的回调确认规则，未归属商户或未配置时要求响应 ok
+func merchantAckRule(merchant *mdb.Merchant) ack.Rule {
+	if merchant == nil {
+		return ack.Rule{}
+	}
+	return ack.Rule{
+		Mode:      merchant.CallbackAckMode,
+		Body:      merchant.CallbackAckBody,
+		JsonPath:  merchant.CallbackAckJsonPath,
+		JsonValue: merchant.CallbackAckJsonValue,
+	}
+}
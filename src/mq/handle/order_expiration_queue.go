@@ -24,16 +24,27 @@ func OrderExpirationHandle(ctx context.Context, t *asynq.Task) error {
 	if err != nil {
 		return err
 	}
-	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
+	if orderInfo.ID <= 0 || !mdb.IsOrderPending(orderInfo.Status) {
 		return nil
 	}
-	err = data.UpdateOrderIsExpirationById(orderInfo.ID)
-	if err != nil {
-		return err
+	// 部分支付的订单保留状态并通知商户实收金额，未支付的订单置为过期
+	if orderInfo.Status == mdb.StatusWaitPay {
+		err = data.UpdateOrderIsExpirationById(orderInfo.ID)
+		if err != nil {
+			return err
+		}
+		orderInfo.Status = mdb.StatusExpired
+		PublishOrderEvent(orderInfo, mdb.WebhookEventOrderExpired)
 	}
-	err = data.UnLockTransaction(orderInfo.Token, orderInfo.ActualAmount)
-	if err != nil {
-		return err
+	// 未选择链的订单没有锁定钱包
+	if orderInfo.Token != "" {
+		err = data.UnLockTransaction(orderInfo.Token, orderInfo.TokenSymbol, orderInfo.TradeId, orderInfo.ActualAmount)
+		if err != nil {
+			return err
+		}
+	}
+	if orderInfo.Status == mdb.StatusPartiallyPaid {
+		return EnqueueOrderCallback(orderInfo)
 	}
 	return nil
 }
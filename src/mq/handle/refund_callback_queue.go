@@ -0,0 +1,113 @@
+package handle
+
+import (
+	"context"
+	"errors"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/sign"
+	"github.com/hibiken/asynq"
+)
+
+const QueueRefundCallback = "refund:callback"
+
+func NewRefundCallbackQueue(refundNo string) (*asynq.Task, error) {
+	return asynq.NewTask(QueueRefundCallback, []byte(refundNo),
+		asynq.Retention(config.GetOrderExpirationTimeDuration()),
+	), nil
+}
+
+// EnqueueRefundCallback 投递退款回调任务
+func EnqueueRefundCallback(refundNo string) error {
+	refundCallbackQueue, err := NewRefundCallbackQueue(refundNo)
+	if err != nil {
+		return err
+	}
+	_, err = client.Enqueue(refundCallbackQueue, callbackMaxRetry(),
+		asynq.Retention(config.GetOrderExpirationTimeDuration()),
+	)
+	return err
+}
+
+// RefundCallbackHandle 退款成功通知商户
+func RefundCallbackHandle(ctx context.Context, t *asynq.Task) error {
+	return deliverRefundCallback(ctx, string(t.Payload()), 0)
+}
+
+// deliverRefundCallback 向订单 notify_url 发送退款通知，parentId 为手动重发时最初的回调日志
+func deliverRefundCallback(ctx context.Context, refundNo string, parentId uint64) error {
+	refund, err := data.GetRefundByNo(refundNo)
+	if err != nil {
+		return err
+	}
+	if refund.ID <= 0 || refund.Status != mdb.RefundStatusSuccess {
+		return nil
+	}
+	order, err := data.GetOrderInfoByTradeId(refund.TradeId)
+	if err != nil {
+		return err
+	}
+	if order.ID <= 0 || order.NotifyUrl == "" {
+		return nil
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Sugar.Error(err)
+		}
+	}()
+	defer func() {
+		data.SaveRefundCallbackResp(refund)
+	}()
+	httpClient := http_client.GetHttpClient()
+	refundResp := response.RefundNotifyResponse{
+		TradeId:        order.TradeId,
+		OrderId:        order.OrderId,
+		RefundNo:       refund.RefundNo,
+		RefundAmount:   refund.Amount,
+		RefundedAmount: order.RefundedAmount,
+		ToWallet:       refund.ToWallet,
+		Chain:          refund.Chain,
+		TxHash:         refund.TxHash,
+		Status:         order.Status,
+	}
+	merchantID, _ := data.GetOrderMerchantID(order)
+	merchant := callbackMerchant(merchantID)
+	signature, err := sign.Get(refundResp, merchantSignKey(merchant))
+	if err != nil {
+		return err
+	}
+	refundResp.Signature = signature
+	rule := merchantAckRule(merchant)
+	callbackLog := &mdb.CallbackLog{
+		TradeId:      order.TradeId,
+		OrderId:      order.OrderId,
+		CallbackType: mdb.CallbackTypeRefund,
+		BizNo:        refund.RefundNo,
+		MerchantID:   merchantID,
+		NotifyUrl:    order.NotifyUrl,
+		RequestBody:  string(jsonBytes(refundResp)),
+		AckRule:      rule.String(),
+	}
+	resp, err := httpClient.R().SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").SetBody(refundResp).Post(order.NotifyUrl)
+	if err != nil {
+		callbackLog.ErrorMessage = err.Error()
+		saveCallbackLog(ctx, callbackLog, parentId)
+		return err
+	}
+	body := string(resp.Body())
+	callbackLog.ResponseBody = body
+	callbackLog.StatusCode = resp.StatusCode()
+	callbackLog.Success = boolToInt(rule.Match(callbackLog.StatusCode, body))
+	saveCallbackLog(ctx, callbackLog, parentId)
+	if callbackLog.Success == 0 {
+		refund.CallBackConfirm = mdb.CallBackConfirmNo
+		return errors.New("not acknowledged")
+	}
+	refund.CallBackConfirm = mdb.CallBackConfirmOk
+	return nil
+}
@@ -0,0 +1,132 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/sign"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	QueueSubscriptionCharge = "subscription:charge"
+	QueueSubscriptionNotify = "subscription:notify"
+)
+
+// subscriptionCharger 订阅扣款执行函数，由服务层启动时注入
+var subscriptionCharger func(subscriptionID uint64) error
+
+// SetSubscriptionCharger 注入订阅扣款执行函数
+func SetSubscriptionCharger(f func(subscriptionID uint64) error) {
+	subscriptionCharger = f
+}
+
+// EnqueueSubscriptionCharge 投递订阅扣款任务，失败重试由订阅催收策略控制
+// 超过 deadline 仍未执行的任务不再执行，由扣款中状态的收尾逻辑重新投递
+func EnqueueSubscriptionCharge(subscriptionID uint64, deadline time.Time) error {
+	task := asynq.NewTask(QueueSubscriptionCharge, []byte(strconv.FormatUint(subscriptionID, 10)))
+	_, err := client.Enqueue(task, asynq.MaxRetry(0), asynq.Deadline(deadline))
+	return err
+}
+
+// SubscriptionChargeHandle 执行订阅扣款
+func SubscriptionChargeHandle(ctx context.Context, t *asynq.Task) error {
+	subscriptionID, err := strconv.ParseUint(string(t.Payload()), 10, 64)
+	if err != nil {
+		return err
+	}
+	if subscriptionCharger == nil {
+		return errors.New("subscription charger not set")
+	}
+	return subscriptionCharger(subscriptionID)
+}
+
+// EnqueueSubscriptionNotify 投递订阅事件通知任务
+func EnqueueSubscriptionNotify(notifyUrl string, merchantID uint64, notify *response.SubscriptionNotifyResponse) error {
+	if notifyUrl == "" {
+		return nil
+	}
+	payload, err := json.Cjson.Marshal(subscriptionNotifyPayload{
+		NotifyUrl:  notifyUrl,
+		MerchantID: merchantID,
+		Notify:     notify,
+	})
+	if err != nil {
+		return err
+	}
+	task := asynq.NewTask(QueueSubscriptionNotify, payload)
+	_, err = client.Enqueue(task, callbackMaxRetry(),
+		asynq.Retention(config.GetOrderExpirationTimeDuration()),
+	)
+	return err
+}
+
+type subscriptionNotifyPayload struct {
+	NotifyUrl  string                               `json:"notify_url"`
+	MerchantID uint64                               `json:"merchant_id"`
+	Notify     *response.SubscriptionNotifyResponse `json:"notify"`
+}
+
+// SubscriptionNotifyHandle 订阅事件通知商户
+func SubscriptionNotifyHandle(ctx context.Context, t *asynq.Task) error {
+	var payload subscriptionNotifyPayload
+	if err := json.Cjson.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+	if payload.Notify == nil {
+		return nil
+	}
+	return deliverSubscriptionNotify(ctx, payload.NotifyUrl, payload.Notify, payload.MerchantID, 0)
+}
+
+// deliverSubscriptionNotify 发送订阅事件通知，parentId 为手动重发时最初的回调日志
+func deliverSubscriptionNotify(ctx context.Context, notifyUrl string, notify *response.SubscriptionNotifyResponse, merchantID, parentId uint64) error {
+	if notifyUrl == "" {
+		return nil
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Sugar.Error(err)
+		}
+	}()
+	merchant := callbackMerchant(merchantID)
+	body := *notify
+	signature, err := sign.Get(body, merchantSignKey(merchant))
+	if err != nil {
+		return err
+	}
+	body.Signature = signature
+	rule := merchantAckRule(merchant)
+	callbackLog := &mdb.CallbackLog{
+		CallbackType: mdb.CallbackTypeSubscription,
+		BizNo:        body.SubscriptionNo,
+		Event:        body.Event,
+		MerchantID:   merchantID,
+		NotifyUrl:    notifyUrl,
+		RequestBody:  string(jsonBytes(body)),
+		AckRule:      rule.String(),
+	}
+	resp, err := http_client.GetHttpClient().R().SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").SetBody(body).Post(notifyUrl)
+	if err != nil {
+		callbackLog.ErrorMessage = err.Error()
+		saveCallbackLog(ctx, callbackLog, parentId)
+		return err
+	}
+	respBody := string(resp.Body())
+	callbackLog.ResponseBody = respBody
+	callbackLog.StatusCode = resp.StatusCode()
+	callbackLog.Success = boolToInt(rule.Match(callbackLog.StatusCode, respBody))
+	saveCallbackLog(ctx, callbackLog, parentId)
+	if callbackLog.Success == 0 {
+		return errors.New("not acknowledged")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package handle
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/sign"
+	"github.com/hibiken/asynq"
+)
+
+const QueueWebhookDelivery = "webhook:delivery"
+
+// WebhookApiVersion 事件信封版本，信封结构变化时递增
+const WebhookApiVersion = "v1"
+
+// 事件通知请求头
+const (
+	WebhookHeaderId        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+type webhookDeliveryPayload struct {
+	EndpointID uint64 `json:"endpoint_id"`
+	EventId    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Body       string `json:"body"`
+	ParentId   uint64 `json:"parent_id"`
+}
+
+// PublishWebhookEvent 向商户订阅了该事件的全部接收地址投递事件
+func PublishWebhookEvent(merchantID uint64, eventType string, eventData interface{}) error {
+	if merchantID <= 0 {
+		return nil
+	}
+	endpoints, err := data.GetEnabledWebhookEndpoints(merchantID)
+	if err != nil {
+		return err
+	}
+	event := response.WebhookEvent{
+		Id:         generateWebhookEventId(),
+		Type:       eventType,
+		ApiVersion: WebhookApiVersion,
+		CreatedAt:  time.Now().Unix(),
+		Data:       eventData,
+	}
+	body, err := json.Cjson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(eventType) {
+			continue
+		}
+		payload, err := json.Cjson.Marshal(webhookDeliveryPayload{
+			EndpointID: endpoint.ID,
+			EventId:    event.Id,
+			EventType:  eventType,
+			Body:       string(body),
+		})
+		if err != nil {
+			return err
+		}
+		task := asynq.NewTask(QueueWebhookDelivery, payload)
+		if _, err = client.Enqueue(task, callbackMaxRetry()); err != nil {
+			log.Sugar.Errorf("[webhook] enqueue event=%s endpoint=%d err=%s", event.Id, endpoint.ID, err)
+		}
+	}
+	return nil
+}
+
+// WebhookDeliveryHandle 投递事件到商户接收地址，非 2xx 响应视为失败并重试
+func WebhookDeliveryHandle(ctx context.Context, t *asynq.Task) error {
+	var payload webhookDeliveryPayload
+	if err := json.Cjson.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+	endpoint, err := data.GetWebhookEndpointById(payload.EndpointID)
+	if err != nil {
+		return err
+	}
+	// 接收地址已删除或停用时不再投递
+	if endpoint.ID <= 0 || endpoint.Status != mdb.WebhookEndpointStatusEnable {
+		return nil
+	}
+	attempt, final := attemptInfo(ctx)
+	delivery := &mdb.WebhookDelivery{
+		EventId:     payload.EventId,
+		EventType:   payload.EventType,
+		EndpointID:  endpoint.ID,
+		MerchantID:  endpoint.MerchantID,
+		Url:         endpoint.Url,
+		Attempt:     attempt,
+		RequestBody: payload.Body,
+		ParentId:    payload.ParentId,
+	}
+	timestamp := time.Now().Unix()
+	start := time.Now()
+	resp, err := http_client.GetHttpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(WebhookHeaderId, payload.EventId).
+		SetHeader(WebhookHeaderEvent, payload.EventType).
+		SetHeader(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10)).
+		SetHeader(WebhookHeaderSignature, "v1="+sign.WebhookSignature(endpoint.Secret, timestamp, []byte(payload.Body))).
+		SetBody(payload.Body).
+		Post(endpoint.Url)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.ErrorMessage = err.Error()
+		delivery.DeadLetter = boolToInt(final)
+		_ = data.CreateWebhookDelivery(delivery)
+		return err
+	}
+	delivery.StatusCode = resp.StatusCode()
+	delivery.ResponseBody = string(resp.Body())
+	if resp.IsSuccess() {
+		delivery.Success = 1
+		_ = data.CreateWebhookDelivery(delivery)
+		return nil
+	}
+	delivery.ErrorMessage = fmt.Sprintf("unexpected status %d", resp.StatusCode())
+	delivery.DeadLetter = boolToInt(final)
+	_ = data.CreateWebhookDelivery(delivery)
+	return errors.New(delivery.ErrorMessage)
+}
+
+// EnqueueWebhookRedelivery 手动重发事件，重发记录关联到最初的投递记录
+func EnqueueWebhookRedelivery(delivery *mdb.WebhookDelivery) error {
+	rootId := delivery.ID
+	if delivery.ParentId > 0 {
+		rootId = delivery.ParentId
+	}
+	payload, err := json.Cjson.Marshal(webhookDeliveryPayload{
+		EndpointID: delivery.EndpointID,
+		EventId:    delivery.EventId,
+		EventType:  delivery.EventType,
+		Body:       delivery.RequestBody,
+		ParentId:   rootId,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Enqueue(asynq.NewTask(QueueWebhookDelivery, payload), callbackMaxRetry())
+	return err
+}
+
+func generateWebhookEventId() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
+
+// PublishOrderEvent 投递订单事件，订单未归属商家时忽略
+func PublishOrderEvent(order *mdb.Orders, eventType string) {
+	merchantID, err := data.GetOrderMerchantID(order)
+	if err != nil || merchantID <= 0 {
+		return
+	}
+	eventData := response.OrderEventData{
+		TradeId:            order.TradeId,
+		OrderId:            order.OrderId,
+		Amount:             order.Amount,
+		Currency:           order.Currency,
+		ActualAmount:       order.ActualAmount,
+		ReceivedAmount:     order.ReceivedAmount,
+		Token:              order.Token,
+		Chain:              order.Chain,
+		TokenSymbol:        order.TokenSymbol,
+		BlockTransactionId: order.BlockTransactionId,
+		Status:             order.Status,
+	}
+	if err = PublishWebhookEvent(merchantID, eventType, eventData); err != nil {
+		log.Sugar.Errorf("[webhook] publish %s tradeId=%s err=%s", eventType, order.TradeId, err)
+	}
+}
@@ -26,6 +26,7 @@ func Start() {
 
 func initClient(redis asynq.RedisClientOpt) {
 	MClient = asynq.NewClient(redis)
+	handle.SetClient(MClient)
 }
 
 func initListen(redis asynq.RedisClientOpt) {
@@ -41,11 +42,18 @@ func initListen(redis asynq.RedisClientOpt) {
 				"low":      viper.GetInt("queue_level_low"),
 			},
 			Logger: log.Sugar,
+			// 回调类任务按退避时间表重试
+			RetryDelayFunc: handle.CallbackRetryDelay,
 		},
 	)
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(handle.QueueOrderExpiration, handle.OrderExpirationHandle)
 	mux.HandleFunc(handle.QueueOrderCallback, handle.OrderCallbackHandle)
+	mux.HandleFunc(handle.QueueRefundCallback, handle.RefundCallbackHandle)
+	mux.HandleFunc(handle.QueueSubscriptionCharge, handle.SubscriptionChargeHandle)
+	mux.HandleFunc(handle.QueueSubscriptionNotify, handle.SubscriptionNotifyHandle)
+	mux.HandleFunc(handle.QueueWebhookDelivery, handle.WebhookDeliveryHandle)
+	mux.HandleFunc(handle.QueueCallbackRedeliver, handle.CallbackRedeliverHandle)
 	if err := srv.Run(mux); err != nil {
 		log.Sugar.Fatalf("[queue] could not run server: %v", err)
 	}
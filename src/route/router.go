@@ -1,10 +1,12 @@
 package route
 
 import (
+	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/controller/comm"
 	"github.com/assimon/luuu/middleware"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"time"
 )
 
 // RegisterRoute 路由注册
@@ -21,6 +23,14 @@ func RegisterRoute(e *echo.Echo) {
 	e.GET("/qrcode", comm.Ctrl.GenerateQrCodeStream)
 	// ==== 支付相关=====
 	payRoute := e.Group("/pay")
+	// 收银台
+	payRoute.GET("/checkout-counter/:trade_id", comm.Ctrl.CheckoutCounter)
+	// 收款链接
+	payRoute.GET("/link/:slug", comm.Ctrl.PaymentLink)
+	payRoute.POST("/link/:slug", comm.Ctrl.PaymentLinkOrder,
+		middleware.EndpointRateLimiter("payment_link", config.GetPaymentLinkRateLimit(), time.Minute))
+	// 付款人选择支付网络
+	payRoute.POST("/select-chain/:trade_id", comm.Ctrl.SelectChain)
 	// 状态检测
 	payRoute.GET("/check-status/:trade_id", comm.Ctrl.CheckStatus)
 
@@ -72,14 +82,37 @@ func RegisterRoute(e *echo.Echo) {
 	adminAuthApi.GET("/authorizations", comm.Ctrl.AdminListAuthorizations)
 	adminAuthApi.GET("/deductions", comm.Ctrl.AdminListDeductions)
 	adminAuthApi.GET("/callbacks", comm.Ctrl.AdminListCallbacks)
+	adminAuthApi.POST("/callbacks/redeliver", comm.Ctrl.AdminRedeliverCallback)
+	adminAuthApi.POST("/callbacks/redeliver-failed", comm.Ctrl.AdminRedeliverFailedCallbacks)
+	adminAuthApi.GET("/export/:type", comm.Ctrl.AdminExport)
 	adminAuthApi.GET("/merchants", comm.Ctrl.AdminListMerchants)
 	adminAuthApi.PUT("/merchants/ban", comm.Ctrl.AdminBanMerchant)
+	adminAuthApi.POST("/merchants/balance-adjust", comm.Ctrl.AdminAdjustMerchantBalance)
+	adminAuthApi.PUT("/merchants/wallet-strategy", comm.Ctrl.AdminUpdateMerchantWalletStrategy)
+	adminAuthApi.GET("/ledger/entries", comm.Ctrl.AdminListLedgerEntries)
+
+	// ==== 手续费方案 ====
+	adminAuthApi.GET("/fee-schedules", comm.Ctrl.AdminListFeeSchedules)
+	adminAuthApi.POST("/fee-schedules", comm.Ctrl.AdminSaveFeeSchedule)
+	adminAuthApi.DELETE("/fee-schedules/:id", comm.Ctrl.AdminDeleteFeeSchedule)
 
 	// ==== 提现审批 ====
 	adminAuthApi.GET("/withdrawals", comm.Ctrl.AdminListWithdrawals)
 	adminAuthApi.PUT("/withdrawals/approve", comm.Ctrl.AdminApproveWithdrawal)
 	adminAuthApi.PUT("/withdrawals/reject", comm.Ctrl.AdminRejectWithdrawal)
 
+	// ==== 订单退款 ====
+	adminAuthApi.GET("/refunds", comm.Ctrl.AdminListRefunds)
+	adminAuthApi.POST("/refunds", comm.Ctrl.AdminCreateRefund)
+	adminAuthApi.PUT("/refunds/retry", comm.Ctrl.AdminRetryRefund)
+
+	// ==== 订阅 ====
+	adminAuthApi.PUT("/subscriptions/reset-billing", comm.Ctrl.AdminResetSubscriptionBilling)
+
+	// ==== 入账对账 ====
+	adminAuthApi.GET("/incoming-transfers", comm.Ctrl.AdminListIncomingTransfers)
+	adminAuthApi.POST("/incoming-transfers/attach", comm.Ctrl.AdminAttachIncomingTransfer)
+
 	// ==== 商家管理系统 ====
 	e.GET("/merchant", func(c echo.Context) error {
 		return c.File("./static/merchant/index.html")
@@ -94,6 +127,7 @@ func RegisterRoute(e *echo.Echo) {
 	merchantApi := apiV1Route.Group("/merchant")
 	merchantApi.Use(middleware.MerchantAuth())
 	merchantApi.GET("/profile", comm.Ctrl.MerchantProfile)
+	merchantApi.POST("/api-token/roll", comm.Ctrl.MerchantRollApiToken)
 
 	// 授权二维码
 	merchantApi.POST("/qrcode", comm.Ctrl.MerchantGenerateQRCode)
@@ -114,6 +148,10 @@ func RegisterRoute(e *echo.Echo) {
 
 	// 商家钱包管理
 	merchantApi.GET("/wallets", comm.Ctrl.MerchantGetWallets)
+	merchantApi.GET("/wallets/balances", comm.Ctrl.MerchantGetWalletBalances)
+	merchantApi.PUT("/wallets/weight", comm.Ctrl.MerchantUpdateWalletWeight)
+	merchantApi.GET("/wallets/strategies", comm.Ctrl.WalletStrategyList)
+	merchantApi.PUT("/wallets/strategy", comm.Ctrl.MerchantUpdateWalletStrategy)
 	merchantApi.POST("/wallets", comm.Ctrl.MerchantAddWallet)
 	merchantApi.DELETE("/wallets/:id", comm.Ctrl.MerchantDeleteWallet)
 	merchantApi.PUT("/wallets/status", comm.Ctrl.MerchantUpdateWalletStatus)
@@ -122,10 +160,60 @@ func RegisterRoute(e *echo.Echo) {
 	merchantApi.GET("/balance", comm.Ctrl.MerchantGetBalance)
 	merchantApi.POST("/withdrawals", comm.Ctrl.MerchantCreateWithdrawal)
 	merchantApi.GET("/withdrawals", comm.Ctrl.MerchantGetWithdrawals)
+	merchantApi.GET("/ledger/entries", comm.Ctrl.MerchantGetLedgerEntries)
+	merchantApi.GET("/fee-schedules", comm.Ctrl.MerchantGetFeeSchedules)
+	merchantApi.GET("/statement", comm.Ctrl.MerchantGetFeeStatement)
+
+	// 商家订单
+	merchantApi.GET("/orders", comm.Ctrl.MerchantGetOrders)
+	merchantApi.GET("/orders/:trade_id", comm.Ctrl.MerchantGetOrderDetail)
+
+	// 商家订单退款
+	merchantApi.GET("/refunds", comm.Ctrl.MerchantGetRefunds)
+	merchantApi.POST("/refunds", comm.Ctrl.MerchantCreateRefund)
+
+	// 收款链接
+	merchantApi.GET("/payment-links", comm.Ctrl.MerchantGetPaymentLinks)
+	merchantApi.POST("/payment-links", comm.Ctrl.MerchantCreatePaymentLink)
+	merchantApi.PUT("/payment-links/:id", comm.Ctrl.MerchantUpdatePaymentLink)
+	merchantApi.DELETE("/payment-links/:id", comm.Ctrl.MerchantDeletePaymentLink)
+
+	// 订阅
+	merchantApi.GET("/subscriptions", comm.Ctrl.MerchantGetSubscriptions)
+	merchantApi.POST("/subscriptions", comm.Ctrl.MerchantCreateSubscription)
+	merchantApi.GET("/subscriptions/:id", comm.Ctrl.MerchantGetSubscriptionDetail)
+	merchantApi.POST("/subscriptions/:id/cancel", comm.Ctrl.MerchantCancelSubscription)
+
+	// 事件通知
+	merchantApi.GET("/webhooks", comm.Ctrl.MerchantGetWebhookEndpoints)
+	merchantApi.POST("/webhooks", comm.Ctrl.MerchantCreateWebhookEndpoint)
+	merchantApi.PUT("/webhooks/:id", comm.Ctrl.MerchantUpdateWebhookEndpoint)
+	merchantApi.POST("/webhooks/:id/roll-secret", comm.Ctrl.MerchantRollWebhookSecret)
+	merchantApi.DELETE("/webhooks/:id", comm.Ctrl.MerchantDeleteWebhookEndpoint)
+	merchantApi.GET("/webhook-deliveries", comm.Ctrl.MerchantGetWebhookDeliveries)
+	merchantApi.POST("/webhook-deliveries/:id/redeliver", comm.Ctrl.MerchantRedeliverWebhook)
+
+	// 回调日志与重发
+	merchantApi.GET("/callbacks", comm.Ctrl.MerchantGetCallbacks)
+	merchantApi.POST("/callbacks/:id/redeliver", comm.Ctrl.MerchantRedeliverCallback)
+	merchantApi.POST("/callbacks/redeliver-failed", comm.Ctrl.MerchantRedeliverFailedCallbacks)
+	merchantApi.GET("/callback-ack", comm.Ctrl.MerchantGetCallbackAck)
+	merchantApi.PUT("/callback-ack", comm.Ctrl.MerchantUpdateCallbackAck)
+
+	// 收银台品牌
+	merchantApi.GET("/checkout-branding", comm.Ctrl.MerchantGetCheckoutBranding)
+	merchantApi.PUT("/checkout-branding", comm.Ctrl.MerchantUpdateCheckoutBranding)
+
+	// 数据导出
+	merchantApi.GET("/export/:type", comm.Ctrl.MerchantExport)
 
 	// ==== 管理后台钱包管理 ====
-	adminAuthApi.GET("/wallets", comm.Ctrl.WalletList)
+	adminAuthApi.GET("/wallets", comm.Ctrl.AdminListWallets)
 	adminAuthApi.POST("/wallets/add", comm.Ctrl.AddWalletAddress)
 	adminAuthApi.POST("/wallets/update-status", comm.Ctrl.UpdateWalletStatus)
 	adminAuthApi.POST("/wallets/delete", comm.Ctrl.DeleteWallet)
+	adminAuthApi.GET("/wallets/sweeps", comm.Ctrl.AdminListWalletSweeps)
+	adminAuthApi.GET("/wallets/balances", comm.Ctrl.AdminListWalletBalances)
+	adminAuthApi.POST("/wallets/update-weight", comm.Ctrl.AdminUpdateWalletWeight)
+	adminAuthApi.GET("/wallets/strategies", comm.Ctrl.WalletStrategyList)
 }
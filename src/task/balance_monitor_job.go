@@ -0,0 +1,18 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/assimon/luuu/model/service"
+)
+
+// BalanceMonitorJob 刷新钱包链上余额并检查 gas 是否充足
+type BalanceMonitorJob struct{}
+
+var gBalanceMonitorJobLock sync.Mutex
+
+func (BalanceMonitorJob) Run() {
+	gBalanceMonitorJobLock.Lock()
+	defer gBalanceMonitorJobLock.Unlock()
+	service.RunBalanceMonitor()
+}
@@ -0,0 +1,289 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/evm"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/log"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	tronTransactionInfoUri = "https://api.trongrid.io/wallet/gettransactioninfobyid"
+	tronNowBlockUri        = "https://api.trongrid.io/wallet/getnowblock"
+	tronBlockByNumUri      = "https://api.trongrid.io/wallet/getblockbynum"
+)
+
+// evmRpcTimeout 单次RPC调用的超时时间
+const evmRpcTimeout = 8 * time.Second
+
+// ConfirmTransferJob 复核确认中的入账：达到确认数且仍在主链则入账，已被重组则回滚
+type ConfirmTransferJob struct{}
+
+var gConfirmTransferJobLock sync.Mutex
+
+func (ConfirmTransferJob) Run() {
+	gConfirmTransferJobLock.Lock()
+	defer gConfirmTransferJobLock.Unlock()
+	transfers, err := data.GetConfirmingOrderTransfers()
+	if err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	if len(transfers) == 0 {
+		return
+	}
+	byChain := map[string][]mdb.OrderTransfer{}
+	for _, transfer := range transfers {
+		byChain[transfer.Chain] = append(byChain[transfer.Chain], transfer)
+	}
+	for chainName, list := range byChain {
+		info := chain.GetChainInfo(chainName)
+		if info == nil {
+			continue
+		}
+		if info.IsTron {
+			confirmTronTransfers(info, list)
+			continue
+		}
+		confirmEvmTransfers(info, list)
+	}
+}
+
+func confirmEvmTransfers(info *chain.ChainInfo, transfers []mdb.OrderTransfer) {
+	client, latest, err := dialEvmChain(info)
+	if err != nil {
+		log.Sugar.Errorf("[%s] 连接RPC节点失败, err=%v", info.Name, err)
+		return
+	}
+	defer client.Close()
+	for _, transfer := range transfers {
+		receipt, err := evmTransactionReceipt(client, common.HexToHash(transfer.TxHash))
+		if errors.Is(err, ethereum.NotFound) {
+			if transferMissing(info, &transfer, latest) {
+				rollbackTransfer(&transfer)
+			}
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		clearTransferMissing(&transfer)
+		if receipt.Status != types.ReceiptStatusSuccessful || !receiptHasTransfer(info.Name, receipt, &transfer) {
+			rollbackTransfer(&transfer)
+			continue
+		}
+		blockNumber := receipt.BlockNumber.Uint64()
+		if latest < blockNumber || latest-blockNumber+1 < uint64(info.Confirmations) {
+			continue
+		}
+		// 确认所在区块仍是主链区块
+		header, err := evmHeaderByNumber(client, receipt.BlockNumber)
+		if err != nil || header.Hash() != receipt.BlockHash {
+			continue
+		}
+		if err = service.ConfirmOrderTransfer(transfer.ID, blockNumber, receipt.BlockHash.Hex()); err != nil {
+			log.Sugar.Errorf("[%s] 入账确认失败, hash=%s, err=%v", info.Name, transfer.TxHash, err)
+		}
+	}
+}
+
+// dialEvmChain 依次尝试各RPC节点，返回第一个能查到最新区块的节点
+func dialEvmChain(info *chain.ChainInfo) (*ethclient.Client, uint64, error) {
+	lastErr := errors.New("未配置RPC节点")
+	for _, rpcUrl := range info.RpcURLs {
+		client, err := ethclient.Dial(rpcUrl)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), evmRpcTimeout)
+		latest, err := client.BlockNumber(ctx)
+		cancel()
+		if err == nil && latest > 0 {
+			return client, latest, nil
+		}
+		client.Close()
+		if err == nil {
+			err = errors.New("最新区块高度为0")
+		}
+		lastErr = fmt.Errorf("%s: %w", rpcUrl, err)
+	}
+	return nil, 0, lastErr
+}
+
+// evmTransactionReceipt 查询交易回执，每次调用单独计时
+func evmTransactionReceipt(client *ethclient.Client, txHash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), evmRpcTimeout)
+	defer cancel()
+	return client.TransactionReceipt(ctx, txHash)
+}
+
+// evmHeaderByNumber 查询区块头，每次调用单独计时
+func evmHeaderByNumber(client *ethclient.Client, number *big.Int) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), evmRpcTimeout)
+	defer cancel()
+	return client.HeaderByNumber(ctx, number)
+}
+
+// receiptHasTransfer 交易回执中是否仍包含该笔代币转账
+func receiptHasTransfer(chainName string, receipt *types.Receipt, transfer *mdb.OrderTransfer) bool {
+	token := chain.GetTokenInfo(chainName, transfer.TokenSymbol)
+	if token == nil {
+		return false
+	}
+	contract := common.HexToAddress(token.Contract)
+	to := common.HexToAddress(transfer.ToAddress)
+	for _, lg := range receipt.Logs {
+		if lg.Address != contract || len(lg.Topics) < 3 || lg.Topics[0] != erc20TransferTopic {
+			continue
+		}
+		if common.BytesToAddress(lg.Topics[2].Bytes()) != to {
+			continue
+		}
+		// 转账金额按 decimal(19,6) 保存，链上金额按相同精度比较
+		amount := evm.ToDecimalAmount(new(big.Int).SetBytes(lg.Data), token.Decimals)
+		if amount.Round(6).Equal(transfer.Amount) {
+			return true
+		}
+	}
+	return false
+}
+
+type tronTransactionInfo struct {
+	Id          string `json:"id"`
+	BlockNumber uint64 `json:"blockNumber"`
+	Receipt     struct {
+		Result string `json:"result"`
+	} `json:"receipt"`
+}
+
+type tronNowBlock struct {
+	BlockHeader struct {
+		RawData struct {
+			Number uint64 `json:"number"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+}
+
+type tronBlock struct {
+	BlockID      string `json:"blockID"`
+	Transactions []struct {
+		TxID string `json:"txID"`
+	} `json:"transactions"`
+}
+
+func confirmTronTransfers(info *chain.ChainInfo, transfers []mdb.OrderTransfer) {
+	client := http_client.GetHttpClient()
+	if apiKey := config.GetTrongridApiKey(); apiKey != "" {
+		client.SetHeader("TRON-PRO-API-KEY", apiKey)
+	}
+	var nowBlock tronNowBlock
+	resp, err := client.R().SetResult(&nowBlock).Post(tronNowBlockUri)
+	if err != nil || !resp.IsSuccess() || nowBlock.BlockHeader.RawData.Number == 0 {
+		return
+	}
+	latest := nowBlock.BlockHeader.RawData.Number
+	for _, transfer := range transfers {
+		var txInfo tronTransactionInfo
+		resp, err := client.R().
+			SetBody(map[string]interface{}{"value": transfer.TxHash}).
+			SetResult(&txInfo).
+			Post(tronTransactionInfoUri)
+		if err != nil || !resp.IsSuccess() {
+			continue
+		}
+		// 节点返回空对象表示交易已不在链上（也可能是节点尚未同步）
+		if txInfo.Id == "" {
+			if transferMissing(info, &transfer, latest) {
+				rollbackTransfer(&transfer)
+			}
+			continue
+		}
+		clearTransferMissing(&transfer)
+		if txInfo.Receipt.Result != "" && txInfo.Receipt.Result != "SUCCESS" {
+			rollbackTransfer(&transfer)
+			continue
+		}
+		if latest < txInfo.BlockNumber || latest-txInfo.BlockNumber+1 < uint64(info.Confirmations) {
+			continue
+		}
+		// 确认所在区块仍是主链区块：主链上该高度的区块须包含此交易
+		blockHash, ok := tronCanonicalBlockHash(client, txInfo.BlockNumber, transfer.TxHash)
+		if !ok {
+			continue
+		}
+		if err = service.ConfirmOrderTransfer(transfer.ID, txInfo.BlockNumber, blockHash); err != nil {
+			log.Sugar.Errorf("[%s] 入账确认失败, hash=%s, err=%v", info.Name, transfer.TxHash, err)
+		}
+	}
+}
+
+// tronCanonicalBlockHash 查询主链上该高度的区块哈希，区块不包含该交易时返回 false
+func tronCanonicalBlockHash(client *resty.Client, blockNumber uint64, txHash string) (string, bool) {
+	var block tronBlock
+	resp, err := client.R().
+		SetBody(map[string]interface{}{"num": blockNumber}).
+		SetResult(&block).
+		Post(tronBlockByNumUri)
+	if err != nil || !resp.IsSuccess() || block.BlockID == "" {
+		return "", false
+	}
+	for _, tx := range block.Transactions {
+		if tx.TxID == txHash {
+			return block.BlockID, true
+		}
+	}
+	return "", false
+}
+
+// transferMissing 记录一次查不到交易，多次查不到且持续超过确认数个区块后才判定已被重组，
+// 避免节点未同步或负载均衡到落后节点时误回滚
+func transferMissing(info *chain.ChainInfo, transfer *mdb.OrderTransfer, latest uint64) bool {
+	if err := data.MarkOrderTransferMissing(transfer, latest); err != nil {
+		log.Sugar.Errorf("[%s] 记录入账交易缺失失败, hash=%s, err=%v", info.Name, transfer.TxHash, err)
+		return false
+	}
+	depth := uint64(info.Confirmations)
+	if depth == 0 {
+		depth = 1
+	}
+	if transfer.MissingCount < 2 || latest <= transfer.MissingSince+depth {
+		log.Sugar.Warnf("[%s] 暂未查到入账交易, hash=%s, 次数=%d, 起始区块=%d", info.Name, transfer.TxHash, transfer.MissingCount, transfer.MissingSince)
+		return false
+	}
+	return true
+}
+
+// clearTransferMissing 交易重新查到后清除缺失记录
+func clearTransferMissing(transfer *mdb.OrderTransfer) {
+	if transfer.MissingCount == 0 {
+		return
+	}
+	if err := data.ResetOrderTransferMissing(transfer.ID); err != nil {
+		log.Sugar.Errorf("[%s] 清除入账交易缺失记录失败, hash=%s, err=%v", transfer.Chain, transfer.TxHash, err)
+	}
+}
+
+func rollbackTransfer(transfer *mdb.OrderTransfer) {
+	log.Sugar.Warnf("[%s] 入账交易已不在主链, 回滚, hash=%s", transfer.Chain, transfer.TxHash)
+	if err := service.RollbackOrderTransfer(transfer.ID); err != nil {
+		log.Sugar.Errorf("[%s] 入账回滚失败, hash=%s, err=%v", transfer.Chain, transfer.TxHash, err)
+	}
+}
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestTransferMissing 测试查不到交易时，须多次查不到且持续超过确认数个区块才判定重组
+func TestTransferMissing(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&mdb.OrderTransfer{}))
+	originalDB, originalLog := dao.Mdb, log.Sugar
+	dao.Mdb, log.Sugar = db, zap.NewNop().Sugar()
+	t.Cleanup(func() {
+		dao.Mdb, log.Sugar = originalDB, originalLog
+		_ = sqlDB.Close()
+	})
+
+	testCases := []struct {
+		name          string
+		confirmations int
+		latest        []uint64 // 依次查不到交易时的最新区块高度
+		expected      bool     // 最后一次是否判定已重组
+		wantCount     int
+		wantSince     uint64
+	}{
+		{"首次查不到", 15, []uint64{100}, false, 1, 100},
+		{"多次查不到但未超过确认数", 15, []uint64{100, 110, 115}, false, 3, 100},
+		{"多次查不到且超过确认数", 15, []uint64{100, 110, 116}, true, 3, 100},
+		{"仅一次查不到即使已超过确认数", 15, []uint64{200}, false, 1, 200},
+		{"不等待确认的链至少跨过一个区块", 0, []uint64{100, 100, 102}, true, 3, 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transfer := &mdb.OrderTransfer{TradeId: "T1", Chain: "BSC", TxHash: "0x" + tc.name, ToAddress: "0xwallet",
+				Status: mdb.TransferStatusConfirming}
+			require.NoError(t, db.Create(transfer).Error)
+			info := &chain.ChainInfo{Name: "BSC", Confirmations: tc.confirmations}
+
+			var got bool
+			for _, latest := range tc.latest {
+				got = transferMissing(info, transfer, latest)
+			}
+			assert.Equal(t, tc.expected, got)
+
+			var saved mdb.OrderTransfer
+			require.NoError(t, db.First(&saved, transfer.ID).Error)
+			assert.Equal(t, tc.wantCount, saved.MissingCount)
+			assert.Equal(t, tc.wantSince, saved.MissingSince)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/assimon/luuu/model/service"
+)
+
+// LedgerRetryJob 补记转账已结束但记账失败的退款与提现
+type LedgerRetryJob struct{}
+
+var gLedgerRetryJobLock sync.Mutex
+
+func (LedgerRetryJob) Run() {
+	gLedgerRetryJobLock.Lock()
+	defer gLedgerRetryJobLock.Unlock()
+	service.RetryPendingLedger()
+}
@@ -10,5 +10,15 @@ func Start() {
 	c.AddJob("@every 5s", ListenTrc20Job{})
 	// evm链钱包监听
 	c.AddJob("@every 10s", ListenEvmJob{})
+	// 入账区块确认
+	c.AddJob("@every 10s", ConfirmTransferJob{})
+	// 订阅周期扣款
+	c.AddJob("@every 60s", SubscriptionBillingJob{})
+	// 收款钱包归集
+	c.AddJob("@every 5m", SweepJob{})
+	// 钱包余额与 gas 监控
+	c.AddJob("@every 10m", BalanceMonitorJob{})
+	// 退款、提现记账补记
+	c.AddJob("@every 5m", LedgerRetryJob{})
 	c.Start()
 }
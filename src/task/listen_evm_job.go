@@ -12,15 +12,9 @@ import (
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/service"
-	"github.com/assimon/luuu/mq"
-	"github.com/assimon/luuu/mq/handle"
-	"github.com/assimon/luuu/telegram"
 	"github.com/assimon/luuu/util/chain"
 	"github.com/assimon/luuu/util/evm"
 	"github.com/assimon/luuu/util/log"
-	"github.com/assimon/luuu/util/math"
-	"github.com/hibiken/asynq"
-	"github.com/spf13/viper"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -29,16 +23,20 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// erc20TransferTopic Transfer(address,address,uint256) 事件签名
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
 type ListenEvmJob struct{}
 
 func (ListenEvmJob) Run() {
-	listenEvmChain(chain.ChainBsc, config.GetBscRpcUrls(), config.GetBscUsdtContract(), config.GetBscUsdtDecimals())
-	listenEvmChain(chain.ChainEvm, config.GetEthRpcUrls(), config.GetEthUsdtContract(), config.GetEthUsdtDecimals())
-	listenEvmChain(chain.ChainPolygon, config.GetPolygonRpcUrls(), config.GetPolygonUsdtContract(), config.GetPolygonUsdtDecimals())
+	listenEvmChain(chain.ChainBsc, config.GetBscRpcUrls())
+	listenEvmChain(chain.ChainEvm, config.GetEthRpcUrls())
+	listenEvmChain(chain.ChainPolygon, config.GetPolygonRpcUrls())
 }
 
-func listenEvmChain(chainName string, rpcUrls []string, tokenContract string, decimals int) {
-	if len(rpcUrls) == 0 || tokenContract == "" {
+func listenEvmChain(chainName string, rpcUrls []string) {
+	tokens := chain.GetEnabledTokens(chainName)
+	if len(rpcUrls) == 0 || len(tokens) == 0 {
 		return
 	}
 	if dao.Rdb == nil {
@@ -46,7 +44,15 @@ func listenEvmChain(chainName string, rpcUrls []string, tokenContract string, de
 	}
 
 	wallets, err := data.GetAvailableWalletAddressByChain(chainName)
-	if err != nil || len(wallets) == 0 {
+	if err != nil {
+		return
+	}
+	hdWallets, err := data.GetPendingHdDepositWallets(chainName, config.GetOrderExpirationTimeDuration()+data.HdLateTransferWatchWindow)
+	if err != nil {
+		log.Sugar.Error(err)
+	}
+	wallets = append(wallets, hdWallets...)
+	if len(wallets) == 0 {
 		return
 	}
 
@@ -75,8 +81,14 @@ func listenEvmChain(chainName string, rpcUrls []string, tokenContract string, de
 	}
 
 	const maxRange uint64 = 500
-	contractAddr := common.HexToAddress(tokenContract)
-	transferTopic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// 合约地址 -> 代币配置，一次查询覆盖所有已启用代币
+	contractTokens := make(map[common.Address]*chain.TokenInfo, len(tokens))
+	contractAddrs := make([]common.Address, 0, len(tokens))
+	for _, token := range tokens {
+		addr := common.HexToAddress(token.Contract)
+		contractTokens[addr] = token
+		contractAddrs = append(contractAddrs, addr)
+	}
 
 	blockTimeCache := map[uint64]uint64{}
 
@@ -91,14 +103,14 @@ func listenEvmChain(chainName string, rpcUrls []string, tokenContract string, de
 			query := ethereum.FilterQuery{
 				FromBlock: big.NewInt(int64(from)),
 				ToBlock:   big.NewInt(int64(to)),
-				Addresses: []common.Address{contractAddr},
-				Topics:    [][]common.Hash{{transferTopic}, nil, {toTopic}},
+				Addresses: contractAddrs,
+				Topics:    [][]common.Hash{{erc20TransferTopic}, nil, {toTopic}},
 			}
 			logs, err := client.FilterLogs(ctx, query)
 			if err != nil {
 				continue
 			}
-			processEvmLogs(chainName, decimals, wallet, logs, client, blockTimeCache)
+			processEvmLogs(chainName, contractTokens, wallet, logs, client, blockTimeCache)
 		}
 		from = to + 1
 	}
@@ -106,55 +118,42 @@ func listenEvmChain(chainName string, rpcUrls []string, tokenContract string, de
 	setLastBlock(chainName, latest)
 }
 
-func processEvmLogs(chainName string, decimals int, wallet mdb.WalletAddress, logs []types.Log, client *ethclient.Client, blockTimeCache map[uint64]uint64) {
+func processEvmLogs(chainName string, contractTokens map[common.Address]*chain.TokenInfo, wallet mdb.WalletAddress, logs []types.Log, client *ethclient.Client, blockTimeCache map[uint64]uint64) {
+	// 区块内的日志序号在重组后可能变化，改用同一交易内转入该地址的第几笔
+	logIndexes := make(map[common.Hash]uint)
 	for _, lg := range logs {
 		if len(lg.Data) == 0 || lg.TxHash.Hex() == "" {
 			continue
 		}
-		amountInt := new(big.Int).SetBytes(lg.Data)
-		amount := evm.ToDecimalAmount(amountInt, decimals)
-		amount = math.MustParsePrecFloat64(amount, 2)
-
-		tradeId, err := data.GetTradeIdByWalletAddressAndAmount(wallet.Token, amount)
-		if err != nil || tradeId == "" {
-			continue
-		}
-		order, err := data.GetOrderInfoByTradeId(tradeId)
-		if err != nil || order.ID <= 0 {
+		token, ok := contractTokens[lg.Address]
+		if !ok {
 			continue
 		}
+		logIndex := logIndexes[lg.TxHash]
+		logIndexes[lg.TxHash]++
+		amountInt := new(big.Int).SetBytes(lg.Data)
+		amount := evm.ToDecimalAmount(amountInt, token.Decimals)
 
-		blockTime := getBlockTime(client, lg.BlockNumber, blockTimeCache)
-		if blockTime > 0 && order.CreatedAt.Timestamp() > int64(blockTime) {
-			continue
+		fromAddress := ""
+		if len(lg.Topics) > 1 {
+			fromAddress = common.BytesToAddress(lg.Topics[1].Bytes()).Hex()
 		}
-
+		blockTime := getBlockTime(client, lg.BlockNumber, blockTimeCache)
 		req := &request.OrderProcessingRequest{
 			Token:              wallet.Token,
-			TradeId:            tradeId,
+			Chain:              chainName,
+			TokenSymbol:        token.Symbol,
 			Amount:             amount,
 			BlockTransactionId: lg.TxHash.Hex(),
+			LogIndex:           logIndex,
+			FromAddress:        fromAddress,
+			BlockTimestamp:     int64(blockTime) * 1000,
+			BlockNumber:        lg.BlockNumber,
+			BlockHash:          lg.BlockHash.Hex(),
 		}
-		if err := service.OrderProcessing(req); err != nil {
-			continue
+		if err := service.ProcessIncomingTransfer(req); err != nil {
+			log.Sugar.Errorf("[%s] 入账处理失败, hash=%s, err=%v", chainName, lg.TxHash.Hex(), err)
 		}
-
-		orderCallbackQueue, _ := handle.NewOrderCallbackQueue(order)
-		orderNoticeMaxRetry := viper.GetInt("order_notice_max_retry")
-		mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(orderNoticeMaxRetry),
-			asynq.Retention(config.GetOrderExpirationTimeDuration()),
-		)
-		msgTpl := `
-<b>📢📢有新的交易支付成功！</b>
-<pre>链: %s</pre>
-<pre>交易号：%s</pre>
-<pre>订单号：%s</pre>
-<pre>请求支付金额：%f cny</pre>
-<pre>实际支付金额：%f usdt</pre>
-<pre>钱包地址：%s</pre>
-`
-		msg := fmt.Sprintf(msgTpl, chainName, order.TradeId, order.OrderId, order.Amount, order.ActualAmount, order.Token)
-		telegram.SendToBot(msg)
 	}
 }
 
@@ -1,8 +1,10 @@
 package task
 
 import (
+	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/chain"
 	"github.com/assimon/luuu/util/log"
 	"sync"
 )
@@ -20,6 +22,11 @@ func (r ListenTrc20Job) Run() {
 		log.Sugar.Error(err)
 		return
 	}
+	hdWallets, err := data.GetPendingHdDepositWallets(chain.ChainTron, config.GetOrderExpirationTimeDuration()+data.HdLateTransferWatchWindow)
+	if err != nil {
+		log.Sugar.Error(err)
+	}
+	walletAddress = append(walletAddress, hdWallets...)
 	if len(walletAddress) <= 0 {
 		return
 	}
@@ -0,0 +1,19 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/assimon/luuu/model/service"
+)
+
+// SubscriptionBillingJob 收尾中断的扣款，并为到期的订阅投递扣款任务
+type SubscriptionBillingJob struct{}
+
+var gSubscriptionBillingJobLock sync.Mutex
+
+func (SubscriptionBillingJob) Run() {
+	gSubscriptionBillingJobLock.Lock()
+	defer gSubscriptionBillingJobLock.Unlock()
+	service.ReclaimStaleSubscriptionBilling()
+	service.DispatchDueSubscriptions()
+}
@@ -0,0 +1,18 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/assimon/luuu/model/service"
+)
+
+// SweepJob 收款钱包余额归集到冷钱包
+type SweepJob struct{}
+
+var gSweepJobLock sync.Mutex
+
+func (SweepJob) Run() {
+	gSweepJobLock.Lock()
+	defer gSweepJobLock.Unlock()
+	service.RunWalletSweep()
+}
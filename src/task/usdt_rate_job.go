@@ -1,6 +1,7 @@
 package task
 
 import (
+	"errors"
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/json"
@@ -36,27 +37,50 @@ type Points struct {
 	C []float64 `json:"c"`
 }
 
+// usdtRateConvertIds coinmarketcap 法币 convertId
+var usdtRateConvertIds = map[string]string{
+	config.CurrencyCny: "2787",
+	config.CurrencyUsd: "2781",
+	config.CurrencyEur: "2790",
+	config.CurrencyHkd: "2792",
+}
+
 func (r UsdtRateJob) Run() {
+	for _, currency := range config.SupportedFiatCurrencies {
+		convertId, ok := usdtRateConvertIds[currency]
+		if !ok {
+			continue
+		}
+		rate, err := fetchUsdtRate(convertId, config.GetUsdtRatePrecision(currency))
+		if err != nil {
+			log.Sugar.Errorf("usdt rate get err, currency=%s, err=%s", currency, err.Error())
+			continue
+		}
+		if rate > 0 {
+			config.SetUsdtRateByCurrency(currency, rate)
+		}
+	}
+}
+
+// fetchUsdtRate 获取 USDT 兑指定法币的最新汇率，按 precision 保留小数位
+func fetchUsdtRate(convertId string, precision int) (float64, error) {
 	client := http_client.GetHttpClient()
-	resp, err := client.R().SetQueryString("id=825&range=1H&convertId=2787").SetHeader("Accept", "application/json").Get(UsdtRateApiUri)
+	resp, err := client.R().SetQueryString("id=825&range=1H&convertId="+convertId).SetHeader("Accept", "application/json").Get(UsdtRateApiUri)
 	if err != nil {
-		log.Sugar.Error("usdt rate get err:", err.Error())
-		return
+		return 0, err
 	}
 	var usdtResp UsdtRateResp
 	err = json.Cjson.Unmarshal(resp.Body(), &usdtResp)
 	if err != nil {
-		log.Sugar.Error("Unmarshal usdt resp err:", err.Error())
-		return
+		return 0, err
 	}
 	if usdtResp.Status.ErrorCode != "0" {
-		log.Sugar.Error("usdt resp err:", usdtResp.Status.ErrorMessage)
-		return
+		return 0, errors.New(usdtResp.Status.ErrorMessage)
 	}
 	for _, points := range usdtResp.Data.Points {
 		if len(points.C) > 0 && points.C[0] > 0 {
-			config.UsdtRate = math.MustParsePrecFloat64(points.C[0], 2)
-			return
+			return math.MustParsePrecFloat64(points.C[0], precision), nil
 		}
 	}
+	return 0, nil
 }
@@ -0,0 +1,116 @@
+package ack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 回调确认方式
+const (
+	ModeBody      = "body" // 响应体等于指定字符串
+	ModeStatus2xx = "2xx"  // 任意 2xx 状态码
+	ModeJson      = "json" // 响应 JSON 指定路径的值等于期望值
+)
+
+// DefaultBody 未配置时要求响应体为 ok
+const DefaultBody = "ok"
+
+// Rule 回调确认规则
+type Rule struct {
+	Mode      string
+	Body      string
+	JsonPath  string
+	JsonValue string
+}
+
+// Normalize 补齐默认值，未配置时沿用响应 ok 的规则
+func (r Rule) Normalize() Rule {
+	if r.Mode == "" {
+		r.Mode = ModeBody
+	}
+	if r.Mode == ModeBody && r.Body == "" {
+		r.Body = DefaultBody
+	}
+	return r
+}
+
+// Validate 校验规则是否完整
+func (r Rule) Validate() error {
+	switch r.Mode {
+	case "", ModeBody, ModeStatus2xx:
+		return nil
+	case ModeJson:
+		if strings.TrimSpace(r.JsonPath) == "" {
+			return fmt.Errorf("json 确认方式需要指定路径")
+		}
+		return nil
+	}
+	return fmt.Errorf("不支持的确认方式: %s", r.Mode)
+}
+
+// String 规则描述，记录在回调日志中
+func (r Rule) String() string {
+	r = r.Normalize()
+	switch r.Mode {
+	case ModeStatus2xx:
+		return ModeStatus2xx
+	case ModeJson:
+		return ModeJson + ":" + r.JsonPath + "=" + r.JsonValue
+	}
+	return ModeBody + ":" + r.Body
+}
+
+// Match 判断商户响应是否满足确认规则
+func (r Rule) Match(statusCode int, body string) bool {
+	r = r.Normalize()
+	switch r.Mode {
+	case ModeStatus2xx:
+		return statusCode >= 200 && statusCode < 300
+	case ModeJson:
+		value, ok := lookup(body, r.JsonPath)
+		return ok && value == r.JsonValue
+	}
+	return strings.TrimSpace(body) == r.Body
+}
+
+// lookup 按点分路径取 JSON 中的值，数组下标使用数字，如 data.items.0.status
+func lookup(body, path string) (string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var node interface{}
+	if err := decoder.Decode(&node); err != nil {
+		return "", false
+	}
+	for _, key := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return "", false
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			node = v[i]
+		default:
+			return "", false
+		}
+	}
+	switch v := node.(type) {
+	case string:
+		return v, true
+	case nil:
+		return "null", true
+	case map[string]interface{}, []interface{}:
+		var buf bytes.Buffer
+		_ = json.NewEncoder(&buf).Encode(v)
+		return strings.TrimSpace(buf.String()), true
+	}
+	return fmt.Sprint(node), true
+}
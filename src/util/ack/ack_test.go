@@ -0,0 +1,49 @@
+package ack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefaultRule 未配置时要求响应 ok
+func TestDefaultRule(t *testing.T) {
+	var r Rule
+	assert.Equal(t, "body:ok", r.String())
+	assert.True(t, r.Match(200, "ok"))
+	assert.True(t, r.Match(200, "ok\n"))
+	assert.False(t, r.Match(200, "success"))
+}
+
+// TestStatus2xxRule 任意 2xx 视为确认
+func TestStatus2xxRule(t *testing.T) {
+	r := Rule{Mode: ModeStatus2xx}
+	assert.True(t, r.Match(200, ""))
+	assert.True(t, r.Match(204, "whatever"))
+	assert.False(t, r.Match(302, "ok"))
+	assert.False(t, r.Match(500, "ok"))
+}
+
+// TestJsonRule JSON 路径匹配
+func TestJsonRule(t *testing.T) {
+	r := Rule{Mode: ModeJson, JsonPath: "code", JsonValue: "0"}
+	assert.Equal(t, "json:code=0", r.String())
+	assert.True(t, r.Match(200, `{"code":0,"msg":"ok"}`))
+	assert.False(t, r.Match(200, `{"code":1}`))
+	assert.False(t, r.Match(200, `{"msg":"ok"}`))
+	assert.False(t, r.Match(200, `not json`))
+
+	r = Rule{Mode: ModeJson, JsonPath: "data.list.1.status", JsonValue: "SUCCESS"}
+	assert.True(t, r.Match(200, `{"data":{"list":[{"status":"FAIL"},{"status":"SUCCESS"}]}}`))
+
+	r = Rule{Mode: ModeJson, JsonPath: "success", JsonValue: "true"}
+	assert.True(t, r.Match(200, `{"success":true}`))
+}
+
+// TestValidate 规则校验
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Rule{}.Validate())
+	assert.NoError(t, Rule{Mode: ModeBody, Body: "success"}.Validate())
+	assert.Error(t, Rule{Mode: ModeJson}.Validate())
+	assert.Error(t, Rule{Mode: "xml"}.Validate())
+}
@@ -13,8 +13,12 @@ const (
 	ChainEvm     = "EVM"
 	ChainBsc     = "BSC"
 	ChainPolygon = "POLYGON"
+	ChainAny     = "ANY" // 下单时不指定链，由付款人在收银台选择
 )
 
+// SupportedChains 支持的链，按收银台展示顺序
+var SupportedChains = []string{ChainTron, ChainBsc, ChainEvm, ChainPolygon}
+
 var evmAddressRe = regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
 
 func NormalizeChain(c string) string {
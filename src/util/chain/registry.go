@@ -6,17 +6,20 @@ import (
 
 // ChainInfo 链配置信息
 type ChainInfo struct {
-	Name         string   // 标准名称: BSC, EVM, POLYGON, TRON
-	DisplayName  string   // 显示名称
-	ChainID      int64    // EVM链ID (0=TRON)
-	ChainIDHex   string   // 十六进制链ID
-	USDTContract string   // USDT合约地址
-	Decimals     int      // USDT精度
-	RpcURLs      []string // RPC节点列表
-	ExplorerURL  string   // 区块浏览器地址
-	NativeSymbol string   // 原生币符号
-	IsTron       bool
-	IsEVM        bool
+	Name          string                // 标准名称: BSC, EVM, POLYGON, TRON
+	DisplayName   string                // 显示名称
+	ChainID       int64                 // EVM链ID (0=TRON)
+	ChainIDHex    string                // 十六进制链ID
+	USDTContract  string                // USDT合约地址
+	Decimals      int                   // USDT精度
+	RpcURLs       []string              // RPC节点列表
+	ExplorerURL   string                // 区块浏览器地址
+	NativeSymbol  string                // 原生币符号
+	Confirmations int                   // 入账所需确认数，0 表示不等待确认
+	GasAlert      float64               // 原生币余额低于该值时告警
+	Tokens        map[string]*TokenInfo // 已启用的代币，按符号索引
+	IsTron        bool
+	IsEVM         bool
 }
 
 // 已注册的链配置（启动时从config初始化）
@@ -26,58 +29,69 @@ var registry map[string]*ChainInfo
 func InitRegistry() {
 	registry = map[string]*ChainInfo{
 		ChainBsc: {
-			Name:         ChainBsc,
-			DisplayName:  "BNB Smart Chain",
-			ChainID:      56,
-			ChainIDHex:   "0x38",
-			USDTContract: config.GetBscUsdtContract(),
-			Decimals:     config.GetBscUsdtDecimals(),
-			RpcURLs:      config.GetBscRpcUrls(),
-			ExplorerURL:  "https://bscscan.com",
-			NativeSymbol: "BNB",
-			IsTron:       false,
-			IsEVM:        true,
+			Name:          ChainBsc,
+			DisplayName:   "BNB Smart Chain",
+			ChainID:       56,
+			ChainIDHex:    "0x38",
+			USDTContract:  config.GetBscUsdtContract(),
+			Decimals:      config.GetBscUsdtDecimals(),
+			RpcURLs:       config.GetBscRpcUrls(),
+			ExplorerURL:   "https://bscscan.com",
+			NativeSymbol:  "BNB",
+			Confirmations: config.GetChainConfirmations("bsc", 15),
+			GasAlert:      config.GetGasAlertThreshold("bsc", 0.005),
+			IsTron:        false,
+			IsEVM:         true,
 		},
 		ChainEvm: {
-			Name:         ChainEvm,
-			DisplayName:  "Ethereum",
-			ChainID:      1,
-			ChainIDHex:   "0x1",
-			USDTContract: config.GetEthUsdtContract(),
-			Decimals:     config.GetEthUsdtDecimals(),
-			RpcURLs:      config.GetEthRpcUrls(),
-			ExplorerURL:  "https://etherscan.io",
-			NativeSymbol: "ETH",
-			IsTron:       false,
-			IsEVM:        true,
+			Name:          ChainEvm,
+			DisplayName:   "Ethereum",
+			ChainID:       1,
+			ChainIDHex:    "0x1",
+			USDTContract:  config.GetEthUsdtContract(),
+			Decimals:      config.GetEthUsdtDecimals(),
+			RpcURLs:       config.GetEthRpcUrls(),
+			ExplorerURL:   "https://etherscan.io",
+			NativeSymbol:  "ETH",
+			Confirmations: config.GetChainConfirmations("eth", 12),
+			GasAlert:      config.GetGasAlertThreshold("eth", 0.005),
+			IsTron:        false,
+			IsEVM:         true,
 		},
 		ChainPolygon: {
-			Name:         ChainPolygon,
-			DisplayName:  "Polygon",
-			ChainID:      137,
-			ChainIDHex:   "0x89",
-			USDTContract: config.GetPolygonUsdtContract(),
-			Decimals:     config.GetPolygonUsdtDecimals(),
-			RpcURLs:      config.GetPolygonRpcUrls(),
-			ExplorerURL:  "https://polygonscan.com",
-			NativeSymbol: "POL",
-			IsTron:       false,
-			IsEVM:        true,
+			Name:          ChainPolygon,
+			DisplayName:   "Polygon",
+			ChainID:       137,
+			ChainIDHex:    "0x89",
+			USDTContract:  config.GetPolygonUsdtContract(),
+			Decimals:      config.GetPolygonUsdtDecimals(),
+			RpcURLs:       config.GetPolygonRpcUrls(),
+			ExplorerURL:   "https://polygonscan.com",
+			NativeSymbol:  "POL",
+			Confirmations: config.GetChainConfirmations("polygon", 64),
+			GasAlert:      config.GetGasAlertThreshold("polygon", 1),
+			IsTron:        false,
+			IsEVM:         true,
 		},
 		ChainTron: {
-			Name:         ChainTron,
-			DisplayName:  "TRON",
-			ChainID:      0,
-			ChainIDHex:   "",
-			USDTContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
-			Decimals:     6,
-			RpcURLs:      []string{"https://api.trongrid.io"},
-			ExplorerURL:  "https://tronscan.org",
-			NativeSymbol: "TRX",
-			IsTron:       true,
-			IsEVM:        false,
+			Name:          ChainTron,
+			DisplayName:   "TRON",
+			ChainID:       0,
+			ChainIDHex:    "",
+			USDTContract:  "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			Decimals:      6,
+			RpcURLs:       []string{"https://api.trongrid.io"},
+			ExplorerURL:   "https://tronscan.org",
+			NativeSymbol:  "TRX",
+			Confirmations: config.GetChainConfirmations("tron", 19),
+			GasAlert:      config.GetGasAlertThreshold("tron", 50),
+			IsTron:        true,
+			IsEVM:         false,
 		},
 	}
+	for name, info := range registry {
+		info.Tokens = buildTokens(name, info.USDTContract, info.Decimals)
+	}
 }
 
 // GetChainInfo 获取链配置信息
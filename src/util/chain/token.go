@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/assimon/luuu/config"
+)
+
+const (
+	TokenUsdt = "USDT"
+	TokenUsdc = "USDC"
+)
+
+// TokenInfo 代币配置信息
+type TokenInfo struct {
+	Symbol   string // 代币符号
+	Contract string // 合约地址
+	Decimals int    // 精度
+}
+
+// builtinTokens 内置的非USDT稳定币合约，可通过 <链前缀>_<代币>_contract / _decimals 覆盖或新增
+var builtinTokens = map[string]map[string]TokenInfo{
+	ChainBsc: {
+		TokenUsdc: {Contract: "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", Decimals: 18},
+	},
+	ChainEvm: {
+		TokenUsdc: {Contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: 6},
+	},
+	ChainPolygon: {
+		TokenUsdc: {Contract: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", Decimals: 6},
+	},
+	ChainTron: {
+		TokenUsdc: {Contract: "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8", Decimals: 6},
+	},
+}
+
+// ConfigPrefix 链在配置项中的前缀
+func ConfigPrefix(chainName string) string {
+	switch NormalizeChain(chainName) {
+	case ChainEvm:
+		return "eth"
+	default:
+		return strings.ToLower(NormalizeChain(chainName))
+	}
+}
+
+// NormalizeTokenSymbol 规范化代币符号，未填写时为 USDT
+func NormalizeTokenSymbol(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return TokenUsdt
+	}
+	return symbol
+}
+
+// buildTokens 按启用列表构建链上代币表，USDT 沿用原有合约配置
+func buildTokens(chainName, usdtContract string, usdtDecimals int) map[string]*TokenInfo {
+	tokens := map[string]*TokenInfo{}
+	prefix := ConfigPrefix(chainName)
+	for _, symbol := range config.GetEnabledTokenSymbols() {
+		info := builtinTokens[chainName][symbol]
+		if symbol == TokenUsdt {
+			info = TokenInfo{Contract: usdtContract, Decimals: usdtDecimals}
+		}
+		if contract := config.GetTokenContract(prefix, symbol); contract != "" {
+			info.Contract = contract
+		}
+		if decimals := config.GetTokenDecimals(prefix, symbol); decimals > 0 {
+			info.Decimals = decimals
+		}
+		if info.Contract == "" || info.Decimals <= 0 {
+			continue
+		}
+		info.Symbol = symbol
+		tokens[symbol] = &info
+	}
+	return tokens
+}
+
+// GetTokenInfo 获取链上已启用的代币配置
+func GetTokenInfo(chainName, symbol string) *TokenInfo {
+	info := GetChainInfo(chainName)
+	if info == nil {
+		return nil
+	}
+	return info.Tokens[NormalizeTokenSymbol(symbol)]
+}
+
+// GetEnabledTokens 获取链上所有已启用的代币（按符号排序）
+func GetEnabledTokens(chainName string) []*TokenInfo {
+	info := GetChainInfo(chainName)
+	if info == nil {
+		return nil
+	}
+	result := make([]*TokenInfo, 0, len(info.Tokens))
+	for _, token := range info.Tokens {
+		result = append(result, token)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// IsTokenEnabled 链上是否启用了该代币
+func IsTokenEnabled(chainName, symbol string) bool {
+	return GetTokenInfo(chainName, symbol) != nil
+}
@@ -12,6 +12,8 @@ var Errno = map[int]string{
 	10007: "订单区块已处理",
 	10008: "订单不存在",
 	10009: "无法解析请求参数",
+	10010: "不支持的法币币种",
+	10011: "订单已选择支付网络",
 }
 
 var (
@@ -26,6 +28,8 @@ var (
 	OrderBlockAlreadyProcess   = Err(10007)
 	OrderNotExists             = Err(10008)
 	ParamsMarshalErr           = Err(10009)
+	CurrencyNotSupportErr      = Err(10010)
+	OrderChainAlreadySelected  = Err(10011)
 )
 
 type RspError struct {
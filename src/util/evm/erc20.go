@@ -7,6 +7,13 @@ import (
 )
 
 const erc20ABIJson = `[
+  {
+    "constant": true,
+    "inputs": [{"name": "owner", "type": "address"}],
+    "name": "balanceOf",
+    "outputs": [{"name": "", "type": "uint256"}],
+    "type": "function"
+  },
   {
     "constant": true,
     "inputs": [
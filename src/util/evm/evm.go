@@ -35,14 +35,14 @@ var (
 	rrIndexByChain = map[string]int{}
 )
 
-func GetAllowance(chainName, owner, spender string) (float64, error) {
-	cfg, err := getChainConfig(chainName)
+func GetAllowance(chainName, tokenSymbol, owner, spender string) (decimal.Decimal, error) {
+	cfg, err := getTokenChainConfig(chainName, tokenSymbol)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 	client, err := dial(cfg)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 	defer client.Close()
 
@@ -52,7 +52,7 @@ func GetAllowance(chainName, owner, spender string) (float64, error) {
 
 	data, err := erc20ABI.Pack("allowance", ownerAddr, spenderAddr)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
@@ -64,24 +64,24 @@ func GetAllowance(chainName, owner, spender string) (float64, error) {
 	}
 	output, err := client.CallContract(ctx, msg, nil)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	results, err := erc20ABI.Unpack("allowance", output)
 	if err != nil || len(results) == 0 {
-		return 0, errors.New("allowance解析失败")
+		return decimal.Zero, errors.New("allowance解析失败")
 	}
 
 	val, ok := results[0].(*big.Int)
 	if !ok {
-		return 0, errors.New("allowance类型错误")
+		return decimal.Zero, errors.New("allowance类型错误")
 	}
 
 	return ToDecimalAmount(val, cfg.Decimals), nil
 }
 
-func TransferFrom(chainName, privateKeyHex, from, to string, amount float64) (string, error) {
-	cfg, err := getChainConfig(chainName)
+func TransferFrom(chainName, tokenSymbol, privateKeyHex, from, to string, amount decimal.Decimal) (string, error) {
+	cfg, err := getTokenChainConfig(chainName, tokenSymbol)
 	if err != nil {
 		return "", err
 	}
@@ -103,7 +103,7 @@ func TransferFrom(chainName, privateKeyHex, from, to string, amount float64) (st
 
 	// spender 是签名者（商家钱包），转入地址可能是公司钱包
 
-	value := fromDecimalAmount(amount, cfg.Decimals)
+	value := ToBaseUnits(amount, cfg.Decimals)
 	data, err := erc20ABI.Pack("transferFrom", fromAddr, toAddr, value)
 	if err != nil {
 		return "", err
@@ -189,8 +189,8 @@ func TransferFrom(chainName, privateKeyHex, from, to string, amount float64) (st
 }
 
 // Transfer 执行 ERC20 transfer（从签名者钱包直接转账到目标地址）
-func Transfer(chainName, privateKeyHex, to string, amount float64) (string, error) {
-	cfg, err := getChainConfig(chainName)
+func Transfer(chainName, tokenSymbol, privateKeyHex, to string, amount decimal.Decimal) (string, error) {
+	cfg, err := getTokenChainConfig(chainName, tokenSymbol)
 	if err != nil {
 		return "", err
 	}
@@ -209,7 +209,7 @@ func Transfer(chainName, privateKeyHex, to string, amount float64) (string, erro
 	contractAddr := common.HexToAddress(cfg.TokenAddress)
 	senderAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
 
-	value := fromDecimalAmount(amount, cfg.Decimals)
+	value := ToBaseUnits(amount, cfg.Decimals)
 	txData, err := erc20ABI.Pack("transfer", toAddr, value)
 	if err != nil {
 		return "", err
@@ -293,33 +293,42 @@ func getChainConfig(chainName string) (*chainConfig, error) {
 	switch chainName {
 	case chain.ChainEvm:
 		return &chainConfig{
-			Name:         "ETH",
-			ChainID:      1,
-			RpcUrls:      config.GetEthRpcUrls(),
-			TokenAddress: config.GetEthUsdtContract(),
-			Decimals:     config.GetEthUsdtDecimals(),
+			Name:    "ETH",
+			ChainID: 1,
+			RpcUrls: config.GetEthRpcUrls(),
 		}, nil
 	case chain.ChainBsc:
 		return &chainConfig{
-			Name:         "BSC",
-			ChainID:      56,
-			RpcUrls:      config.GetBscRpcUrls(),
-			TokenAddress: config.GetBscUsdtContract(),
-			Decimals:     config.GetBscUsdtDecimals(),
+			Name:    "BSC",
+			ChainID: 56,
+			RpcUrls: config.GetBscRpcUrls(),
 		}, nil
 	case chain.ChainPolygon:
 		return &chainConfig{
-			Name:         "POLYGON",
-			ChainID:      137,
-			RpcUrls:      config.GetPolygonRpcUrls(),
-			TokenAddress: config.GetPolygonUsdtContract(),
-			Decimals:     config.GetPolygonUsdtDecimals(),
+			Name:    "POLYGON",
+			ChainID: 137,
+			RpcUrls: config.GetPolygonRpcUrls(),
 		}, nil
 	default:
 		return nil, errors.New("不支持的链")
 	}
 }
 
+// getTokenChainConfig 获取链配置并附带指定代币的合约与精度
+func getTokenChainConfig(chainName, tokenSymbol string) (*chainConfig, error) {
+	cfg, err := getChainConfig(chainName)
+	if err != nil {
+		return nil, err
+	}
+	token := chain.GetTokenInfo(chainName, tokenSymbol)
+	if token == nil {
+		return nil, errors.New("该链未启用此代币")
+	}
+	cfg.TokenAddress = token.Contract
+	cfg.Decimals = token.Decimals
+	return cfg, nil
+}
+
 func dial(cfg *chainConfig) (*ethclient.Client, error) {
 	if len(cfg.RpcUrls) == 0 {
 		return nil, errors.New("未配置RPC节点")
@@ -341,18 +350,15 @@ func pickRpcUrl(chainName string, urls []string) string {
 	return chosen
 }
 
-func ToDecimalAmount(val *big.Int, decimals int) float64 {
+// ToDecimalAmount 链上最小单位转为代币金额
+func ToDecimalAmount(val *big.Int, decimals int) decimal.Decimal {
 	if val == nil {
-		return 0
+		return decimal.Zero
 	}
-	d := decimal.NewFromBigInt(val, 0)
-	scale := decimal.NewFromInt(1).Shift(int32(decimals))
-	return d.Div(scale).InexactFloat64()
+	return decimal.NewFromBigInt(val, -int32(decimals))
 }
 
-func fromDecimalAmount(amount float64, decimals int) *big.Int {
-	d := decimal.NewFromFloat(amount)
-	scale := decimal.NewFromInt(1).Shift(int32(decimals))
-	val := d.Mul(scale).BigInt()
-	return val
+// ToBaseUnits 代币金额转为链上最小单位，超出精度的部分舍去
+func ToBaseUnits(amount decimal.Decimal, decimals int) *big.Int {
+	return amount.Shift(int32(decimals)).Truncate(0).BigInt()
 }
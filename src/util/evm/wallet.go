@@ -0,0 +1,226 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// nativeDecimals 原生币精度（ETH/BNB/POL 均为 18 位）
+const nativeDecimals = 18
+
+// nativeTransferGas 原生币转账固定消耗的 gas
+const nativeTransferGas = 21000
+
+// 链上交易状态
+const (
+	TxStatusPending = iota // 未上链或未出块
+	TxStatusSuccess
+	TxStatusFailed
+)
+
+// GetTokenBalance 查询地址的代币余额
+func GetTokenBalance(chainName, tokenSymbol, owner string) (decimal.Decimal, error) {
+	cfg, err := getTokenChainConfig(chainName, tokenSymbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer client.Close()
+
+	data, err := erc20ABI.Pack("balanceOf", common.HexToAddress(owner))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	contractAddr := common.HexToAddress(cfg.TokenAddress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	output, err := client.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	results, err := erc20ABI.Unpack("balanceOf", output)
+	if err != nil || len(results) == 0 {
+		return decimal.Zero, errors.New("balanceOf解析失败")
+	}
+	val, ok := results[0].(*big.Int)
+	if !ok {
+		return decimal.Zero, errors.New("balanceOf类型错误")
+	}
+	return ToDecimalAmount(val, cfg.Decimals), nil
+}
+
+// GetNativeBalance 查询地址的原生币余额
+func GetNativeBalance(chainName, owner string) (decimal.Decimal, error) {
+	cfg, err := getChainConfig(chainName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	val, err := client.BalanceAt(ctx, common.HexToAddress(owner), nil)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ToDecimalAmount(val, nativeDecimals), nil
+}
+
+// EstimateTransferFee 估算从 from 转出代币需要的原生币手续费，gas 上浮 20%
+func EstimateTransferFee(chainName, tokenSymbol, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	cfg, err := getTokenChainConfig(chainName, tokenSymbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer client.Close()
+
+	txData, err := erc20ABI.Pack("transfer", common.HexToAddress(to), ToBaseUnits(amount, cfg.Decimals))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	contractAddr := common.HexToAddress(cfg.TokenAddress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	gasLimit, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From: common.HexToAddress(from),
+		To:   &contractAddr,
+		Data: txData,
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	gasLimit = gasLimit + gasLimit/5
+
+	estimator := NewGasEstimator(client, cfg.ChainID)
+	gasPrice, err := estimator.EstimateOptimalGasPrice()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if maxFee, _, err := estimator.EstimateEIP1559Fees(); err == nil && maxFee != nil && maxFee.Cmp(gasPrice) > 0 {
+		gasPrice = maxFee
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+	return ToDecimalAmount(fee, nativeDecimals), nil
+}
+
+// TransferNative 原生币转账（用于补充 gas）
+func TransferNative(chainName, privateKeyHex, to string, amount decimal.Decimal) (string, error) {
+	cfg, err := getChainConfig(chainName)
+	if err != nil {
+		return "", err
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return "", errors.New("私钥格式错误")
+	}
+	senderAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	toAddr := common.HexToAddress(to)
+	value := ToBaseUnits(amount, nativeDecimals)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	nonce, err := client.PendingNonceAt(ctx, senderAddr)
+	if err != nil {
+		return "", err
+	}
+
+	estimator := NewGasEstimator(client, cfg.ChainID)
+	var tx *types.Transaction
+	if maxFee, maxPriorityFee, err := estimator.EstimateEIP1559Fees(); err == nil && maxFee != nil {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(cfg.ChainID),
+			Nonce:     nonce,
+			To:        &toAddr,
+			Value:     value,
+			Gas:       nativeTransferGas,
+			GasFeeCap: maxFee,
+			GasTipCap: maxPriorityFee,
+		})
+	} else {
+		gasPrice, err := estimator.EstimateOptimalGasPrice()
+		if err != nil {
+			return "", err
+		}
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &toAddr,
+			Value:    value,
+			Gas:      nativeTransferGas,
+			GasPrice: gasPrice,
+		})
+	}
+
+	signer := types.LatestSignerForChainID(big.NewInt(cfg.ChainID))
+	signedTx, err := types.SignTx(tx, signer, privateKey)
+	if err != nil {
+		return "", err
+	}
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		return "", err
+	}
+	return signedTx.Hash().Hex(), nil
+}
+
+// GetTransactionStatus 查询交易回执状态，未出块时返回 TxStatusPending
+func GetTransactionStatus(chainName, txHash string) (int, error) {
+	cfg, err := getChainConfig(chainName)
+	if err != nil {
+		return TxStatusPending, err
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return TxStatusPending, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return TxStatusPending, nil
+	}
+	if err != nil {
+		return TxStatusPending, err
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return TxStatusSuccess, nil
+	}
+	return TxStatusFailed, nil
+}
+
+// AddressFromPrivateKey 私钥对应的地址
+func AddressFromPrivateKey(privateKeyHex string) (string, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return "", errors.New("私钥格式错误")
+	}
+	return crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), nil
+}
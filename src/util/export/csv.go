@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type csvWriter struct {
+	out     io.Writer
+	w       *csv.Writer
+	started bool
+	cells   []string
+}
+
+func newCsvWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), out: w}
+}
+
+func (c *csvWriter) Write(row []interface{}) error {
+	if !c.started {
+		// 带 BOM，Excel 打开中文不乱码
+		if _, err := c.out.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+		c.started = true
+	}
+	c.cells = c.cells[:0]
+	for _, v := range row {
+		c.cells = append(c.cells, csvCell(v))
+	}
+	return c.w.Write(c.cells)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvCell 单元格文本，以公式字符开头的字符串加单引号，防止在表格软件中被执行
+func csvCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+			return "'" + val
+		}
+		return val
+	case float64:
+		return formatFloat(val)
+	case decimal.Decimal:
+		return val.String()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"errors"
+	"io"
+)
+
+// 导出格式
+const (
+	FormatCsv  = "csv"
+	FormatXlsx = "xlsx"
+)
+
+// Writer 逐行写出表格，写完后必须调用 Close
+type Writer interface {
+	// Write 写入一行，单元格支持 string、整数与浮点数
+	Write(row []interface{}) error
+	Close() error
+}
+
+// NewWriter 按格式创建流式写出器，数据直接写入 w 不在内存中累积
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCsv, "":
+		return newCsvWriter(w), nil
+	case FormatXlsx:
+		return newXlsxWriter(w)
+	}
+	return nil, errors.New("不支持的导出格式")
+}
+
+// ContentType 导出文件的 MIME 类型
+func ContentType(format string) string {
+	if format == FormatXlsx {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
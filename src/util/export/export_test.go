@@ -0,0 +1,57 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCsvWriter 测试 CSV 导出
+func TestCsvWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCsv, &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write([]interface{}{"交易号", "金额"}))
+	assert.NoError(t, w.Write([]interface{}{"EP001", 12.5}))
+	assert.NoError(t, w.Write([]interface{}{"=cmd()", int64(3)}))
+	assert.NoError(t, w.Write([]interface{}{"EP002", decimal.RequireFromString("0.100000")}))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "\xEF\xBB\xBF交易号,金额\nEP001,12.5\n'=cmd(),3\nEP002,0.1\n", buf.String())
+}
+
+// TestXlsxWriter 测试 XLSX 导出为合法压缩包且包含单元格内容
+func TestXlsxWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXlsx, &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write([]interface{}{"交易号", "金额"}))
+	assert.NoError(t, w.Write([]interface{}{"a<b", 12.5, int64(3), nil, decimal.RequireFromString("7.250000")}))
+	assert.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+	assert.Len(t, zr.File, 5)
+	assert.Contains(t, sheet, `<t xml:space="preserve">交易号</t>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">a&lt;b</t>`)
+	assert.Contains(t, sheet, `<c><v>12.5</v></c><c><v>3</v></c><c/><c><v>7.25</v></c>`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+// TestUnsupportedFormat 不支持的格式
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/assimon/luuu/util/chain"
+	"github.com/assimon/luuu/util/tron"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	CoinTypeEvm  = 60  // BIP44 以太坊系币种编号（BSC/ETH/Polygon 共用）
+	CoinTypeTron = 195 // BIP44 TRON 币种编号
+
+	NetworkEvm  = "EVM"
+	NetworkTron = "TRON"
+)
+
+// NetworkOf 链所属的派生网络，EVM 系链共用同一组地址
+func NetworkOf(chainName string) string {
+	if chain.IsTronChain(chainName) {
+		return NetworkTron
+	}
+	return NetworkEvm
+}
+
+// CoinTypeOf 链对应的 BIP44 币种编号
+func CoinTypeOf(chainName string) uint32 {
+	if chain.IsTronChain(chainName) {
+		return CoinTypeTron
+	}
+	return CoinTypeEvm
+}
+
+// DerivationPath 收款地址的完整派生路径 m/44'/coin'/0'/0/index
+func DerivationPath(chainName string, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/0'/0/%d", CoinTypeOf(chainName), index)
+}
+
+// DeriveAddress 通过账户级扩展公钥(m/44'/coin'/0')派生外部链第 index 个收款地址
+func DeriveAddress(xpub, chainName string, index uint32) (string, error) {
+	if !chain.IsSupported(chainName) {
+		return "", errors.New("不支持的链")
+	}
+	if index >= hdkeychain.HardenedKeyStart {
+		return "", errors.New("派生索引超出范围")
+	}
+	account, err := hdkeychain.NewKeyFromString(strings.TrimSpace(xpub))
+	if err != nil {
+		return "", fmt.Errorf("扩展公钥无效: %w", err)
+	}
+	external, err := account.Child(0)
+	if err != nil {
+		return "", err
+	}
+	child, err := external.Child(index)
+	if err != nil {
+		return "", err
+	}
+	pubKey, err := child.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	address := crypto.PubkeyToAddress(*pubKey.ToECDSA())
+	if chain.IsTronChain(chainName) {
+		return tron.EncodeAddress(address.Bytes()), nil
+	}
+	return address.Hex(), nil
+}
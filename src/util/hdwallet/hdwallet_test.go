@@ -0,0 +1,62 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/assimon/luuu/util/chain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+)
+
+// 助记词 "abandon abandon ... about"（无密码）对应的种子
+const testSeed = "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
+
+// accountXpub 由测试种子计算 m/44'/coin'/0' 的扩展公钥
+func accountXpub(t *testing.T, coinType uint32) string {
+	seed, err := hex.DecodeString(testSeed)
+	assert.NoError(t, err)
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	assert.NoError(t, err)
+	for _, i := range []uint32{44, coinType, 0} {
+		key, err = key.Child(hdkeychain.HardenedKeyStart + i)
+		assert.NoError(t, err)
+	}
+	pub, err := key.Neuter()
+	assert.NoError(t, err)
+	return pub.String()
+}
+
+// TestDeriveAddress 测试 BIP44 标准向量
+func TestDeriveAddress(t *testing.T) {
+	addr, err := DeriveAddress(accountXpub(t, CoinTypeEvm), chain.ChainBsc, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", addr)
+
+	addr, err = DeriveAddress(accountXpub(t, CoinTypeTron), chain.ChainTron, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH", addr)
+
+	next, err := DeriveAddress(accountXpub(t, CoinTypeTron), chain.ChainTron, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, addr, next)
+}
+
+// TestDeriveAddressInvalid 测试非法参数
+func TestDeriveAddressInvalid(t *testing.T) {
+	_, err := DeriveAddress("invalid", chain.ChainTron, 0)
+	assert.Error(t, err)
+
+	_, err = DeriveAddress(accountXpub(t, CoinTypeEvm), "SOL", 0)
+	assert.Error(t, err)
+
+	_, err = DeriveAddress(accountXpub(t, CoinTypeEvm), chain.ChainEvm, hdkeychain.HardenedKeyStart)
+	assert.Error(t, err)
+}
+
+// TestDerivationPath 测试派生路径
+func TestDerivationPath(t *testing.T) {
+	assert.Equal(t, "m/44'/195'/0'/0/7", DerivationPath(chain.ChainTron, 7))
+	assert.Equal(t, "m/44'/60'/0'/0/7", DerivationPath(chain.ChainPolygon, 7))
+}
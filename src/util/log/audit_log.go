@@ -25,6 +25,12 @@ const (
 	// 扣款相关
 	EventDeduct             AuditEvent = "deduct"
 	EventDeductFailed       AuditEvent = "deduct_failed"
+	EventSubscriptionReset  AuditEvent = "subscription_billing_reset"
+
+	// 资金相关
+	EventBalanceAdjust      AuditEvent = "balance_adjust"
+	EventFeeScheduleChange  AuditEvent = "fee_schedule_change"
+	EventWalletConfig       AuditEvent = "wallet_config_change"
 
 	// 安全相关
 	EventPrivateKeyAccess   AuditEvent = "private_key_access"
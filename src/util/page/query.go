@@ -0,0 +1,45 @@
+package page
+
+import "strings"
+
+// Query 列表分页与排序参数
+// 传入 Cursor 时按上一页最后一条记录的ID翻页（游标分页），不再统计总数；否则按 Page 偏移分页
+type Query struct {
+	Page     int    `query:"page"`      // 页码
+	PageSize int    `query:"page_size"` // 每页行数
+	Cursor   uint64 `query:"cursor"`    // 游标，上一页返回的 next_cursor
+	Sort     string `query:"sort"`      // 排序字段，前缀 - 表示倒序，如 -created_at
+}
+
+// Normalize 补全默认值，每页行数不超过 MaxPageSize
+func (q *Query) Normalize(defaultPageSize int) {
+	if q.Page <= 0 {
+		q.Page = DefaultPage
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultPageSize
+	}
+	if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+}
+
+// Offset 偏移分页的起始行
+func (q *Query) Offset() int {
+	if q.Page <= 0 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// SortField 解析排序字段，未指定时按 id 倒序
+func (q *Query) SortField() (field string, desc bool) {
+	sort := strings.TrimSpace(q.Sort)
+	if sort == "" {
+		return "id", true
+	}
+	if strings.HasPrefix(sort, "-") {
+		return strings.TrimPrefix(sort, "-"), true
+	}
+	return strings.TrimPrefix(sort, "+"), false
+}
@@ -0,0 +1,38 @@
+package page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryNormalize(t *testing.T) {
+	q := &Query{}
+	q.Normalize(50)
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, 50, q.PageSize)
+	assert.Equal(t, 0, q.Offset())
+
+	q = &Query{Page: 3, PageSize: 1000}
+	q.Normalize(50)
+	assert.Equal(t, MaxPageSize, q.PageSize)
+	assert.Equal(t, 2*MaxPageSize, q.Offset())
+}
+
+func TestQuerySortField(t *testing.T) {
+	cases := []struct {
+		sort  string
+		field string
+		desc  bool
+	}{
+		{"", "id", true},
+		{"-created_at", "created_at", true},
+		{"amount", "amount", false},
+		{"+amount", "amount", false},
+	}
+	for _, c := range cases {
+		field, desc := (&Query{Sort: c.sort}).SortField()
+		assert.Equal(t, c.field, field, c.sort)
+		assert.Equal(t, c.desc, desc, c.sort)
+	}
+}
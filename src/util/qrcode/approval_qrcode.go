@@ -7,6 +7,7 @@ import (
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/util/chain"
+	"github.com/shopspring/decimal"
 	"github.com/skip2/go-qrcode"
 )
 
@@ -20,18 +21,22 @@ type QRCodeData struct {
 }
 
 // GenerateApprovalQRCode 生成授权二维码（兼容主流钱包App）
-func GenerateApprovalQRCode(chainName, merchantWallet string, amountUsdt float64, authNo string) (*QRCodeData, error) {
+func GenerateApprovalQRCode(chainName, tokenSymbol, merchantWallet string, amountUsdt decimal.Decimal, authNo string) (*QRCodeData, error) {
 	chainName = chain.NormalizeChain(chainName)
 	info := chain.GetChainInfo(chainName)
 	if info == nil {
 		return nil, fmt.Errorf("不支持的链: %s", chainName)
 	}
+	token := chain.GetTokenInfo(chainName, tokenSymbol)
+	if token == nil {
+		return nil, fmt.Errorf("%s链未启用代币: %s", chainName, tokenSymbol)
+	}
 
 	var qrData *QRCodeData
 	var err error
 
 	if info.IsEVM {
-		qrData, err = generateEVMApprovalQR(info, merchantWallet, amountUsdt)
+		qrData, err = generateEVMApprovalQR(info, token, merchantWallet, amountUsdt)
 	} else if info.IsTron {
 		qrData, err = generateTronApprovalQR(merchantWallet, amountUsdt, authNo)
 	} else {
@@ -55,13 +60,12 @@ func GenerateApprovalQRCode(chainName, merchantWallet string, amountUsdt float64
 // generateEVMApprovalQR 生成 EIP-681 格式二维码
 // 兼容: MetaMask, Trust Wallet, imToken, TokenPocket, Coinbase Wallet
 // 格式: ethereum:<contract>@<chainId>/approve?address=<spender>&uint256=<amount>
-func generateEVMApprovalQR(info *chain.ChainInfo, merchantWallet string, amountUsdt float64) (*QRCodeData, error) {
-	// 使用 big.Int 精确计算金额，避免浮点误差
-	amountWei := UsdtToWei(amountUsdt, info.Decimals)
+func generateEVMApprovalQR(info *chain.ChainInfo, token *chain.TokenInfo, merchantWallet string, amountUsdt decimal.Decimal) (*QRCodeData, error) {
+	amountWei := UsdtToWei(amountUsdt, token.Decimals)
 
 	// 构建 EIP-681 URI
 	uri := fmt.Sprintf("ethereum:%s@%d/approve?address=%s&uint256=%s",
-		info.USDTContract,
+		token.Contract,
 		info.ChainID,
 		merchantWallet,
 		amountWei.String(),
@@ -71,13 +75,13 @@ func generateEVMApprovalQR(info *chain.ChainInfo, merchantWallet string, amountU
 		Format:      "eip681",
 		URI:         uri,
 		FallbackURL: "",
-		Description: fmt.Sprintf("请使用钱包App扫描此二维码，授权 %.4f USDT (%s链)", amountUsdt, info.DisplayName),
+		Description: fmt.Sprintf("请使用钱包App扫描此二维码，授权 %s %s (%s链)", amountUsdt.StringFixed(4), token.Symbol, info.DisplayName),
 	}, nil
 }
 
 // generateTronApprovalQR 生成 TRON 授权二维码
 // TronLink不支持EIP-681，使用Web页面引导客户在TronLink内置浏览器中完成授权
-func generateTronApprovalQR(merchantWallet string, amountUsdt float64, authNo string) (*QRCodeData, error) {
+func generateTronApprovalQR(merchantWallet string, amountUsdt decimal.Decimal, authNo string) (*QRCodeData, error) {
 	appURI := config.GetAppUri()
 	if appURI == "" {
 		return nil, fmt.Errorf("未配置 app_uri，无法生成TRON授权二维码")
@@ -90,35 +94,18 @@ func generateTronApprovalQR(merchantWallet string, amountUsdt float64, authNo st
 		Format:      "tron_web",
 		URI:         uri,
 		FallbackURL: uri,
-		Description: fmt.Sprintf("请使用TronLink扫描此二维码，授权 %.4f USDT (TRON链)", amountUsdt),
+		Description: fmt.Sprintf("请使用TronLink扫描此二维码，授权 %s USDT (TRON链)", amountUsdt.StringFixed(4)),
 	}, nil
 }
 
-// UsdtToWei 将 USDT 金额转换为链上最小单位（精确计算）
-func UsdtToWei(amountUsdt float64, decimals int) *big.Int {
-	// 将浮点数转为字符串避免精度丢失
-	// 先乘以一个大数来保留小数精度，再用big.Int运算
-	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-
-	// 用 big.Float 做中间计算
-	amountFloat := new(big.Float).SetFloat64(amountUsdt)
-	multiplierFloat := new(big.Float).SetInt(multiplier)
-	result := new(big.Float).Mul(amountFloat, multiplierFloat)
-
-	// 截断为整数
-	amountWei, _ := result.Int(new(big.Int))
-	return amountWei
+// UsdtToWei 将 USDT 金额转换为链上最小单位，超出精度的部分舍去
+func UsdtToWei(amountUsdt decimal.Decimal, decimals int) *big.Int {
+	return amountUsdt.Shift(int32(decimals)).Truncate(0).BigInt()
 }
 
 // WeiToUsdt 将链上最小单位转换为 USDT 金额
-func WeiToUsdt(amountWei *big.Int, decimals int) float64 {
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	result := new(big.Float).Quo(
-		new(big.Float).SetInt(amountWei),
-		new(big.Float).SetInt(divisor),
-	)
-	f, _ := result.Float64()
-	return f
+func WeiToUsdt(amountWei *big.Int, decimals int) decimal.Decimal {
+	return decimal.NewFromBigInt(amountWei, -int32(decimals))
 }
 
 // GenerateQRCodeImage 生成纯二维码图片（返回base64编码的PNG）
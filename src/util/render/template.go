@@ -0,0 +1,52 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TemplateRenderer 基于 html/template 的页面渲染器，name 为模板文件路径
+type TemplateRenderer struct {
+	Reload bool // 每次渲染重新加载模板（调试模式）
+	mu     sync.RWMutex
+	cache  map[string]*template.Template
+}
+
+// NewTemplateRenderer 创建模板渲染器
+func NewTemplateRenderer(reload bool) *TemplateRenderer {
+	return &TemplateRenderer{
+		Reload: reload,
+		cache:  map[string]*template.Template{},
+	}
+}
+
+// Render 实现 echo.Renderer
+func (r *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	tpl, err := r.lookup(name)
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, data)
+}
+
+func (r *TemplateRenderer) lookup(name string) (*template.Template, error) {
+	if !r.Reload {
+		r.mu.RLock()
+		tpl, ok := r.cache[name]
+		r.mu.RUnlock()
+		if ok {
+			return tpl, nil
+		}
+	}
+	tpl, err := template.ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[name] = tpl
+	r.mu.Unlock()
+	return tpl, nil
+}
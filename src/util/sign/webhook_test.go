@@ -0,0 +1,25 @@
+package sign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWebhookSignature 测试事件通知签名
+func TestWebhookSignature(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":"evt_1","type":"order.paid"}`)
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("1707380000." + string(body)))
+	expected := hex.EncodeToString(h.Sum(nil))
+
+	assert.Equal(t, expected, WebhookSignature(secret, 1707380000, body))
+	// 时间戳参与签名，防止重放
+	assert.NotEqual(t, expected, WebhookSignature(secret, 1707380001, body))
+	assert.NotEqual(t, expected, WebhookSignature("whsec_other", 1707380000, body))
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"strings"
 
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // IsValidTronAddress 校验 Tron Base58Check 地址是否合法
@@ -55,3 +57,20 @@ func leftPadHex(hexStr string, length int) string {
 	}
 	return hexStr
 }
+
+// EncodeAddress 将 20 字节地址编码为 Tron Base58Check 地址
+func EncodeAddress(raw []byte) string {
+	payload := append([]byte{0x41}, raw...)
+	hash := sha256.Sum256(payload)
+	hash2 := sha256.Sum256(hash[:])
+	return base58.Encode(append(payload, hash2[:4]...))
+}
+
+// PrivateKeyToAddress 通过私钥计算 Tron 地址
+func PrivateKeyToAddress(privateKeyHex string) (string, error) {
+	privKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return "", errors.New("invalid private key")
+	}
+	return EncodeAddress(crypto.PubkeyToAddress(privKey.PublicKey).Bytes()), nil
+}
@@ -0,0 +1,30 @@
+package tron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrivateKeyToAddress 测试私钥推导 Tron 地址
+func TestPrivateKeyToAddress(t *testing.T) {
+	addr, err := PrivateKeyToAddress("0x0000000000000000000000000000000000000000000000000000000000000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC", addr)
+	assert.True(t, IsValidTronAddress(addr))
+
+	_, err = PrivateKeyToAddress("invalid")
+	assert.Error(t, err)
+}
+
+// TestEncodeAddressRoundTrip 测试地址编码与参数 hex 互转
+func TestEncodeAddressRoundTrip(t *testing.T) {
+	raw := make([]byte, 20)
+	raw[19] = 0x01
+	addr := EncodeAddress(raw)
+	assert.True(t, IsValidTronAddress(addr))
+
+	paramHex, err := AddressToHex(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000001", paramHex)
+}